@@ -0,0 +1,80 @@
+"use strict";
+
+// The JS nodes of the balancing suite (see services.go), with the NATS url as first argument:
+//   - JS_USERS brokers js-user-<n> with the user service: user.whoami returns the node;
+//   - a broker js-caller-<strategy> per strategy of STRATEGIES, with the service jscaller-<strategy>:
+//       actions returns $node.actions of the caller;
+//       fire    calls user.whoami "count" times, one call after the other, and returns the count by node;
+//       shard   calls user.whoami with each of the "keys" as SHARD_KEY param, "repeat" times, and
+//               returns the node by key. It fails when a key is answered by two nodes.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS balancer with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const users = Number(process.env["JS_USERS"] || 1);
+const strategies = (process.env["STRATEGIES"] || "RoundRobin").split(",");
+const shardKey = process.env["SHARD_KEY"] || "id";
+
+const brokers = [];
+
+for (let index = 1; index <= users; index++) {
+	const broker = new ServiceBroker({ transporter, nodeID: "js-user-" + index, logLevel: "warn" });
+	broker.createService({
+		name: "user",
+		actions: {
+			whoami() {
+				return broker.nodeID;
+			}
+		}
+	});
+	brokers.push(broker);
+}
+
+for (const strategy of strategies) {
+	const name = strategy.toLowerCase();
+	const broker = new ServiceBroker({
+		transporter,
+		nodeID: "js-caller-" + name,
+		logLevel: "warn",
+		registry: {
+			strategy,
+			strategyOptions: strategy === "Shard" ? { shardKey } : {}
+		}
+	});
+	broker.createService({
+		name: "jscaller-" + name,
+		actions: {
+			actions(ctx) {
+				return ctx.call("$node.actions", ctx.params);
+			},
+
+			async fire(ctx) {
+				const counts = {};
+				for (let call = 0; call < ctx.params.count; call++) {
+					const nodeID = await broker.call("user.whoami", {});
+					counts[nodeID] = (counts[nodeID] || 0) + 1;
+				}
+				return counts;
+			},
+
+			async shard(ctx) {
+				const nodes = {};
+				for (let call = 0; call < ctx.params.repeat; call++) {
+					for (const key of ctx.params.keys) {
+						const nodeID = await broker.call("user.whoami", { [shardKey]: key });
+						if (nodes[key] !== undefined && nodes[key] !== nodeID) {
+							throw new Error("the key " + key + " was answered by " + nodes[key] + " and " + nodeID);
+						}
+						nodes[key] = nodeID;
+					}
+				}
+				return nodes;
+			}
+		}
+	});
+	brokers.push(broker);
+}
+
+Promise.all(brokers.map(broker => broker.start()));
@@ -0,0 +1,24 @@
+package balancing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBalancing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Load Balancing Suite")
+}
+
+var _ = AfterSuite(func() {
+	if lazyCluster.Started() {
+		fmt.Print("\nSkew report\n\n" + report.Markdown())
+	}
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
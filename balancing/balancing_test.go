@@ -0,0 +1,174 @@
+package balancing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster, the AfterSuite prints the report.
+var cluster *Cluster
+var report = NewReport(UserIDs())
+
+// lazyCluster starts the user services and the JS callers once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// expectRoundRobin checks that each node answered its share of the calls, give or take one call.
+func expectRoundRobin(distribution Distribution) {
+	fair := distribution.Total() / len(UserIDs())
+	for _, nodeID := range UserIDs() {
+		Expect(distribution[nodeID]).Should(BeNumerically("~", fair, 1), fmt.Sprint(distribution))
+	}
+}
+
+// memoryBroker creates a broker on the memory transporter shared by the brokers created from mem.
+func memoryBroker(nodeID string, mem *memory.SharedMemory, config moleculer.Config) *broker.ServiceBroker {
+	config.LogLevel = "ERROR"
+	config.DiscoverNodeID = func() string {
+		return nodeID
+	}
+	config.TransporterFactory = func() interface{} {
+		return harness.MemoryTransporter(mem)()
+	}
+	return broker.New(&config)
+}
+
+var _ = Describe("Go callers", func() {
+	var mem *memory.SharedMemory
+	var users []*broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		users = nil
+		for _, nodeID := range UserIDs() {
+			user := memoryBroker(nodeID, mem, moleculer.Config{})
+			user.Publish(UserService(nodeID))
+			user.Start()
+			users = append(users, user)
+		}
+	})
+
+	AfterEach(func() {
+		for _, user := range users {
+			user.Stop()
+		}
+	})
+
+	for _, item := range Strategies {
+		strategy := item
+
+		It("should spread the calls with "+strategy.GoName+" for "+strategy.Name, func() {
+			config := moleculer.Config{}
+			if strategy.Go != nil {
+				config.StrategyFactory = strategy.Go
+			}
+			caller := memoryBroker("go-caller", mem, config)
+			caller.Start()
+			defer caller.Stop()
+			Expect(harness.WaitForEndpoints(caller, time.Now().Add(5*time.Second), "user.whoami", UserIDs()...)).Should(Succeed())
+
+			distribution, err := FireGo(caller, Calls)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(distribution.Total()).Should(Equal(Calls))
+			if strategy.GoName == "RoundRobin" {
+				expectRoundRobin(distribution)
+			} else {
+				Expect(distribution.Fair(UserIDs(), 0.25)).Should(BeTrue(), fmt.Sprint(distribution))
+			}
+		})
+	}
+
+	It("should call the node of ShardNode for each key", func() {
+		caller := memoryBroker("go-caller", mem, moleculer.Config{})
+		caller.Start()
+		defer caller.Stop()
+		Expect(harness.WaitForEndpoints(caller, time.Now().Add(5*time.Second), "user.whoami", UserIDs()...)).Should(Succeed())
+
+		keys := []string{"1", "2", "3", "42", "alice", "bob"}
+		nodes, err := ShardGo(caller, keys, 3)
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, key := range keys {
+			Expect(nodes).Should(HaveKeyWithValue(key, ShardNode(key, UserIDs())))
+		}
+	})
+
+	It("should always call the local service", func() {
+		distribution, err := FireGo(users[0], 100)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(distribution).Should(Equal(Distribution{UserIDs()[0]: 100}))
+	})
+})
+
+var _ = Describe("Load balancing with moleculer JS", func() {
+	BeforeEach(lazyCluster.Start)
+
+	for _, item := range Strategies {
+		strategy := item
+
+		It("the JS caller should spread the calls among Go and JS with "+strategy.Name, func() {
+			distribution, err := cluster.FireJS(strategy, Calls)
+			Expect(err).ShouldNot(HaveOccurred())
+			report.Add(strategy.Name, "JS", distribution)
+
+			Expect(distribution.Total()).Should(Equal(Calls))
+			for nodeID := range distribution {
+				Expect(UserIDs()).Should(ContainElement(nodeID))
+			}
+			if strategy.Name == "RoundRobin" {
+				expectRoundRobin(distribution)
+			} else if strategy.Even {
+				Expect(distribution.Fair(UserIDs(), 0.25)).Should(BeTrue(), fmt.Sprint(distribution))
+			}
+		})
+
+		It("the Go caller should spread the calls among Go and JS with "+strategy.GoName+" for "+strategy.Name, func() {
+			caller, err := cluster.GoCaller(strategy)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer caller.Stop()
+
+			distribution, err := FireGo(caller, Calls)
+			Expect(err).ShouldNot(HaveOccurred())
+			report.Add(strategy.Name, "Go ("+strategy.GoName+")", distribution)
+
+			Expect(distribution.Total()).Should(Equal(Calls))
+			if strategy.GoName == "RoundRobin" {
+				expectRoundRobin(distribution)
+			} else {
+				Expect(distribution.Fair(UserIDs(), 0.25)).Should(BeTrue(), fmt.Sprint(distribution))
+			}
+		})
+	}
+
+	It("the same shard key should land on the same node from Go and from JS", func() {
+		keys := []string{}
+		for index := 0; index < 200; index++ {
+			keys = append(keys, fmt.Sprint("user-", index))
+		}
+		fromJS, err := cluster.ShardJS(keys, 3)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		caller, err := cluster.GoCaller(Strategies[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		defer caller.Stop()
+		fromGo, err := ShardGo(caller, keys, 3)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(fromGo).Should(Equal(fromJS))
+		distribution := Distribution{}
+		for _, nodeID := range fromJS {
+			distribution[nodeID]++
+		}
+		report.Add("Shard", "JS and Go, by key", distribution)
+	})
+})
@@ -0,0 +1,223 @@
+package balancing
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+const (
+	// GoUsers is the number of Go nodes with the user service.
+	GoUsers = 2
+	// JSUsers is the number of JS nodes with the user service.
+	JSUsers = 2
+	// Calls is the number of calls each caller fires per strategy.
+	Calls = 2000
+)
+
+// FireTimeout is the RequestTimeout of the Go brokers: FireJS and ShardJS wait for a JS caller to
+// make all its calls.
+var FireTimeout = time.Minute
+
+// GoUserIDs are the nodes of the Go user services.
+func GoUserIDs() []string {
+	return nodeIDs("go-user", GoUsers)
+}
+
+// JSUserIDs are the nodes of the JS user services.
+func JSUserIDs() []string {
+	return nodeIDs("js-user", JSUsers)
+}
+
+// UserIDs are the nodes of all the user services, sorted.
+func UserIDs() []string {
+	all := append(GoUserIDs(), JSUserIDs()...)
+	sort.Strings(all)
+	return all
+}
+
+func nodeIDs(prefix string, count int) []string {
+	list := []string{}
+	for index := 1; index <= count; index++ {
+		list = append(list, fmt.Sprint(prefix, "-", index))
+	}
+	return list
+}
+
+// JSCaller is the service of balancer.js that calls the user service with strategy.
+func JSCaller(strategy Strategy) string {
+	return "jscaller-" + strings.ToLower(strategy.Name)
+}
+
+// UserService is the user service of the Go node nodeID: user.whoami returns nodeID.
+func UserService(nodeID string) moleculer.ServiceSchema {
+	return moleculer.ServiceSchema{
+		Name: "user",
+		Actions: []moleculer.Action{
+			{
+				Name: "whoami",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					return nodeID
+				},
+			},
+		},
+	}
+}
+
+// Cluster is GoUsers Go brokers with the user service and balancer.js with JSUsers user services and
+// a caller per strategy, connected over NATS.
+type Cluster struct {
+	Users []*broker.ServiceBroker
+	JS    *harness.Peer
+	url   string
+}
+
+// natsBroker creates a broker connected to the NATS server at url.
+func natsBroker(nodeID, url string, config moleculer.Config) *broker.ServiceBroker {
+	config.LogLevel = "WARN"
+	config.RequestTimeout = FireTimeout
+	config.DiscoverNodeID = func() string {
+		return nodeID
+	}
+	config.TransporterFactory = func() interface{} {
+		return harness.NATSTransporter(url)()
+	}
+	return broker.New(&config)
+}
+
+// Start starts balancer.js and the Go user services, all connected to the NATS server at url,
+// and waits until each JS caller sees all the user services.
+func Start(dir, url string) (*Cluster, error) {
+	strategies := []string{}
+	for _, strategy := range Strategies {
+		strategies = append(strategies, strategy.Name)
+	}
+	js, err := harness.StartNode(dir, "balancer.js", map[string]string{
+		"JS_USERS":   strconv.Itoa(JSUsers),
+		"STRATEGIES": strings.Join(strategies, ","),
+		"SHARD_KEY":  ShardKey,
+	}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, url: url}
+	for _, nodeID := range GoUserIDs() {
+		user := natsBroker(nodeID, url, moleculer.Config{})
+		user.Publish(UserService(nodeID))
+		user.Start()
+		cluster.Users = append(cluster.Users, user)
+	}
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	for _, strategy := range Strategies {
+		if err := harness.WaitForServices(cluster.Users[0], deadline, JSCaller(strategy)); err != nil {
+			cluster.Stop()
+			return nil, err
+		}
+		if err := harness.WaitForEndpointsIn(cluster.Users[0], deadline, JSCaller(strategy)+".actions", "user.whoami", UserIDs()...); err != nil {
+			cluster.Stop()
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+// GoCaller starts a Go broker without the user service that calls it with strategy, and waits until
+// it sees all the user services.
+func (cluster *Cluster) GoCaller(strategy Strategy) (*broker.ServiceBroker, error) {
+	config := moleculer.Config{}
+	if strategy.Go != nil {
+		config.StrategyFactory = strategy.Go
+	}
+	caller := natsBroker("go-caller-"+strings.ToLower(strategy.Name), cluster.url, config)
+	caller.Start()
+	if err := harness.WaitForEndpoints(caller, time.Now().Add(harness.StartTimeout), "user.whoami", UserIDs()...); err != nil {
+		caller.Stop()
+		return nil, err
+	}
+	return caller, nil
+}
+
+// Stop stops the Go user services and kills balancer.js.
+func (cluster *Cluster) Stop() {
+	for _, user := range cluster.Users {
+		user.Stop()
+	}
+	cluster.JS.Kill()
+}
+
+// FireGo calls user.whoami calls times from caller, one call after the other, and returns which node answered.
+func FireGo(caller *broker.ServiceBroker, calls int) (Distribution, error) {
+	distribution := Distribution{}
+	for index := 0; index < calls; index++ {
+		result := <-caller.Call("user.whoami", map[string]interface{}{})
+		if result.IsError() {
+			return distribution, result.Error()
+		}
+		distribution[result.String()]++
+	}
+	return distribution, nil
+}
+
+// FireJS asks the JS caller with strategy to call user.whoami calls times and returns which node answered.
+func (cluster *Cluster) FireJS(strategy Strategy, calls int) (Distribution, error) {
+	result := <-cluster.Users[0].Call(JSCaller(strategy)+".fire", map[string]interface{}{"count": calls})
+	if result.IsError() {
+		return nil, result.Error()
+	}
+	distribution := Distribution{}
+	for nodeID, count := range result.Map() {
+		distribution[nodeID] = count.Int()
+	}
+	return distribution, nil
+}
+
+// ShardGo calls user.whoami with each key as ShardKey param, repeat times, on the node ShardNode
+// selects, and returns which node answered by key. It fails when a key is answered by two nodes.
+func ShardGo(caller *broker.ServiceBroker, keys []string, repeat int) (map[string]string, error) {
+	nodes := map[string]string{}
+	for index := 0; index < repeat; index++ {
+		for _, key := range keys {
+			target := ShardNode(key, UserIDs())
+			result := <-caller.Call("user.whoami", map[string]interface{}{ShardKey: key}, moleculer.Options{NodeID: target})
+			if result.IsError() {
+				return nodes, result.Error()
+			}
+			if answered, exists := nodes[key]; exists && answered != result.String() {
+				return nodes, fmt.Errorf("the key %s was answered by %s and %s", key, answered, result.String())
+			}
+			nodes[key] = result.String()
+		}
+	}
+	return nodes, nil
+}
+
+// ShardJS asks the JS caller with the Shard strategy to call user.whoami with each key as ShardKey param,
+// repeat times, and returns which node answered by key. It fails when a key is answered by two nodes.
+func (cluster *Cluster) ShardJS(keys []string, repeat int) (map[string]string, error) {
+	var shard Strategy
+	for _, strategy := range Strategies {
+		if strategy.Name == "Shard" {
+			shard = strategy
+		}
+	}
+	if shard.Name == "" {
+		return nil, errors.New("the strategy table has no Shard strategy")
+	}
+	result := <-cluster.Users[0].Call(JSCaller(shard)+".shard", map[string]interface{}{"keys": keys, "repeat": repeat})
+	if result.IsError() {
+		return nil, result.Error()
+	}
+	nodes := map[string]string{}
+	for key, nodeID := range result.Map() {
+		nodes[key] = nodeID.String()
+	}
+	return nodes, nil
+}
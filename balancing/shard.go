@@ -0,0 +1,41 @@
+package balancing
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+)
+
+const (
+	// ShardKey is the shardKey of the Shard strategy on both sides: the "id" param.
+	ShardKey = "id"
+	// shardVNodes and shardRingSize are the defaults of the Shard strategy of moleculer JS.
+	shardVNodes   = 10
+	shardRingSize = 1 << 32
+)
+
+// ShardNode returns the node the Shard strategy of moleculer JS selects for key among nodeIDs.
+// moleculer-go has no Shard strategy and its strategies do not get the params of the call, so a
+// Go caller shards with ShardNode and calls the node with moleculer.Options{NodeID}.
+//
+// Like moleculer JS, the ring has shardVNodes slices per node, given to the sorted nodes in turn,
+// and the hash of a key is the first 4 bytes of its MD5.
+func ShardNode(key string, nodeIDs []string) string {
+	if len(nodeIDs) == 0 {
+		return ""
+	}
+	sorted := append([]string{}, nodeIDs...)
+	sort.Strings(sorted)
+	total := len(sorted) * shardVNodes
+	slice := uint64(shardRingSize / total)
+
+	sum := md5.Sum([]byte(key))
+	hash := uint64(binary.BigEndian.Uint32(sum[:4]))
+	for index := 0; index < total-1; index++ {
+		if hash <= slice*uint64(index+1) {
+			return sorted[index%len(sorted)]
+		}
+	}
+	// The last slice ends at the ring size.
+	return sorted[(total-1)%len(sorted)]
+}
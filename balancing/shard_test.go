@@ -0,0 +1,79 @@
+package balancing
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ShardNode", func() {
+	It("should select the node the Shard strategy of moleculer JS selects", func() {
+		// Computed with the ring of the Shard strategy of moleculer JS 0.14, default options.
+		expected := map[string]string{
+			"1":       "js-user-1",
+			"2":       "js-user-2",
+			"3":       "go-user-1",
+			"42":      "go-user-2",
+			"alice":   "js-user-2",
+			"bob":     "go-user-1",
+			"carol":   "js-user-1",
+			"user-7":  "js-user-1",
+			"user-99": "go-user-2",
+			"🚀":       "js-user-1",
+		}
+		for key, nodeID := range expected {
+			Expect(ShardNode(key, UserIDs())).Should(Equal(nodeID), key)
+		}
+	})
+
+	It("should not depend on the order of the nodes", func() {
+		reversed := []string{"js-user-2", "js-user-1", "go-user-2", "go-user-1"}
+		for index := 0; index < 100; index++ {
+			key := fmt.Sprint("user-", index)
+			Expect(ShardNode(key, reversed)).Should(Equal(ShardNode(key, UserIDs())))
+		}
+	})
+
+	It("should spread the keys among the nodes", func() {
+		distribution := Distribution{}
+		for index := 0; index < 4000; index++ {
+			distribution[ShardNode(fmt.Sprint("user-", index), UserIDs())]++
+		}
+		Expect(distribution.Fair(UserIDs(), 0.25)).Should(BeTrue(), fmt.Sprint(distribution))
+	})
+
+	It("should select no node among none", func() {
+		Expect(ShardNode("1", nil)).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Distribution", func() {
+	nodeIDs := []string{"a", "b"}
+
+	It("should have no skew when the calls are spread evenly", func() {
+		distribution := Distribution{"a": 10, "b": 10}
+		Expect(distribution.Skew(nodeIDs)).Should(Equal(0.0))
+		Expect(distribution.Fair(nodeIDs, 0)).Should(BeTrue())
+	})
+
+	It("should have the skew of the number of nodes when a single node answered", func() {
+		distribution := Distribution{"a": 20}
+		Expect(distribution.Skew(nodeIDs)).Should(Equal(2.0))
+		Expect(distribution.Fair(nodeIDs, 0.25)).Should(BeFalse())
+	})
+
+	It("should not be fair when a node that is not expected answered", func() {
+		Expect(Distribution{"a": 10, "b": 10, "c": 1}.Fair(nodeIDs, 0.25)).Should(BeFalse())
+	})
+
+	It("should report the distributions sorted by strategy and caller", func() {
+		report := NewReport(nodeIDs)
+		report.Add("Random", "JS", Distribution{"a": 9, "b": 11})
+		report.Add("Random", "Go (Random)", Distribution{"a": 10, "b": 10})
+		Expect(report.Markdown()).Should(Equal("| Strategy | Caller | a | b | Skew |\n" +
+			"|---|---|---|---|---|\n" +
+			"| Random | Go (Random) | 10 | 10 | 0.00 |\n" +
+			"| Random | JS | 9 | 11 | 0.20 |\n"))
+	})
+})
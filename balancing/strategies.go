@@ -0,0 +1,120 @@
+// Package balancing checks how calls are balanced when the same service is published by Go nodes and
+// JS nodes: GoUsers moleculer-go brokers and JSUsers moleculer JS brokers publish the user service,
+// and a Go caller and a JS caller per strategy fire Calls calls to user.whoami each.
+package balancing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moleculer-go/moleculer/strategy"
+)
+
+// Strategy is a row of the strategy table: a strategy of moleculer JS and what the Go caller uses for it.
+type Strategy struct {
+	// Name is the strategy option of the JS caller.
+	Name string
+	// Go creates the strategy of the Go caller, RandomStrategy when nil: moleculer-go has
+	// RandomStrategy and RoundRobinStrategy only.
+	Go func() interface{}
+	// GoName names the strategy of the Go caller.
+	GoName string
+	// Even is true when the JS caller spreads calls without a shard key evenly.
+	Even bool
+}
+
+// Strategies is the strategy table. CpuUsage and Latency depend on the load of the machine: the
+// distribution of the JS caller is reported, not checked. The Go caller uses RandomStrategy for
+// them, and for Shard, which Go callers use with ShardNode.
+var Strategies = []Strategy{
+	{
+		Name: "RoundRobin",
+		Go: func() interface{} {
+			return strategy.NewRoundRobinStrategy()
+		},
+		GoName: "RoundRobin",
+		Even:   true,
+	},
+	{Name: "Random", GoName: "Random", Even: true},
+	{Name: "CpuUsage", GoName: "Random"},
+	{Name: "Latency", GoName: "Random"},
+	{Name: "Shard", GoName: "Random"},
+}
+
+// Distribution is the number of calls each node answered.
+type Distribution map[string]int
+
+// Total is the number of calls of the distribution.
+func (distribution Distribution) Total() int {
+	total := 0
+	for _, count := range distribution {
+		total += count
+	}
+	return total
+}
+
+// Skew is the difference between the most and the least called of nodeIDs, relative to a fair share:
+// 0 when the calls are spread evenly, len(nodeIDs) when a single node answered them all.
+func (distribution Distribution) Skew(nodeIDs []string) float64 {
+	if len(nodeIDs) == 0 || distribution.Total() == 0 {
+		return 0
+	}
+	min, max := distribution[nodeIDs[0]], distribution[nodeIDs[0]]
+	for _, nodeID := range nodeIDs {
+		if distribution[nodeID] < min {
+			min = distribution[nodeID]
+		}
+		if distribution[nodeID] > max {
+			max = distribution[nodeID]
+		}
+	}
+	fair := float64(distribution.Total()) / float64(len(nodeIDs))
+	return float64(max-min) / fair
+}
+
+// Fair tells whether each of nodeIDs answered its share of the calls, give or take tolerance
+// (0.25 is 25% of a share).
+func (distribution Distribution) Fair(nodeIDs []string, tolerance float64) bool {
+	fair := float64(distribution.Total()) / float64(len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		count := float64(distribution[nodeID])
+		if count < fair*(1-tolerance) || count > fair*(1+tolerance) {
+			return false
+		}
+	}
+	return len(distribution) == len(nodeIDs)
+}
+
+// Report is the skew report: the distribution of each caller, by strategy.
+type Report struct {
+	nodeIDs []string
+	rows    []string
+}
+
+// NewReport creates a report on the distribution of the calls among nodeIDs.
+func NewReport(nodeIDs []string) *Report {
+	return &Report{nodeIDs: nodeIDs}
+}
+
+// Add adds the distribution of a caller with a strategy.
+func (report *Report) Add(strategy, caller string, distribution Distribution) {
+	columns := []string{strategy, caller}
+	for _, nodeID := range report.nodeIDs {
+		columns = append(columns, fmt.Sprint(distribution[nodeID]))
+	}
+	columns = append(columns, fmt.Sprintf("%.2f", distribution.Skew(report.nodeIDs)))
+	report.rows = append(report.rows, "| "+strings.Join(columns, " | ")+" |")
+}
+
+// Markdown returns the report as a markdown table, sorted by strategy and caller.
+func (report *Report) Markdown() string {
+	header := append(append([]string{"Strategy", "Caller"}, report.nodeIDs...), "Skew")
+	lines := []string{
+		"| " + strings.Join(header, " | ") + " |",
+		"|" + strings.Repeat("---|", len(header)),
+	}
+	rows := append([]string{}, report.rows...)
+	sort.Strings(rows)
+	return strings.Join(append(lines, rows...), "\n") + "\n"
+}
@@ -0,0 +1,170 @@
+package conformance
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/payload"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+)
+
+// GoNodeID is the node whose packets are captured.
+const GoNodeID = "go-node"
+
+// CaptureTimeout is how long Capture waits for the Go node to send every packet type it supports.
+var CaptureTimeout = 10 * time.Second
+
+// Unsupported are the packet types moleculer-go v0.3.10 cannot be made to send, with the reason.
+var Unsupported = map[string]string{
+	"PING":         "the Go broker has no API to ping a node",
+	"GOSSIP_HELLO": "the Go TCP transporter exchanges gossip packets below the transit layer",
+	"GOSSIP_REQ":   "the Go TCP transporter exchanges gossip packets below the transit layer",
+	"GOSSIP_RES":   "the Go TCP transporter exchanges gossip packets below the transit layer",
+}
+
+var greeterService = moleculer.ServiceSchema{
+	Name: "greeter",
+	Actions: []moleculer.Action{
+		{
+			Name: "hello",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return "Hello " + params.Get("name").String()
+			},
+		},
+	},
+	Events: []moleculer.Event{
+		{
+			Name:    "math.added",
+			Group:   "greeter",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) {},
+		},
+	},
+}
+
+var mathService = moleculer.ServiceSchema{
+	Name: "math",
+	Actions: []moleculer.Action{
+		{
+			Name: "add",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return params.Get("a").Int() + params.Get("b").Int()
+			},
+		},
+	},
+	Events: []moleculer.Event{
+		{
+			Name:    "math.added",
+			Group:   "math",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) {},
+		},
+	},
+}
+
+// Capture runs a Go node against a second Go node over the memory transporter and returns
+// the first packet of each type the Go node sent, keyed by packet type:
+// DISCOVER, INFO and HEARTBEAT while joining, REQUEST for a call to math.add, RESPONSE when
+// greeter.hello is called, EVENT for math.added, PONG for a PING sent by a raw probe and
+// DISCONNECT when it stops. All the packets of the Go node are recorded by recorder.
+func Capture(recorder *harness.PacketRecorder) (map[string]map[string]interface{}, error) {
+	logger := log.WithField("transport", "memory")
+	mem := &memory.SharedMemory{}
+	local := broker.New(&moleculer.Config{
+		LogLevel:           "ERROR",
+		HeartbeatFrequency: 100 * time.Millisecond,
+		DiscoverNodeID: func() string {
+			return GoNodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := memory.Create(logger, mem)
+			return recorder.Wrap(&transport)
+		},
+	})
+	remote := broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return "remote-node"
+		},
+		TransporterFactory: func() interface{} {
+			transport := memory.Create(logger, mem)
+			return &transport
+		},
+	})
+	local.Publish(greeterService)
+	remote.Publish(mathService)
+	local.Start()
+	remote.Start()
+	defer remote.Stop()
+
+	deadline := time.Now().Add(CaptureTimeout)
+	if err := harness.WaitForServices(local, deadline, "math"); err != nil {
+		local.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServices(remote, deadline, "greeter"); err != nil {
+		local.Stop()
+		return nil, err
+	}
+	if result := <-local.Call("math.add", map[string]interface{}{"a": 1, "b": 2}); result.IsError() {
+		local.Stop()
+		return nil, result.Error()
+	}
+	if result := <-remote.Call("greeter.hello", map[string]interface{}{"name": "JS"}); result.IsError() {
+		local.Stop()
+		return nil, result.Error()
+	}
+	local.Emit("math.added", map[string]interface{}{"a": 1, "b": 2})
+
+	probe := memory.Create(logger, mem)
+	probe.SetPrefix("MOL")
+	probe.Publish("PING", GoNodeID, payload.New(map[string]interface{}{
+		"ver":    "4",
+		"sender": "probe-node",
+		"time":   time.Now().UnixNano() / int64(time.Millisecond),
+		"id":     "probe-ping",
+	}))
+
+	expected := []string{"DISCOVER", "INFO", "HEARTBEAT", "REQUEST", "RESPONSE", "EVENT", "PONG"}
+	for !sentAll(recorder, expected) && time.Now().Before(deadline) {
+		time.Sleep(harness.PollInterval)
+	}
+	local.Stop()
+
+	packets := map[string]map[string]interface{}{}
+	for _, packet := range recorder.Packets() {
+		if packet.Direction != "out" {
+			continue
+		}
+		if _, exists := packets[packet.Type]; exists {
+			continue
+		}
+		if value, isMap := packet.Payload.(map[string]interface{}); isMap {
+			packets[packet.Type] = value
+		}
+	}
+	for _, packetType := range append(expected, "DISCONNECT") {
+		if _, exists := packets[packetType]; !exists {
+			return packets, fmt.Errorf("the Go node did not send %s, see %s", packetType, filepath.Base(recorder.Path()))
+		}
+	}
+	return packets, nil
+}
+
+func sentAll(recorder *harness.PacketRecorder, packetTypes []string) bool {
+	sent := map[string]bool{}
+	for _, packet := range recorder.Packets() {
+		if packet.Direction == "out" {
+			sent[packet.Type] = true
+		}
+	}
+	for _, packetType := range packetTypes {
+		if !sent[packetType] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package conformance
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The Go packets are captured once, by the first spec, and recorded to a file kept if a spec fails.
+var (
+	recorder   *harness.PacketRecorder
+	captured   map[string]map[string]interface{}
+	captureErr error
+	known      map[string][]string
+	failed     bool
+)
+
+var _ = AfterSuite(func() {
+	if recorder == nil {
+		return
+	}
+	recorder.Close(failed)
+	if failed {
+		fmt.Fprintln(GinkgoWriter, "packets recorded in", recorder.Path())
+	}
+})
+
+var _ = Describe("Protocol v4 packets", func() {
+	BeforeEach(func() {
+		if recorder != nil {
+			return
+		}
+		var err error
+		recorder, err = harness.NewPacketRecorder("Memory", harness.PacketFile("Conformance Go packets"))
+		Expect(err).ShouldNot(HaveOccurred())
+		captured, captureErr = Capture(recorder)
+		known, err = LoadKnownDifferences("testdata/known_differences.yaml")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		failed = failed || CurrentGinkgoTestDescription().Failed
+	})
+
+	for _, item := range PacketTypes {
+		packetType := item
+		It("should send "+packetType+" with the fields of moleculer JS", func() {
+			if reason, unsupported := Unsupported[packetType]; unsupported {
+				Skip(packetType + " is not sent by moleculer-go: " + reason)
+			}
+			Expect(captured).Should(HaveKey(packetType), fmt.Sprint(captureErr))
+			golden, err := LoadGolden("testdata/js", packetType)
+			if os.IsNotExist(err) {
+				Skip("no golden " + packetType + " packet: record them from moleculer JS with node record.js")
+			}
+			Expect(err).ShouldNot(HaveOccurred())
+
+			differences := Diff(golden, captured[packetType])
+			for _, difference := range differences {
+				fmt.Fprintln(GinkgoWriter, packetType, difference)
+			}
+			unexpected, stale := Compare(differences, known[packetType])
+			Expect(unexpected).Should(BeEmpty(), "%s differs from moleculer JS:\n%s", packetType, strings.Join(unexpected, "\n"))
+			Expect(stale).Should(BeEmpty(), "%s no longer differs from moleculer JS, remove from known_differences.yaml:\n%s", packetType, strings.Join(stale, "\n"))
+		})
+	}
+})
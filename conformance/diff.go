@@ -0,0 +1,237 @@
+// Package conformance compares the protocol packets of moleculer-go with golden packets of moleculer JS.
+package conformance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// PacketTypes are the packet types of the moleculer protocol v4.
+var PacketTypes = []string{
+	"DISCOVER", "INFO", "HEARTBEAT", "REQUEST", "RESPONSE", "EVENT",
+	"PING", "PONG", "DISCONNECT", "GOSSIP_HELLO", "GOSSIP_REQ", "GOSSIP_RES",
+}
+
+// Volatile fields change on every packet and are not compared.
+var Volatile = map[string]bool{
+	"id":      true,
+	"sender":  true,
+	"seq":     true,
+	"time":    true,
+	"arrived": true,
+}
+
+// Opaque fields hold user or configuration data: only their own type is compared, not their content.
+var Opaque = map[string]bool{
+	"params":   true,
+	"data":     true,
+	"meta":     true,
+	"config":   true,
+	"metadata": true,
+	"settings": true,
+}
+
+// Dictionaries are objects keyed by names (actions, events, node IDs): their keys are not compared,
+// the fields of all their values are compared as one.
+var Dictionaries = map[string]bool{
+	"services[].actions": true,
+	"services[].events":  true,
+	"online":             true,
+	"offline":            true,
+}
+
+// Difference is a field that is missing, extra or differently typed in the Go packet.
+type Difference struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+// String renders the difference as a line of a diff: "- needAck: null" for a missing field,
+// "+ paramsType: number" for an extra field and "~ caller: string, expected null" for a type mismatch.
+func (d Difference) String() string {
+	switch {
+	case d.Actual == "":
+		return fmt.Sprintf("- %s: %s", d.Path, d.Expected)
+	case d.Expected == "":
+		return fmt.Sprintf("+ %s: %s", d.Path, d.Actual)
+	}
+	return fmt.Sprintf("~ %s: %s, expected %s", d.Path, d.Actual, d.Expected)
+}
+
+// jsonType returns the JSON type of a decoded value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "number"
+}
+
+// normalize decodes a value again from JSON, so Go values compare as JSON values.
+func normalize(value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var result interface{}
+	if json.Unmarshal(content, &result) != nil {
+		return value
+	}
+	return result
+}
+
+// merge folds the values of an array or dictionary into one list of values per JSON type,
+// e.g. the objects of a list of services into one object with the fields of all of them.
+func merge(values []interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for _, value := range values {
+		kind := jsonType(value)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			object, _ := merged[kind].(map[string]interface{})
+			if object == nil {
+				object = map[string]interface{}{}
+			}
+			for key, item := range v {
+				if previous, exists := object[key]; exists {
+					object[key] = append(previous.([]interface{}), item)
+				} else {
+					object[key] = []interface{}{item}
+				}
+			}
+			merged[kind] = object
+		case []interface{}:
+			previous, _ := merged[kind].([]interface{})
+			merged[kind] = append(previous, v...)
+		default:
+			merged[kind] = value
+		}
+	}
+	return merged
+}
+
+// Diff compares the fields of a Go packet with a golden packet and returns the differences sorted by path.
+func Diff(golden, actual map[string]interface{}) []Difference {
+	differences := diffObject("", toFieldLists(normalize(golden)), toFieldLists(normalize(actual)))
+	sort.SliceStable(differences, func(i, j int) bool { return differences[i].Path < differences[j].Path })
+	return differences
+}
+
+// toFieldLists turns an object into the form used by merge: each field holds the list of its values.
+func toFieldLists(value interface{}) map[string]interface{} {
+	object, _ := value.(map[string]interface{})
+	fields := map[string]interface{}{}
+	for key, item := range object {
+		fields[key] = []interface{}{item}
+	}
+	return fields
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// diffObject compares objects whose fields hold the list of all the values seen for the field.
+func diffObject(path string, golden, actual map[string]interface{}) []Difference {
+	differences := []Difference{}
+	for key, expected := range golden {
+		fieldPath := join(path, key)
+		if Volatile[fieldPath] {
+			continue
+		}
+		values, exists := actual[key]
+		if !exists {
+			differences = append(differences, Difference{Path: fieldPath, Expected: typesOf(expected.([]interface{}))})
+			continue
+		}
+		differences = append(differences, diffValues(fieldPath, expected.([]interface{}), values.([]interface{}))...)
+	}
+	for key, values := range actual {
+		fieldPath := join(path, key)
+		if _, exists := golden[key]; !exists && !Volatile[fieldPath] {
+			differences = append(differences, Difference{Path: fieldPath, Actual: typesOf(values.([]interface{}))})
+		}
+	}
+	return differences
+}
+
+func typesOf(values []interface{}) string {
+	kinds := []string{}
+	for kind := range merge(values) {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, "|")
+}
+
+// diffValues compares all the values seen for a field: their types and, for objects and arrays, their content.
+func diffValues(path string, golden, actual []interface{}) []Difference {
+	expectedTypes, actualTypes := typesOf(golden), typesOf(actual)
+	if expectedTypes != actualTypes {
+		return []Difference{{Path: path, Expected: expectedTypes, Actual: actualTypes}}
+	}
+	if Opaque[path] || Opaque[lastKey(path)] {
+		return nil
+	}
+	expected, got := merge(golden), merge(actual)
+	differences := []Difference{}
+	if object, isObject := expected["object"].(map[string]interface{}); isObject {
+		if Dictionaries[path] {
+			values, gotValues := dictionaryValues(object), dictionaryValues(got["object"].(map[string]interface{}))
+			if len(values) > 0 && len(gotValues) > 0 {
+				differences = append(differences, diffValues(path+"{}", values, gotValues)...)
+			}
+		} else {
+			differences = append(differences, diffObject(path, object, got["object"].(map[string]interface{}))...)
+		}
+	}
+	if items, isArray := expected["array"].([]interface{}); isArray {
+		gotItems := got["array"].([]interface{})
+		if len(items) > 0 && len(gotItems) > 0 {
+			differences = append(differences, diffValues(path+"[]", items, gotItems)...)
+		}
+	}
+	return differences
+}
+
+// dictionaryValues returns all the values of a merged dictionary.
+func dictionaryValues(object map[string]interface{}) []interface{} {
+	values := []interface{}{}
+	for _, items := range object {
+		values = append(values, items.([]interface{})...)
+	}
+	return values
+}
+
+func lastKey(path string) string {
+	return path[strings.LastIndex(path, ".")+1:]
+}
+
+// LoadGolden reads the golden packet of a packet type from dir, e.g. testdata/js/REQUEST.json.
+func LoadGolden(dir, packetType string) (map[string]interface{}, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dir, packetType+".json"))
+	if err != nil {
+		return nil, err
+	}
+	packet := map[string]interface{}{}
+	if err := json.Unmarshal(content, &packet); err != nil {
+		return nil, fmt.Errorf("%s.json: %s", packetType, err)
+	}
+	return packet, nil
+}
@@ -0,0 +1,75 @@
+package conformance
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func lines(differences []Difference) []string {
+	result := []string{}
+	for _, difference := range differences {
+		result = append(result, difference.String())
+	}
+	return result
+}
+
+var _ = Describe("Diff", func() {
+	It("should name the missing, extra and differently typed fields", func() {
+		golden := map[string]interface{}{"action": "math.add", "caller": nil, "needAck": nil, "level": 1}
+		actual := map[string]interface{}{"action": "math.sub", "caller": "", "paramsType": 4, "level": 2}
+		Expect(lines(Diff(golden, actual))).Should(Equal([]string{
+			"~ caller: string, expected null",
+			"- needAck: null",
+			"+ paramsType: number",
+		}))
+	})
+
+	It("should ignore the volatile fields", func() {
+		golden := map[string]interface{}{"id": "a", "sender": "js-node", "seq": 1, "time": 1, "arrived": 2}
+		actual := map[string]interface{}{"id": nil, "sender": "go-node", "time": "now"}
+		Expect(Diff(golden, actual)).Should(BeEmpty())
+	})
+
+	It("should compare only the type of opaque fields", func() {
+		golden := map[string]interface{}{"params": map[string]interface{}{"a": 1}, "data": 3}
+		Expect(Diff(golden, map[string]interface{}{"params": map[string]interface{}{"b": "x"}, "data": 4.5})).Should(BeEmpty())
+		Expect(lines(Diff(golden, map[string]interface{}{"params": []interface{}{}, "data": 3}))).Should(Equal([]string{
+			"~ params: array, expected object",
+		}))
+	})
+
+	It("should compare the elements of arrays and the values of dictionaries as one", func() {
+		golden := map[string]interface{}{"services": []interface{}{
+			map[string]interface{}{"name": "math", "actions": map[string]interface{}{
+				"math.add": map[string]interface{}{"name": "math.add", "rawName": "add"},
+			}},
+		}}
+		actual := map[string]interface{}{"services": []interface{}{
+			map[string]interface{}{"name": "$node", "actions": map[string]interface{}{
+				"$node.list": map[string]interface{}{"name": "$node.list", "rawName": "list"},
+			}},
+			map[string]interface{}{"name": "greeter", "nodeID": "go-node", "actions": map[string]interface{}{
+				"greeter.hello": map[string]interface{}{"name": "greeter.hello", "params": map[string]interface{}{}},
+			}},
+		}}
+		Expect(lines(Diff(golden, actual))).Should(Equal([]string{
+			"+ services[].actions{}.params: object",
+			"+ services[].nodeID: string",
+		}))
+	})
+
+	It("should skip the elements of an empty array or dictionary", func() {
+		golden := map[string]interface{}{"groups": []interface{}{"math"}, "online": map[string]interface{}{"go-node": []interface{}{1}}}
+		actual := map[string]interface{}{"groups": []interface{}{}, "online": map[string]interface{}{}}
+		Expect(Diff(golden, actual)).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Compare", func() {
+	It("should report the unknown differences and the known ones that did not occur", func() {
+		differences := []Difference{{Path: "paramsType", Actual: "number"}, {Path: "caller", Expected: "null", Actual: "string"}}
+		unexpected, stale := Compare(differences, []string{"+ paramsType: number", "- needAck: null"})
+		Expect(unexpected).Should(Equal([]string{"~ caller: string, expected null"}))
+		Expect(stale).Should(Equal([]string{"- needAck: null"}))
+	})
+})
@@ -0,0 +1,48 @@
+package conformance
+
+import (
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LoadKnownDifferences reads the differences accepted for each packet type, as diff lines, e.g.
+//
+//	REQUEST:
+//	  - "+ paramsType: number"
+func LoadKnownDifferences(path string) (map[string][]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string][]string{}
+	return known, yaml.Unmarshal(content, &known)
+}
+
+// Compare splits the differences of a packet type into the ones not in known and the known ones
+// that did not occur.
+func Compare(differences []Difference, known []string) (unexpected, stale []string) {
+	occurred := map[string]bool{}
+	for _, difference := range differences {
+		line := difference.String()
+		occurred[line] = true
+		if !contains(known, line) {
+			unexpected = append(unexpected, line)
+		}
+	}
+	for _, line := range known {
+		if !occurred[line] {
+			stale = append(stale, line)
+		}
+	}
+	return unexpected, stale
+}
+
+func contains(lines []string, line string) bool {
+	for _, item := range lines {
+		if item == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Regenerates the golden packets in testdata/js from moleculer JS:
+//   npm install && node record.js
+// Two JS brokers exchange the packets the Go node sends in capture.go, first over the in-process
+// Fake transporter, then over TCP for the gossip packets. The first packet of each type sent by
+// js-node is written to testdata/js/<TYPE>.json.
+const fs = require("fs");
+const path = require("path");
+const { ServiceBroker } = require("moleculer");
+
+const names = { REQ: "REQUEST", RES: "RESPONSE" };
+const golden = {};
+
+const recorder = {
+	name: "GoldenPackets",
+	transporterSend(next) {
+		return (topic, data, meta) => {
+			const type = names[meta.packet.type] || meta.packet.type;
+			if (!golden[type]) {
+				golden[type] = JSON.parse(data.toString());
+			}
+			return next(topic, data, meta);
+		};
+	}
+};
+
+function brokers(transporter) {
+	const js = new ServiceBroker({
+		nodeID: "js-node",
+		transporter,
+		heartbeatInterval: 1,
+		logLevel: "warn",
+		middlewares: [recorder]
+	});
+	js.createService({
+		name: "greeter",
+		actions: {
+			hello(ctx) {
+				return "Hello " + ctx.params.name;
+			}
+		},
+		events: {
+			"math.added": { group: "greeter", handler() {} }
+		}
+	});
+	const remote = new ServiceBroker({ nodeID: "remote-node", transporter, logLevel: "warn" });
+	remote.createService({
+		name: "math",
+		actions: {
+			add(ctx) {
+				return ctx.params.a + ctx.params.b;
+			}
+		},
+		events: {
+			"math.added": { group: "math", handler() {} }
+		}
+	});
+	return { js, remote };
+}
+
+const delay = ms => new Promise(resolve => setTimeout(resolve, ms));
+
+async function record(transporter, run) {
+	const { js, remote } = brokers(transporter);
+	await Promise.all([js.start(), remote.start()]);
+	await js.waitForServices("math");
+	await remote.waitForServices("greeter");
+	await run(js, remote);
+	await remote.stop();
+	await js.stop();
+}
+
+async function main() {
+	await record("Fake", async (js, remote) => {
+		await js.call("math.add", { a: 1, b: 2 });
+		await remote.call("greeter.hello", { name: "JS" });
+		js.emit("math.added", { a: 1, b: 2 });
+		await js.ping("remote-node");
+		await remote.ping("js-node");
+		await delay(1500);
+	});
+	await record("TCP", async () => delay(3000));
+
+	const dir = path.join(__dirname, "testdata", "js");
+	fs.mkdirSync(dir, { recursive: true });
+	for (const [type, packet] of Object.entries(golden)) {
+		fs.writeFileSync(path.join(dir, type + ".json"), JSON.stringify(packet, null, 2) + "\n");
+		console.log("recorded", type);
+	}
+}
+
+main().catch(err => {
+	console.error(err);
+	process.exit(1);
+});
@@ -0,0 +1,28 @@
+"use strict";
+
+// The JS node of the crashes suite that the specs kill, with the NATS url as first argument:
+//   jscrasher.ping returns "pong".
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS crasher with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-crasher",
+	logLevel: "warn",
+	heartbeatInterval: 1,
+	heartbeatTimeout: 5
+});
+
+broker.createService({
+	name: "jscrasher",
+	actions: {
+		ping() {
+			return "pong";
+		}
+	}
+});
+
+broker.start();
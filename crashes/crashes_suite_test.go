@@ -0,0 +1,24 @@
+package crashes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCrashes(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crashes Suite")
+}
+
+var _ = AfterSuite(func() {
+	if !report.Empty() {
+		fmt.Print("\nDetection report\n\n" + report.Markdown())
+	}
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
@@ -0,0 +1,133 @@
+package crashes
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// report collects the detections of all the specs, printed by the AfterSuite.
+var report = &Report{}
+
+// lazyCluster starts the watchers once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// detected waits until the watcher service name noticed the crash of nodeID, adds the detection to
+// the report and checks it.
+func detected(bkr *broker.ServiceBroker, name, nodeID string, crash time.Time, goWatcher bool) {
+	var detection *Detection
+	_, latest := Window(HeartbeatInterval, HeartbeatTimeout)
+	Eventually(func() *Detection {
+		detections, err := Detections(bkr, name)
+		Expect(err).ShouldNot(HaveOccurred())
+		for index := range detections {
+			if detections[index].Node == nodeID && detections[index].After(crash) >= 0 {
+				detection = &detections[index]
+			}
+		}
+		return detection
+	}, latest+5*time.Second, 100*time.Millisecond).ShouldNot(BeNil(), "%s did not notice the crash of %s", name, nodeID)
+	report.Add(*detection, crash)
+	Expect(Check(*detection, crash, goWatcher)).Should(Succeed())
+}
+
+var _ = Describe("Go crash detection", func() {
+	var mem *memory.SharedMemory
+	var watcher *broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		watcher = broker.New(Config(GoWatcher, harness.MemoryTransporter(mem)))
+		watcher.Publish(WatcherService())
+		watcher.Start()
+	})
+
+	AfterEach(func() {
+		watcher.Stop()
+	})
+
+	It("a Go watcher should notice the crash of a Go node within the heartbeat window", func() {
+		crasher, plug := StartGoCrasher(harness.MemoryTransporter(mem))
+		defer crasher.Stop()
+		Expect(harness.WaitForServices(watcher, time.Now().Add(5*time.Second), "gocrasher")).Should(Succeed())
+
+		crash := time.Now()
+		plug.Pull()
+		detected(watcher, "gowatcher", GoCrasher, crash, true)
+	})
+
+	Context("with a replayed JS crasher", func() {
+		var heartbeatInterval time.Duration
+
+		BeforeEach(func() {
+			heartbeatInterval = harness.ReplayHeartbeatInterval
+			harness.ReplayHeartbeatInterval = HeartbeatInterval
+		})
+
+		AfterEach(func() {
+			harness.ReplayHeartbeatInterval = heartbeatInterval
+		})
+
+		It("a Go watcher should notice the crash of a JS node within the heartbeat window", func() {
+			peer := harness.NewReplayPeer(JSCrasher, harness.MemoryTransporter(mem)(), harness.ReplayInfo(
+				map[string]interface{}{
+					"name":     "jscrasher",
+					"fullName": "jscrasher",
+					"settings": map[string]interface{}{},
+					"metadata": map[string]interface{}{},
+					"actions": map[string]interface{}{
+						"jscrasher.ping": map[string]interface{}{"rawName": "ping", "name": "jscrasher.ping"},
+					},
+					"events": map[string]interface{}{},
+				},
+			))
+			Expect(peer.Start()).Should(Succeed())
+			defer peer.Kill()
+			Expect(harness.WaitForServices(watcher, time.Now().Add(5*time.Second), "jscrasher")).Should(Succeed())
+
+			crash := time.Now()
+			Expect(peer.Kill()).Should(Succeed())
+			detected(watcher, "gowatcher", JSCrasher, crash, true)
+		})
+	})
+})
+
+var _ = Describe("Crash detection with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	It("the Go and JS watchers should notice a killed JS node within the heartbeat window", func() {
+		crasher, err := cluster.StartJSCrasher(".", harness.NATSURL())
+		Expect(err).ShouldNot(HaveOccurred())
+		defer crasher.Kill()
+
+		crash := time.Now()
+		Expect(crasher.Kill()).Should(Succeed())
+		detected(cluster.Watcher, "gowatcher", JSCrasher, crash, true)
+		detected(cluster.Watcher, "jswatcher", JSCrasher, crash, false)
+	})
+
+	It("the Go and JS watchers should notice a crashed Go node within the heartbeat window", func() {
+		crasher, plug, err := cluster.StartGoCrasher(harness.NATSURL())
+		Expect(err).ShouldNot(HaveOccurred())
+		defer crasher.Stop()
+
+		crash := time.Now()
+		plug.Pull()
+		detected(cluster.Watcher, "gowatcher", GoCrasher, crash, true)
+		detected(cluster.Watcher, "jswatcher", GoCrasher, crash, false)
+	})
+})
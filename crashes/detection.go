@@ -0,0 +1,100 @@
+// Package crashes measures how long a node takes to notice that another node crashed, without
+// DISCONNECT: a Go watcher and a JS watcher record when they fire $node.disconnected for a killed JS
+// node and for a Go node whose plug was pulled (see harness.Plug), and the endpoints of the node
+// they still have then.
+package crashes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The heartbeats of all the nodes of the suite.
+const (
+	HeartbeatInterval = time.Second
+	HeartbeatTimeout  = 5 * time.Second
+)
+
+// Detection is a crash noticed by a watcher.
+type Detection struct {
+	Watcher string `json:"watcher"`
+	Node    string `json:"node"`
+	// At is when the watcher fired $node.disconnected, in milliseconds since the epoch.
+	At int64 `json:"at"`
+	// Unexpected is the flag of $node.disconnected. moleculer-go has no such flag: it is always
+	// false on the Go watcher.
+	Unexpected bool `json:"unexpected"`
+	// Endpoints is the number of action endpoints of the node the watcher still had.
+	Endpoints int `json:"endpoints"`
+}
+
+// After returns how long after crash the watcher noticed it.
+func (detection Detection) After(crash time.Time) time.Duration {
+	return time.Duration(detection.At)*time.Millisecond - time.Duration(crash.UnixNano())
+}
+
+// Window returns the earliest and the latest a watcher with heartbeatTimeout notices the crash of a
+// node that sent its heartbeats every heartbeatInterval. Both moleculer JS and moleculer-go check
+// the heartbeats every heartbeatTimeout and drop the nodes whose last heartbeat is more than
+// heartbeatTimeout old, counted in whole seconds. moleculer JS also moves each heartbeat by up to
+// half a second. So the earliest is when the last heartbeat was a heartbeatInterval before the
+// crash, and the latest is when a check comes just before the heartbeat expires.
+func Window(heartbeatInterval, heartbeatTimeout time.Duration) (time.Duration, time.Duration) {
+	earliest := heartbeatTimeout - heartbeatInterval - 2*time.Second
+	if earliest < 0 {
+		earliest = 0
+	}
+	return earliest, 2*heartbeatTimeout + 1500*time.Millisecond
+}
+
+// Check checks that a watcher noticed the crash within the Window of the suite, and dropped the
+// endpoints of the node. goWatcher tells that the watcher has no unexpected flag to check.
+func Check(detection Detection, crash time.Time, goWatcher bool) error {
+	earliest, latest := Window(HeartbeatInterval, HeartbeatTimeout)
+	after := detection.After(crash)
+	if after < earliest || after > latest {
+		return fmt.Errorf("%s noticed the crash of %s after %v, not within [%v, %v]", detection.Watcher, detection.Node, after, earliest, latest)
+	}
+	if detection.Endpoints != 0 {
+		return fmt.Errorf("%s still had %d endpoints of %s when it noticed the crash", detection.Watcher, detection.Endpoints, detection.Node)
+	}
+	if !goWatcher && !detection.Unexpected {
+		return fmt.Errorf("%s fired $node.disconnected for the crash of %s without unexpected", detection.Watcher, detection.Node)
+	}
+	return nil
+}
+
+// Report is the detection report: how long each watcher took to notice each crash.
+type Report struct {
+	rows []string
+}
+
+// Add adds a detection of a crash.
+func (report *Report) Add(detection Detection, crash time.Time) {
+	earliest, latest := Window(HeartbeatInterval, HeartbeatTimeout)
+	columns := []string{
+		detection.Watcher,
+		detection.Node,
+		detection.After(crash).Round(time.Millisecond).String(),
+		fmt.Sprintf("%v - %v", earliest, latest),
+	}
+	report.rows = append(report.rows, "| "+strings.Join(columns, " | ")+" |")
+}
+
+// Empty returns true when no detection was added.
+func (report *Report) Empty() bool {
+	return len(report.rows) == 0
+}
+
+// Markdown returns the report as a markdown table, sorted by watcher and crashed node.
+func (report *Report) Markdown() string {
+	lines := []string{
+		"| Watcher | Crashed node | Detection | Window |",
+		"|---|---|---|---|",
+	}
+	rows := append([]string{}, report.rows...)
+	sort.Strings(rows)
+	return strings.Join(append(lines, rows...), "\n") + "\n"
+}
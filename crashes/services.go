@@ -0,0 +1,190 @@
+package crashes
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+const (
+	// GoWatcher is the Go node with the gowatcher service.
+	GoWatcher = "go-watcher"
+	// JSWatcher is the JS node of watcher.js, with the jswatcher service.
+	JSWatcher = "js-watcher"
+	// GoCrasher is the Go node that crashes, with the gocrasher service.
+	GoCrasher = "go-crasher"
+	// JSCrasher is the JS node of crasher.js that crashes, with the jscrasher service.
+	JSCrasher = "js-crasher"
+)
+
+// WatcherService returns the gowatcher service, which records a Detection for each $node.disconnected:
+//   - gowatcher.detections returns them.
+func WatcherService() moleculer.ServiceSchema {
+	var mutex sync.Mutex
+	detections := []Detection{}
+	return moleculer.ServiceSchema{
+		Name: "gowatcher",
+		Actions: []moleculer.Action{
+			{
+				Name: "detections",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					list := []interface{}{}
+					for _, detection := range detections {
+						list = append(list, map[string]interface{}{
+							"watcher":    detection.Watcher,
+							"node":       detection.Node,
+							"at":         detection.At,
+							"unexpected": detection.Unexpected,
+							"endpoints":  detection.Endpoints,
+						})
+					}
+					return list
+				},
+			},
+		},
+		Events: []moleculer.Event{
+			{
+				Name: "$node.disconnected",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+					detection := Detection{Watcher: GoWatcher, Node: params.String(), At: time.Now().UnixNano() / int64(time.Millisecond)}
+					actions := <-ctx.Call("$node.actions", map[string]interface{}{"withEndpoints": true})
+					for _, action := range actions.Array() {
+						for _, endpoint := range action.Get("endpoints").Array() {
+							if endpoint.Get("nodeID").String() == detection.Node {
+								detection.Endpoints++
+							}
+						}
+					}
+					mutex.Lock()
+					defer mutex.Unlock()
+					detections = append(detections, detection)
+				},
+			},
+		},
+	}
+}
+
+// Detections returns the detections of the watcher service name, asked by bkr.
+func Detections(bkr *broker.ServiceBroker, name string) ([]Detection, error) {
+	result := <-bkr.Call(name+".detections", map[string]interface{}{})
+	if result.IsError() {
+		return nil, result.Error()
+	}
+	bytes, err := json.Marshal(result.Value())
+	if err != nil {
+		return nil, err
+	}
+	detections := []Detection{}
+	return detections, json.Unmarshal(bytes, &detections)
+}
+
+// Config returns the config of a Go node with the heartbeats of the suite.
+func Config(nodeID string, transporter func() transit.Transport) *moleculer.Config {
+	return &moleculer.Config{
+		LogLevel:           "ERROR",
+		HeartbeatFrequency: HeartbeatInterval,
+		HeartbeatTimeout:   HeartbeatTimeout,
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	}
+}
+
+// StartGoCrasher starts the Go crasher with the gocrasher service, connected with transporter through
+// the plug it returns.
+func StartGoCrasher(transporter func() transit.Transport) (*broker.ServiceBroker, *harness.Plug) {
+	plug := harness.NewPlug()
+	crasher := broker.New(Config(GoCrasher, func() transit.Transport {
+		return plug.Wrap(transporter())
+	}))
+	crasher.Publish(moleculer.ServiceSchema{
+		Name: "gocrasher",
+		Actions: []moleculer.Action{
+			{
+				Name: "ping",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					return "pong"
+				},
+			},
+		},
+	})
+	crasher.Start()
+	return crasher, plug
+}
+
+// Cluster is the Go watcher and watcher.js with the JS watcher, connected over NATS.
+type Cluster struct {
+	Watcher *broker.ServiceBroker
+	JS      *harness.Peer
+}
+
+// Start starts watcher.js and the Go watcher, connected to the NATS server at url, and waits until
+// they see each other.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "watcher.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Watcher: broker.New(Config(GoWatcher, harness.NATSTransporter(url)))}
+	cluster.Watcher.Publish(WatcherService())
+	cluster.Watcher.Start()
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Watcher, deadline, "jswatcher"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(cluster.Watcher, deadline, "jswatcher.services", "gowatcher"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// StartJSCrasher starts crasher.js and waits until both watchers see the jscrasher service.
+func (cluster *Cluster) StartJSCrasher(dir, url string) (*harness.Peer, error) {
+	crasher, err := harness.StartNode(dir, "crasher.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := cluster.waitFor("jscrasher"); err != nil {
+		crasher.Kill()
+		return nil, err
+	}
+	return crasher, nil
+}
+
+// waitFor waits until both watchers see service.
+func (cluster *Cluster) waitFor(service string) error {
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Watcher, deadline, service); err != nil {
+		return err
+	}
+	return harness.WaitForServicesIn(cluster.Watcher, deadline, "jswatcher.services", service)
+}
+
+// StartGoCrasher starts the Go crasher over NATS and waits until both watchers see the gocrasher service.
+func (cluster *Cluster) StartGoCrasher(url string) (*broker.ServiceBroker, *harness.Plug, error) {
+	crasher, plug := StartGoCrasher(harness.NATSTransporter(url))
+	if err := cluster.waitFor("gocrasher"); err != nil {
+		crasher.Stop()
+		return nil, nil, err
+	}
+	return crasher, plug, nil
+}
+
+// Stop stops the Go watcher and kills watcher.js.
+func (cluster *Cluster) Stop() {
+	cluster.Watcher.Stop()
+	cluster.JS.Kill()
+}
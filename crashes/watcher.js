@@ -0,0 +1,47 @@
+"use strict";
+
+// The JS watcher of the crashes suite (see services.go), with the NATS url as first argument:
+//   jswatcher.detections returns a detection for each $node.disconnected: the node, when it fired in
+//                        milliseconds since the epoch, its unexpected flag and the number of action
+//                        endpoints of the node the registry still had;
+//   jswatcher.services   returns $node.services of js-watcher.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS watcher with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-watcher",
+	logLevel: "warn",
+	heartbeatInterval: 1,
+	heartbeatTimeout: 5
+});
+
+const detections = [];
+
+broker.createService({
+	name: "jswatcher",
+	actions: {
+		detections() {
+			return detections;
+		},
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		}
+	},
+	events: {
+		"$node.disconnected"(ctx) {
+			const at = Date.now();
+			const node = ctx.params.node.id;
+			let endpoints = 0;
+			for (const action of broker.registry.getActionList({ withEndpoints: true })) {
+				endpoints += (action.endpoints || []).filter(endpoint => endpoint.nodeID === node).length;
+			}
+			detections.push({ watcher: "js-watcher", node, at, unexpected: ctx.params.unexpected, endpoints });
+		}
+	}
+});
+
+broker.start();
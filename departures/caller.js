@@ -0,0 +1,50 @@
+"use strict";
+
+// The JS caller of the departures suite (see services.go), with the NATS url as first argument:
+//   jscaller.call           calls params.action and returns how it failed: { name, message, at },
+//                           at in milliseconds since the epoch;
+//   jscaller.disconnections returns the $node.disconnected events: [{ node, unexpected }];
+//   jscaller.services       returns $node.services of js-caller.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS caller with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-caller",
+	logLevel: "warn",
+	heartbeatInterval: 1,
+	heartbeatTimeout: 5,
+	requestTimeout: 30 * 1000
+});
+
+const disconnections = [];
+
+broker.createService({
+	name: "jscaller",
+	actions: {
+		async call(ctx) {
+			try {
+				const data = await ctx.call(ctx.params.action, {});
+				return { name: "", message: "resolved with " + JSON.stringify(data), at: Date.now() };
+			} catch (err) {
+				return { name: err.name, message: err.message, at: Date.now() };
+			}
+		},
+		disconnections() {
+			return disconnections;
+		},
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		}
+	},
+	events: {
+		"$node.disconnected"(payload) {
+			disconnections.push({ node: payload.node.id, unexpected: !!payload.unexpected });
+		}
+	}
+});
+
+broker.start();
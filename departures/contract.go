@@ -0,0 +1,85 @@
+// Package departures checks what the other nodes see when a node leaves, gracefully or not: a
+// graceful stop sends DISCONNECT and the in-flight requests to the node are rejected right away,
+// while a crash sends nothing and the requests are rejected once the heartbeats of the node expired,
+// in both cases long before the request timeout. A Go caller and a JS caller each keep a request in
+// flight to a Go subject and to a JS subject when they leave.
+package departures
+
+import (
+	"fmt"
+	"time"
+)
+
+// The heartbeats and the request timeout of all the nodes of the suite.
+const (
+	HeartbeatInterval = time.Second
+	HeartbeatTimeout  = 5 * time.Second
+	RequestTimeout    = 30 * time.Second
+)
+
+// Immediately is how soon the in-flight requests to a node that sent DISCONNECT must be rejected.
+const Immediately = time.Second
+
+// Departure is how a subject leaves.
+type Departure struct {
+	Name string
+	// Graceful is true when the subject stops with broker.stop() or bkr.Stop(), false when it crashes.
+	Graceful bool
+}
+
+// Departures are the departures of each spec.
+var Departures = []Departure{
+	{Name: "a graceful stop", Graceful: true},
+	{Name: "a crash", Graceful: false},
+}
+
+// Deadline returns how long after the departure the in-flight requests may be rejected: Immediately
+// after a graceful stop, and once the heartbeats expired after a crash, at most twice the
+// heartbeatTimeout since the nodes check them every heartbeatTimeout (see the crashes suite).
+func (departure Departure) Deadline() time.Duration {
+	if departure.Graceful {
+		return Immediately
+	}
+	return 2*HeartbeatTimeout + 1500*time.Millisecond
+}
+
+// Disconnects returns the number of DISCONNECT packets the subject sends when it leaves.
+func (departure Departure) Disconnects() int {
+	if departure.Graceful {
+		return 1
+	}
+	return 0
+}
+
+// Rejection is how a caller failed an in-flight request to a subject that left.
+type Rejection struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	// At is when the caller rejected the request, in milliseconds since the epoch.
+	At int64 `json:"at"`
+}
+
+// After returns how long after departed the caller rejected the request.
+func (rejection Rejection) After(departed time.Time) time.Duration {
+	return time.Duration(rejection.At)*time.Millisecond - time.Duration(departed.UnixNano())
+}
+
+// GoRejection returns the message of the error of moleculer-go for a request to nodeID that left.
+func GoRejection(nodeID string) string {
+	return fmt.Sprintf("Node %s disconnected. The request was canceled.", nodeID)
+}
+
+// JSRejection returns the name and the message of the error of moleculer JS for a request for action
+// to nodeID that left.
+func JSRejection(action, nodeID string) (string, string) {
+	return "RequestRejectedError", fmt.Sprintf("Request is rejected when call '%s' action on '%s' node.", action, nodeID)
+}
+
+// Check checks that the rejection came before the Deadline of the departure.
+func (departure Departure) Check(rejection Rejection, departed time.Time) error {
+	after := rejection.After(departed)
+	if after > departure.Deadline() {
+		return fmt.Errorf("the in-flight request was rejected %v after %s, not within %v: %s", after, departure.Name, departure.Deadline(), rejection.Message)
+	}
+	return nil
+}
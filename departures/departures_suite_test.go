@@ -0,0 +1,20 @@
+package departures
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDepartures(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Departures Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
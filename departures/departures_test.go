@@ -0,0 +1,201 @@
+package departures
+
+import (
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the callers once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// replaySubject is a replayed JS subject, whose wait calls block until the peer leaves.
+type replaySubject struct {
+	peer *harness.ReplayPeer
+
+	mutex   sync.Mutex
+	waiting int
+}
+
+func startReplaySubject(nodeID string, mem *memory.SharedMemory) *replaySubject {
+	subject := &replaySubject{}
+	subject.peer = harness.NewReplayPeer(nodeID, harness.MemoryTransporter(mem)(), harness.ReplayInfo(
+		map[string]interface{}{
+			"name":     JSService,
+			"fullName": JSService,
+			"settings": map[string]interface{}{},
+			"metadata": map[string]interface{}{},
+			"actions": map[string]interface{}{
+				JSService + ".wait":    map[string]interface{}{"rawName": "wait", "name": JSService + ".wait"},
+				JSService + ".waiting": map[string]interface{}{"rawName": "waiting", "name": JSService + ".waiting"},
+			},
+			"events": map[string]interface{}{},
+		},
+	))
+	subject.peer.Handle(JSService+".wait", func(request moleculer.Payload) (interface{}, error) {
+		subject.mutex.Lock()
+		subject.waiting++
+		subject.mutex.Unlock()
+		<-subject.peer.Exited()
+		return "left", nil
+	})
+	subject.peer.Handle(JSService+".waiting", func(request moleculer.Payload) (interface{}, error) {
+		subject.mutex.Lock()
+		defer subject.mutex.Unlock()
+		return subject.waiting, nil
+	})
+	Expect(subject.peer.Start()).Should(Succeed())
+	return subject
+}
+
+func (subject *replaySubject) NodeID() string {
+	return subject.peer.NodeID()
+}
+
+func (subject *replaySubject) Service() string {
+	return JSService
+}
+
+func (subject *replaySubject) Leave(departure Departure) error {
+	if departure.Graceful {
+		return subject.peer.Stop()
+	}
+	return subject.peer.Kill()
+}
+
+// inFlight starts the calls and waits until subject handles all of them.
+func inFlight(bkr *broker.ServiceBroker, subject Subject, calls ...func(*broker.ServiceBroker, Subject) <-chan Rejection) []<-chan Rejection {
+	rejections := []<-chan Rejection{}
+	for _, call := range calls {
+		rejections = append(rejections, call(bkr, subject))
+	}
+	Eventually(func() (int, error) {
+		return Waiting(bkr, subject)
+	}, harness.StartTimeout, 100*time.Millisecond).Should(Equal(len(calls)))
+	return rejections
+}
+
+// rejected waits for the rejection of an in-flight call, until long after the deadline of departure
+// but before RequestTimeout.
+func rejected(rejections <-chan Rejection, departure Departure) Rejection {
+	var rejection Rejection
+	Eventually(rejections, departure.Deadline()+2*time.Second).Should(Receive(&rejection))
+	return rejection
+}
+
+var _ = Describe("Go departures", func() {
+	var mem *memory.SharedMemory
+	var packets *harness.PacketRecorder
+	var caller *broker.ServiceBroker
+	var replayHeartbeat time.Duration
+
+	BeforeEach(func() {
+		replayHeartbeat = harness.ReplayHeartbeatInterval
+		harness.ReplayHeartbeatInterval = HeartbeatInterval
+
+		var err error
+		packets, err = harness.NewPacketRecorder("Memory", harness.PacketFile(CurrentGinkgoTestDescription().FullTestText))
+		Expect(err).ShouldNot(HaveOccurred())
+		mem = &memory.SharedMemory{}
+		caller = StartGoCaller(harness.MemoryTransporter(mem), packets)
+	})
+
+	AfterEach(func() {
+		caller.Stop()
+		packets.Close(CurrentGinkgoTestDescription().Failed)
+		harness.ReplayHeartbeatInterval = replayHeartbeat
+	})
+
+	for _, item := range Departures {
+		departure := item
+
+		It("a Go caller should reject its in-flight requests to a Go node after "+departure.Name, func() {
+			subject := StartGoSubject(SubjectID(true, departure), harness.MemoryTransporter(mem))
+			defer subject.Leave(departure)
+			Expect(harness.WaitForServices(caller, time.Now().Add(harness.StartTimeout), GoService)).Should(Succeed())
+			calls := inFlight(caller, subject, GoCall)
+
+			departed := time.Now()
+			Expect(subject.Leave(departure)).Should(Succeed())
+			rejection := rejected(calls[0], departure)
+			Expect(rejection.Message).Should(Equal(GoRejection(subject.NodeID())))
+			Expect(departure.Check(rejection, departed)).Should(Succeed())
+			Expect(Disconnects(packets.Packets(), subject.NodeID())).Should(Equal(departure.Disconnects()))
+		})
+
+		It("a Go caller should reject its in-flight requests to a replayed JS node after "+departure.Name, func() {
+			subject := startReplaySubject(SubjectID(false, departure), mem)
+			defer subject.Leave(departure)
+			Expect(harness.WaitForServices(caller, time.Now().Add(harness.StartTimeout), JSService)).Should(Succeed())
+			calls := inFlight(caller, subject, GoCall)
+
+			departed := time.Now()
+			Expect(subject.Leave(departure)).Should(Succeed())
+			rejection := rejected(calls[0], departure)
+			Expect(rejection.Message).Should(Equal(GoRejection(subject.NodeID())))
+			Expect(departure.Check(rejection, departed)).Should(Succeed())
+			Expect(Disconnects(packets.Packets(), subject.NodeID())).Should(Equal(departure.Disconnects()))
+		})
+	}
+})
+
+var _ = Describe("Departures with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	// depart keeps a call of each caller in flight to subject, makes it leave and checks how the
+	// callers saw it leave.
+	depart := func(subject Subject, departure Departure) {
+		Expect(cluster.WaitFor(subject)).Should(Succeed())
+		calls := inFlight(cluster.Caller, subject, GoCall, JSCall)
+
+		departed := time.Now()
+		Expect(subject.Leave(departure)).Should(Succeed())
+		goRejection := rejected(calls[0], departure)
+		Expect(goRejection.Message).Should(Equal(GoRejection(subject.NodeID())))
+		Expect(departure.Check(goRejection, departed)).Should(Succeed())
+
+		jsRejection := rejected(calls[1], departure)
+		name, message := JSRejection(subject.Service()+".wait", subject.NodeID())
+		Expect(jsRejection.Name).Should(Equal(name), jsRejection.Message)
+		Expect(jsRejection.Message).Should(Equal(message))
+		Expect(departure.Check(jsRejection, departed)).Should(Succeed())
+
+		Expect(Disconnects(cluster.Packets.Packets(), subject.NodeID())).Should(Equal(departure.Disconnects()))
+		Eventually(func() ([]Disconnection, error) {
+			return Disconnections(cluster.Caller)
+		}, departure.Deadline(), 100*time.Millisecond).Should(ContainElement(Disconnection{Node: subject.NodeID(), Unexpected: !departure.Graceful}))
+	}
+
+	for _, item := range Departures {
+		departure := item
+
+		It("the Go and JS callers should reject their in-flight requests to a Go node after "+departure.Name, func() {
+			subject := StartGoSubject(SubjectID(true, departure), harness.NATSTransporter(harness.NATSURL()))
+			defer subject.Leave(departure)
+			depart(subject, departure)
+		})
+
+		It("the Go and JS callers should reject their in-flight requests to a JS node after "+departure.Name, func() {
+			subject, err := StartJSSubject(SubjectID(false, departure), ".", harness.NATSURL())
+			Expect(err).ShouldNot(HaveOccurred())
+			defer subject.Leave(departure)
+			depart(subject, departure)
+		})
+	}
+})
@@ -0,0 +1,299 @@
+package departures
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+const (
+	// GoCaller is the Go node that calls the subjects and records the packets it receives.
+	GoCaller = "go-caller"
+	// JSCaller is the JS node of caller.js, with the jscaller service.
+	JSCaller = "js-caller"
+	// GoService is the service of the Go subjects.
+	GoService = "goleaver"
+	// JSService is the service of the JS subjects.
+	JSService = "jsleaver"
+)
+
+// SubjectID returns the nodeID of the subject of a departure, for a Go subject or a JS subject.
+func SubjectID(goSubject bool, departure Departure) string {
+	prefix := "js-leaver-"
+	if goSubject {
+		prefix = "go-leaver-"
+	}
+	if departure.Graceful {
+		return prefix + "stop"
+	}
+	return prefix + "crash"
+}
+
+// Subject is a node that leaves while requests to its wait action are in flight.
+type Subject interface {
+	NodeID() string
+	// Service returns the service of the node, with the actions:
+	//   wait blocks until the node leaves;
+	//   waiting returns the number of wait calls in flight.
+	Service() string
+	// Leave stops the node gracefully or crashes it.
+	Leave(departure Departure) error
+}
+
+// Config returns the config of a Go node with the heartbeats and the request timeout of the suite.
+func Config(nodeID string, transporter func() transit.Transport) *moleculer.Config {
+	return &moleculer.Config{
+		LogLevel:           "ERROR",
+		HeartbeatFrequency: HeartbeatInterval,
+		HeartbeatTimeout:   HeartbeatTimeout,
+		RequestTimeout:     RequestTimeout,
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	}
+}
+
+// StartGoCaller starts the Go caller, connected with transporter through recorder.
+func StartGoCaller(transporter func() transit.Transport, recorder *harness.PacketRecorder) *broker.ServiceBroker {
+	caller := broker.New(Config(GoCaller, func() transit.Transport {
+		return recorder.Wrap(transporter())
+	}))
+	caller.Start()
+	return caller
+}
+
+// GoCall calls the wait action of subject from bkr and sends how the call failed. A call that
+// succeeds is sent as a Rejection without name whose message tells so.
+func GoCall(bkr *broker.ServiceBroker, subject Subject) <-chan Rejection {
+	rejections := make(chan Rejection, 1)
+	go func() {
+		result := <-bkr.Call(subject.Service()+".wait", map[string]interface{}{})
+		rejection := Rejection{At: time.Now().UnixNano() / int64(time.Millisecond)}
+		if result.IsError() {
+			rejection.Message = result.Error().Error()
+		} else {
+			rejection.Message = fmt.Sprintf("resolved with %v", result.Value())
+		}
+		rejections <- rejection
+	}()
+	return rejections
+}
+
+// JSCall makes the JS caller call the wait action of subject, asked by bkr, and sends how the call
+// failed, see GoCall.
+func JSCall(bkr *broker.ServiceBroker, subject Subject) <-chan Rejection {
+	rejections := make(chan Rejection, 1)
+	go func() {
+		rejection := Rejection{}
+		result := <-bkr.Call("jscaller.call", map[string]interface{}{"action": subject.Service() + ".wait"})
+		if result.IsError() {
+			rejection.Message = "jscaller.call failed: " + result.Error().Error()
+			rejection.At = time.Now().UnixNano() / int64(time.Millisecond)
+		} else if err := decode(result.Value(), &rejection); err != nil {
+			rejection.Message = err.Error()
+		}
+		rejections <- rejection
+	}()
+	return rejections
+}
+
+// Waiting returns the number of wait calls in flight on subject, asked by bkr.
+func Waiting(bkr *broker.ServiceBroker, subject Subject) (int, error) {
+	result := <-bkr.Call(subject.Service()+".waiting", map[string]interface{}{})
+	if result.IsError() {
+		return 0, result.Error()
+	}
+	return result.Int(), nil
+}
+
+// Disconnection is a $node.disconnected seen by the JS caller.
+type Disconnection struct {
+	Node       string `json:"node"`
+	Unexpected bool   `json:"unexpected"`
+}
+
+// Disconnections returns the $node.disconnected events seen by the JS caller, asked by bkr.
+func Disconnections(bkr *broker.ServiceBroker) ([]Disconnection, error) {
+	result := <-bkr.Call("jscaller.disconnections", map[string]interface{}{})
+	if result.IsError() {
+		return nil, result.Error()
+	}
+	disconnections := []Disconnection{}
+	return disconnections, decode(result.Value(), &disconnections)
+}
+
+// Disconnects returns the number of DISCONNECT packets from nodeID in packets.
+func Disconnects(packets []harness.Packet, nodeID string) int {
+	count := 0
+	for _, packet := range packets {
+		if packet.Direction == "in" && packet.Type == "DISCONNECT" && packet.Sender == nodeID {
+			count++
+		}
+	}
+	return count
+}
+
+func decode(value interface{}, target interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// GoSubject is a Go subject, connected through a plug so that it can crash (see harness.Plug).
+type GoSubject struct {
+	nodeID string
+	bkr    *broker.ServiceBroker
+	plug   *harness.Plug
+	left   chan struct{}
+
+	mutex   sync.Mutex
+	waiting int
+}
+
+// StartGoSubject starts a Go subject with the goleaver service, connected with transporter.
+func StartGoSubject(nodeID string, transporter func() transit.Transport) *GoSubject {
+	subject := &GoSubject{nodeID: nodeID, plug: harness.NewPlug(), left: make(chan struct{})}
+	subject.bkr = broker.New(Config(nodeID, func() transit.Transport {
+		return subject.plug.Wrap(transporter())
+	}))
+	subject.bkr.Publish(moleculer.ServiceSchema{
+		Name: GoService,
+		Actions: []moleculer.Action{
+			{
+				Name: "wait",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					subject.mutex.Lock()
+					subject.waiting++
+					subject.mutex.Unlock()
+					<-subject.left
+					return "left"
+				},
+			},
+			{
+				Name: "waiting",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					subject.mutex.Lock()
+					defer subject.mutex.Unlock()
+					return subject.waiting
+				},
+			},
+		},
+	})
+	subject.bkr.Start()
+	return subject
+}
+
+// NodeID returns the nodeID of the subject.
+func (subject *GoSubject) NodeID() string {
+	return subject.nodeID
+}
+
+// Service returns goleaver.
+func (subject *GoSubject) Service() string {
+	return GoService
+}
+
+// Leave stops the broker, after pulling its plug for a crash. The wait calls in flight return once
+// the broker stopped, too late to be sent.
+func (subject *GoSubject) Leave(departure Departure) error {
+	select {
+	case <-subject.left:
+		return nil
+	default:
+	}
+	if !departure.Graceful {
+		subject.plug.Pull()
+	}
+	subject.bkr.Stop()
+	subject.plug.Pull()
+	close(subject.left)
+	return nil
+}
+
+// JSSubject is a JS subject: a subject.js process.
+type JSSubject struct {
+	nodeID string
+	peer   *harness.Peer
+}
+
+// StartJSSubject starts subject.js with the jsleaver service, connected to the NATS server at url.
+func StartJSSubject(nodeID, dir, url string) (*JSSubject, error) {
+	peer, err := harness.StartNode(dir, "subject.js", map[string]string{"NODE_ID": nodeID}, url)
+	if err != nil {
+		return nil, err
+	}
+	return &JSSubject{nodeID: nodeID, peer: peer}, nil
+}
+
+// NodeID returns the nodeID of the subject.
+func (subject *JSSubject) NodeID() string {
+	return subject.nodeID
+}
+
+// Service returns jsleaver.
+func (subject *JSSubject) Service() string {
+	return JSService
+}
+
+// Leave terminates subject.js, which moleculer JS handles with broker.stop(), or kills it.
+func (subject *JSSubject) Leave(departure Departure) error {
+	if departure.Graceful {
+		return subject.peer.Stop()
+	}
+	return subject.peer.Kill()
+}
+
+// Cluster is the Go caller and caller.js with the JS caller, connected over NATS. The packets the Go
+// caller receives are recorded by Packets.
+type Cluster struct {
+	Caller  *broker.ServiceBroker
+	Packets *harness.PacketRecorder
+	JS      *harness.Peer
+}
+
+// Start starts caller.js and the Go caller, connected to the NATS server at url, and waits until
+// they see each other.
+func Start(dir, url string) (*Cluster, error) {
+	packets, err := harness.NewPacketRecorder("NATS", harness.PacketFile("departures"))
+	if err != nil {
+		return nil, err
+	}
+	js, err := harness.StartNode(dir, "caller.js", map[string]string{}, url)
+	if err != nil {
+		packets.Close(false)
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Packets: packets, Caller: StartGoCaller(harness.NATSTransporter(url), packets)}
+	if err := harness.WaitForServices(cluster.Caller, time.Now().Add(harness.StartTimeout), "jscaller"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// WaitFor waits until both callers see the service of subject.
+func (cluster *Cluster) WaitFor(subject Subject) error {
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Caller, deadline, subject.Service()); err != nil {
+		return err
+	}
+	return harness.WaitForServicesIn(cluster.Caller, deadline, "jscaller.services", subject.Service())
+}
+
+// Stop stops the Go caller, kills caller.js and deletes the recording.
+func (cluster *Cluster) Stop() {
+	cluster.Caller.Stop()
+	cluster.JS.Kill()
+	cluster.Packets.Close(false)
+}
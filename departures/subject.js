@@ -0,0 +1,37 @@
+"use strict";
+
+// A JS subject of the departures suite (see services.go), with the NATS url as first argument and
+// its nodeID in the NODE_ID environment variable:
+//   jsleaver.wait    never returns;
+//   jsleaver.waiting returns the number of wait calls in flight.
+// moleculer JS stops the broker, with a DISCONNECT, when the process gets SIGTERM.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS subject " + process.env["NODE_ID"] + " with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: process.env["NODE_ID"],
+	logLevel: "warn",
+	heartbeatInterval: 1,
+	heartbeatTimeout: 5
+});
+
+let waiting = 0;
+
+broker.createService({
+	name: "jsleaver",
+	actions: {
+		wait() {
+			waiting++;
+			return new Promise(() => {});
+		},
+		waiting() {
+			return waiting;
+		}
+	}
+});
+
+broker.start();
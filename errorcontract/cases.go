@@ -0,0 +1,153 @@
+// Package errorcontract checks which fields of an error survive when it crosses the boundary
+// between moleculer-go and moleculer JS: name, message, code, type, data, retryable, nodeID and stack.
+//
+// JSErrors are thrown by the actions of errors.js and received by Go callers. GoErrors are returned or
+// panicked by the actions of GoService and received by JS callers (jserrors.callgo).
+package errorcontract
+
+// JSError is a row of the JS → Go table: an error thrown by the action jserrors.<Action>.
+type JSError struct {
+	// Action is the action of errors.js that throws the error.
+	Action string
+	// Description is used in the spec descriptions.
+	Description string
+	// Packet is the error of the RESPONSE moleculer JS sends, without the stack.
+	Packet map[string]interface{}
+	// InGo is the Error() of the error a Go caller gets.
+	InGo string
+}
+
+// GoError is a row of the Go → JS table: an error returned or panicked by the action goerrors.<Action>.
+type GoError struct {
+	// Action is the action of GoService that fails.
+	Action string
+	// Description is used in the spec descriptions.
+	Description string
+	// Packet is the error of the RESPONSE moleculer-go sends, without the stack.
+	Packet map[string]interface{}
+	// Stack is true when the RESPONSE error has a stack.
+	Stack bool
+	// InJS are the fields of the error a JS caller gets, see jserrors.callgo in errors.js.
+	InJS map[string]interface{}
+}
+
+// JSErrors is the JS → Go table. moleculer-go keeps only the message of the errors of moleculer JS:
+// a Go caller gets errors.New(message), whatever the name, code, type, data or retryable.
+var JSErrors = []JSError{
+	{
+		Action:      "moleculerError",
+		Description: "MoleculerError with code, type and data",
+		Packet: map[string]interface{}{
+			"name":      "MoleculerError",
+			"message":   "Something went wrong",
+			"nodeID":    "js-node",
+			"code":      500.0,
+			"type":      "SOMETHING_WRONG",
+			"retryable": false,
+			"data":      map[string]interface{}{"reason": "test"},
+		},
+		InGo: "Something went wrong",
+	},
+	{
+		Action:      "validationError",
+		Description: "ValidationError of the params validator",
+		Packet: map[string]interface{}{
+			"name":      "ValidationError",
+			"message":   "Parameters validation error!",
+			"nodeID":    "js-node",
+			"code":      422.0,
+			"type":      "VALIDATION_ERROR",
+			"retryable": false,
+			"data": []interface{}{
+				map[string]interface{}{"type": "required", "message": "The 'name' field is required.", "field": "name", "action": "jserrors.validationError", "nodeID": "js-node"},
+			},
+		},
+		InGo: "Parameters validation error!",
+	},
+	{
+		Action:      "serviceNotFound",
+		Description: "ServiceNotFoundError of a call to a missing service",
+		Packet: map[string]interface{}{
+			"name":      "ServiceNotFoundError",
+			"message":   "Service 'missing.action' is not found.",
+			"nodeID":    "js-node",
+			"code":      404.0,
+			"type":      "SERVICE_NOT_FOUND",
+			"retryable": true,
+			"data":      map[string]interface{}{"action": "missing.action"},
+		},
+		InGo: "Service 'missing.action' is not found.",
+	},
+	{
+		Action:      "requestTimeout",
+		Description: "RequestTimeoutError of a call that times out",
+		Packet: map[string]interface{}{
+			"name":      "RequestTimeoutError",
+			"message":   "Request is timed out when call 'jserrors.slow' action on 'js-node' node.",
+			"nodeID":    "js-node",
+			"code":      504.0,
+			"type":      "REQUEST_TIMEOUT",
+			"retryable": true,
+			"data":      map[string]interface{}{"action": "jserrors.slow", "nodeID": "js-node"},
+		},
+		InGo: "Request is timed out when call 'jserrors.slow' action on 'js-node' node.",
+	},
+	{
+		Action:      "customError",
+		Description: "custom MoleculerError subclass with data",
+		Packet: map[string]interface{}{
+			"name":      "OrderRejectedError",
+			"message":   "Order 42 is rejected",
+			"nodeID":    "js-node",
+			"code":      409.0,
+			"type":      "ORDER_REJECTED",
+			"retryable": false,
+			"data":      map[string]interface{}{"orderID": 42.0, "reasons": []interface{}{"out of stock"}},
+		},
+		InGo: "Order 42 is rejected",
+	},
+	{
+		Action:      "plainError",
+		Description: "plain Error",
+		Packet: map[string]interface{}{
+			"name":    "Error",
+			"message": "Plain failure",
+			"nodeID":  "js-node",
+		},
+		InGo: "Plain failure",
+	},
+}
+
+// GoErrors is the Go → JS table. moleculer-go sends only a message and the name "Error", plus
+// a stack for panics: a JS caller gets an Error without code, type, data or retryable.
+var GoErrors = []GoError{
+	{
+		Action:      "fail",
+		Description: "returned error",
+		Packet:      map[string]interface{}{"name": "Error", "message": "this action returns an error!"},
+		InJS:        map[string]interface{}{"name": "Error", "message": "this action returns an error!", "nodeID": "go-node"},
+	},
+	{
+		Action:      "failPayload",
+		Description: "returned error payload with data",
+		Packet:      map[string]interface{}{"name": "Error", "message": "this action returns an error payload!"},
+		InJS:        map[string]interface{}{"name": "Error", "message": "this action returns an error payload!", "nodeID": "go-node"},
+	},
+	{
+		Action:      "panicError",
+		Description: "panic with an error",
+		Packet:      map[string]interface{}{"name": "Error", "message": "this action panics with an error!"},
+		Stack:       true,
+		InJS:        map[string]interface{}{"name": "Error", "message": "this action panics with an error!", "nodeID": "go-node"},
+	},
+	{
+		Action:      "panix",
+		Description: "panic with a string",
+		Packet:      map[string]interface{}{"name": "Error", "message": "this action will panic!"},
+		Stack:       true,
+		InJS:        map[string]interface{}{"name": "Error", "message": "this action will panic!", "nodeID": "go-node"},
+	},
+}
+
+// Fields are the fields of the mapping table, in order.
+var Fields = []string{"name", "message", "code", "type", "data", "retryable", "nodeID", "stack"}
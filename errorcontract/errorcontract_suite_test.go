@@ -0,0 +1,23 @@
+package errorcontract
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestErrorContract(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Error Contract Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyContract.Stop()
+	if recorder != nil {
+		recorder.Close(false)
+	}
+	harness.StopEmbeddedNATS()
+})
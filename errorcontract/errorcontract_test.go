@@ -0,0 +1,179 @@
+package errorcontract
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// contract is started by lazyContract, recorder records its packets.
+var contract *Contract
+var recorder *harness.PacketRecorder
+
+// lazyContract starts the Go broker and errors.js once, for all the specs that need moleculer JS.
+var lazyContract = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	recorder, err = harness.NewPacketRecorder("NATS", harness.PacketFile("errorcontract"))
+	if err != nil {
+		return nil, err
+	}
+	contract, err = Start(".", harness.NATSURL(), recorder)
+	return contract, err
+})
+
+// memoryBroker creates a broker on the memory transporter shared by the brokers created from mem,
+// recording its packets when packets is not nil.
+func memoryBroker(nodeID string, mem *memory.SharedMemory, packets *harness.PacketRecorder) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := harness.MemoryTransporter(mem)()
+			if packets != nil {
+				return packets.Wrap(transport)
+			}
+			return transport
+		},
+	})
+}
+
+var _ = Describe("Go errors on the wire", func() {
+	var dir string
+	var packets *harness.PacketRecorder
+	var caller, callee *broker.ServiceBroker
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "errorcontract")
+		Expect(err).ShouldNot(HaveOccurred())
+		packets, err = harness.NewPacketRecorder("Memory", filepath.Join(dir, "packets.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		mem := &memory.SharedMemory{}
+		caller = memoryBroker("caller-node", mem, packets)
+		callee = memoryBroker(GoNodeID, mem, nil)
+		callee.Publish(GoService)
+		callee.Start()
+		caller.Start()
+		Expect(harness.WaitForServices(caller, time.Now().Add(5*time.Second), "goerrors")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		caller.Stop()
+		callee.Stop()
+		packets.Close(false)
+		os.RemoveAll(dir)
+	})
+
+	for _, item := range GoErrors {
+		row := item
+
+		It("should send the "+row.Description+" of goerrors."+row.Action+" as in the Go → JS table", func() {
+			result := <-caller.Call("goerrors."+row.Action, map[string]interface{}{})
+			Expect(result.Error()).Should(HaveOccurred())
+
+			sent, stack, err := ResponseError(packets.Packets(), "goerrors."+row.Action)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sent).Should(Equal(row.Packet))
+			Expect(stack).Should(Equal(row.Stack))
+		})
+	}
+})
+
+var _ = Describe("JS errors in Go", func() {
+	var local *broker.ServiceBroker
+	var peer *harness.ReplayPeer
+
+	BeforeEach(func() {
+		mem := &memory.SharedMemory{}
+		local = memoryBroker(GoNodeID, mem, nil)
+		local.Start()
+
+		actions := map[string]interface{}{}
+		for _, row := range JSErrors {
+			actions["jserrors."+row.Action] = map[string]interface{}{"rawName": row.Action, "name": "jserrors." + row.Action}
+		}
+		peer = harness.NewReplayPeer(JSNodeID, harness.MemoryTransporter(mem)(), harness.ReplayInfo(
+			map[string]interface{}{
+				"name":     "jserrors",
+				"fullName": "jserrors",
+				"settings": map[string]interface{}{},
+				"metadata": map[string]interface{}{},
+				"actions":  actions,
+				"events":   map[string]interface{}{},
+			},
+		))
+		for _, item := range JSErrors {
+			row := item
+			peer.Handle("jserrors."+row.Action, func(request moleculer.Payload) (interface{}, error) {
+				failure := harness.ReplayError{"stack": row.Packet["name"].(string) + ": " + row.InGo + "\n    at errors.js"}
+				for field, value := range row.Packet {
+					failure[field] = value
+				}
+				return nil, failure
+			})
+		}
+		Expect(peer.Start()).Should(Succeed())
+		Expect(harness.WaitForServices(local, time.Now().Add(5*time.Second), "jserrors")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		peer.Kill()
+		local.Stop()
+	})
+
+	for _, item := range JSErrors {
+		row := item
+
+		It("should keep only the message of the "+row.Description+" of jserrors."+row.Action, func() {
+			result := <-local.Call("jserrors."+row.Action, map[string]interface{}{})
+			Expect(result.Error()).Should(MatchError(row.InGo))
+		})
+	}
+})
+
+var _ = Describe("Error contract with moleculer JS", func() {
+	BeforeEach(lazyContract.Start)
+
+	for _, item := range JSErrors {
+		row := item
+
+		It("JS → Go should map the "+row.Description+" as in the table", func() {
+			result := <-contract.Broker.Call("jserrors."+row.Action, map[string]interface{}{})
+			Expect(result.Error()).Should(MatchError(row.InGo))
+
+			sent, stack, err := ResponseError(recorder.Packets(), "jserrors."+row.Action)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(stack).Should(BeTrue())
+			for field, value := range row.Packet {
+				if field == "data" && row.Action == "validationError" {
+					// The messages of the validator change between its versions.
+					Expect(sent).Should(HaveKey("data"))
+					continue
+				}
+				Expect(sent).Should(HaveKeyWithValue(field, value))
+			}
+		})
+	}
+
+	for _, item := range GoErrors {
+		row := item
+
+		It("Go → JS should map the "+row.Description+" as in the table", func() {
+			result := <-contract.Broker.Call("jserrors.callgo", map[string]interface{}{"action": row.Action})
+			Expect(result.Error()).ShouldNot(HaveOccurred())
+			Expect(result.Value()).Should(Equal(row.InJS))
+		})
+	}
+})
@@ -0,0 +1,83 @@
+"use strict";
+
+// The JS service of the error contract suite (see cases.go), with the NATS url as first argument.
+//   jserrors.<action> throws the error of the JSErrors row of the same action.
+//   jserrors.slow     answers after a second, for requestTimeout.
+//   jserrors.callgo   calls the goerrors action named by the "action" param and returns the fields
+//                     of the error it gets, see GoError.InJS.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS errors with transporter: " + transporter);
+
+const { ServiceBroker, Errors } = require("moleculer");
+const { MoleculerError } = Errors;
+
+const broker = new ServiceBroker({ transporter, nodeID: process.env["NODE_ID"], logLevel: "info" });
+
+class OrderRejectedError extends MoleculerError {
+	constructor(orderID, reasons) {
+		super("Order " + orderID + " is rejected", 409, "ORDER_REJECTED", { orderID, reasons });
+	}
+}
+
+// fields returns the fields of an error that are defined, without the stack.
+function fields(err) {
+	const result = {};
+	for (const name of ["name", "message", "code", "type", "data", "retryable", "nodeID"]) {
+		if (err[name] !== undefined) {
+			result[name] = err[name];
+		}
+	}
+	return result;
+}
+
+broker.createService({
+	name: "jserrors",
+	actions: {
+		services(ctx) {
+			return ctx.call("$node.services");
+		},
+
+		moleculerError() {
+			throw new MoleculerError("Something went wrong", 500, "SOMETHING_WRONG", { reason: "test" });
+		},
+
+		validationError: {
+			params: { name: "string" },
+			handler() {
+				return "unreachable";
+			}
+		},
+
+		serviceNotFound(ctx) {
+			return ctx.call("missing.action");
+		},
+
+		requestTimeout(ctx) {
+			return ctx.call("jserrors.slow", {}, { timeout: 100 });
+		},
+
+		slow() {
+			return new Promise(resolve => setTimeout(() => resolve("slow"), 1000));
+		},
+
+		customError() {
+			throw new OrderRejectedError(42, ["out of stock"]);
+		},
+
+		plainError() {
+			throw new Error("Plain failure");
+		},
+
+		async callgo(ctx) {
+			try {
+				await ctx.call("goerrors." + ctx.params.action);
+			} catch (err) {
+				return fields(err);
+			}
+			throw new Error("goerrors." + ctx.params.action + " did not fail");
+		}
+	}
+});
+
+broker.start();
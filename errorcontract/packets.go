@@ -0,0 +1,48 @@
+package errorcontract
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/moleculer-go/compatibility/harness"
+)
+
+// ResponseError finds the REQUEST for action in the recorded packets and returns the error of its
+// RESPONSE without the stack, and whether the error had a stack.
+func ResponseError(packets []harness.Packet, action string) (map[string]interface{}, bool, error) {
+	requestID := ""
+	for _, packet := range packets {
+		fields := plainMap(packet.Payload)
+		if requestID == "" && packet.Type == "REQUEST" && fields["action"] == action {
+			requestID, _ = fields["id"].(string)
+			continue
+		}
+		if requestID == "" || packet.Type != "RESPONSE" || fields["id"] != requestID {
+			continue
+		}
+		failure, isMap := fields["error"].(map[string]interface{})
+		if !isMap {
+			return nil, false, fmt.Errorf("the RESPONSE to %s has no error: %v", action, fields)
+		}
+		_, stack := failure["stack"]
+		delete(failure, "stack")
+		return failure, stack, nil
+	}
+	if requestID == "" {
+		return nil, false, fmt.Errorf("no REQUEST for %s was recorded", action)
+	}
+	return nil, false, fmt.Errorf("no RESPONSE to the REQUEST %s for %s was recorded", requestID, action)
+}
+
+// plainMap converts a recorded payload to the map it is on the wire, numbers as float64.
+func plainMap(value interface{}) map[string]interface{} {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(bytes, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
@@ -0,0 +1,99 @@
+package errorcontract
+
+import (
+	"errors"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/payload"
+)
+
+const (
+	// GoNodeID is the node of GoService.
+	GoNodeID = "go-node"
+	// JSNodeID is the node of errors.js.
+	JSNodeID = "js-node"
+)
+
+// GoService fails the way Go actions do, one action per GoError: fail and panix are the
+// UserService.Fail and UserService.Panix of the tcp-transporter example.
+var GoService = moleculer.ServiceSchema{
+	Name: "goerrors",
+	Actions: []moleculer.Action{
+		{
+			Name: "fail",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return errors.New("this action returns an error!")
+			},
+		},
+		{
+			Name: "failPayload",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return payload.PayloadError("this action returns an error payload!", payload.New(map[string]interface{}{"orderID": 42}))
+			},
+		},
+		{
+			Name: "panicError",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				panic(errors.New("this action panics with an error!"))
+			},
+		},
+		{
+			Name: "panix",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				panic("this action will panic!")
+			},
+		},
+	},
+}
+
+// Contract is a Go broker with GoService and errors.js, connected over NATS.
+type Contract struct {
+	Broker *broker.ServiceBroker
+	JS     *harness.Peer
+}
+
+// Start starts errors.js and a Go broker with GoService, both connected to the NATS server at url,
+// and waits until each side sees the service of the other. When packets is not nil, the packets of
+// the Go broker are recorded.
+func Start(dir, url string, packets *harness.PacketRecorder) (*Contract, error) {
+	js, err := harness.StartNode(dir, "errors.js", map[string]string{"NODE_ID": JSNodeID}, url)
+	if err != nil {
+		return nil, err
+	}
+	bkr := broker.New(&moleculer.Config{
+		LogLevel: "WARN",
+		DiscoverNodeID: func() string {
+			return GoNodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := harness.NATSTransporter(url)()
+			if packets != nil {
+				return packets.Wrap(transport)
+			}
+			return transport
+		},
+	})
+	bkr.Publish(GoService)
+	bkr.Start()
+	contract := &Contract{Broker: bkr, JS: js}
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(bkr, deadline, "jserrors"); err != nil {
+		contract.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(bkr, deadline, "jserrors.services", "goerrors"); err != nil {
+		contract.Stop()
+		return nil, err
+	}
+	return contract, nil
+}
+
+// Stop stops the Go broker and kills errors.js.
+func (contract *Contract) Stop() {
+	contract.Broker.Stop()
+	contract.JS.Kill()
+}
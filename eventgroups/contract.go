@@ -0,0 +1,166 @@
+// Package eventgroups checks the balanced events of moleculer when Go and JS nodes listen to the
+// same event in several groups: emit delivers each event once per group, broadcast once per node
+// and broadcastLocal only to the listeners of the emitter, whatever the language of each node.
+package eventgroups
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Event is the event of the listeners.
+const Event = "user.created"
+
+const (
+	// GoEmitter is the Go node that sends the events.
+	GoEmitter = "go-emitter"
+	// JSEmitter is the JS node that sends the events.
+	JSEmitter = "js-emitter"
+)
+
+// Groups are the groups of the listeners of Event and their nodes, a Go and a JS node each. A group
+// is a service with a listener, named as the group. The emitters listen in the tracker group.
+var Groups = map[string][]string{
+	"mail":    {"go-mail", "js-mail"},
+	"audit":   {"go-audit", "js-audit"},
+	"tracker": {GoEmitter, JSEmitter},
+}
+
+// Emission is a row of the event table: a way to send Event.
+type Emission struct {
+	// Name is used in the spec descriptions.
+	Name string
+	// Method is emit, broadcast or broadcastLocal.
+	Method string
+	// Groups are the groups the events are sent to, all when empty.
+	Groups []string
+	// GoIssue tells how moleculer-go breaks the contract when it sends the events, empty when it does not.
+	// The specs of a Go emitter check the deliveries of a broadcast against it, see CheckGoBroadcast.
+	GoIssue string
+}
+
+// goBroadcastIssue is the GoIssue of the broadcasts: EventCatalog.Find takes the address of its loop
+// variable, and moleculer-go is built with the loop semantics of Go 1.12.
+const goBroadcastIssue = "each event is delivered as many times as the group has nodes, all to the same node"
+
+// Emissions is the event table.
+var Emissions = []Emission{
+	{Name: "emit", Method: "emit"},
+	{Name: "emit to the mail group", Method: "emit", Groups: []string{"mail"}},
+	{Name: "broadcast", Method: "broadcast", GoIssue: goBroadcastIssue},
+	{Name: "broadcast to the mail group", Method: "broadcast", Groups: []string{"mail"}, GoIssue: goBroadcastIssue},
+	{Name: "broadcastLocal", Method: "broadcastLocal", GoIssue: "moleculer-go has no broadcastLocal to services"},
+}
+
+// reaches tells whether the emission sends the events to group.
+func (emission Emission) reaches(group string) bool {
+	if len(emission.Groups) == 0 {
+		return true
+	}
+	for _, name := range emission.Groups {
+		if name == group {
+			return true
+		}
+	}
+	return false
+}
+
+// expected returns the deliveries each node of a group should get when emitter sends count events,
+// and the deliveries of the whole group.
+func (emission Emission) expected(group string, nodeIDs []string, emitter string, count int) (map[string]int, int) {
+	byNode := map[string]int{}
+	if !emission.reaches(group) {
+		return byNode, 0
+	}
+	switch emission.Method {
+	case "broadcast":
+		for _, nodeID := range nodeIDs {
+			byNode[nodeID] = count
+		}
+		return byNode, count * len(nodeIDs)
+	case "broadcastLocal":
+		for _, nodeID := range nodeIDs {
+			if nodeID == emitter {
+				byNode[nodeID] = count
+				return byNode, count
+			}
+		}
+		return byNode, 0
+	}
+	// An emit is delivered to the local listener of the group when there is one.
+	for _, nodeID := range nodeIDs {
+		if nodeID == emitter {
+			byNode[nodeID] = count
+		}
+	}
+	return byNode, count
+}
+
+// Total returns the number of deliveries among groups when emitter sends count events.
+func (emission Emission) Total(groups map[string][]string, emitter string, count int) int {
+	total := 0
+	for group, nodeIDs := range groups {
+		_, deliveries := emission.expected(group, nodeIDs, emitter, count)
+		total += deliveries
+	}
+	return total
+}
+
+// Check returns an error when the deliveries by node break the contract for count events sent by
+// emitter to groups. Which node of a group gets an emitted event is up to the strategy of the emitter.
+func (emission Emission) Check(groups map[string][]string, deliveries map[string]int, emitter string, count int) error {
+	names := []string{}
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	for _, group := range names {
+		nodeIDs := groups[group]
+		byNode, total := emission.expected(group, nodeIDs, emitter, count)
+		got := 0
+		for _, nodeID := range nodeIDs {
+			got += deliveries[nodeID]
+		}
+		if got != total {
+			return fmt.Errorf("the %s group got %d deliveries instead of %d: %v", group, got, total, deliveries)
+		}
+		for _, nodeID := range nodeIDs {
+			want, checked := byNode[nodeID]
+			if (checked || emission.Method != "emit") && deliveries[nodeID] != want {
+				return fmt.Errorf("%s of the %s group got %d deliveries instead of %d: %v", nodeID, group, deliveries[nodeID], want, deliveries)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckGoBroadcast returns an error when the deliveries by node of count events broadcast by a Go
+// emitter to groups are not those of goBroadcastIssue: in each group the emission reaches, one node
+// gets count events per node of the group and the others get none.
+func (emission Emission) CheckGoBroadcast(groups map[string][]string, deliveries map[string]int, count int) error {
+	names := []string{}
+	for group := range groups {
+		names = append(names, group)
+	}
+	sort.Strings(names)
+	for _, group := range names {
+		nodeIDs := groups[group]
+		want := 0
+		if emission.reaches(group) {
+			want = count * len(nodeIDs)
+		}
+		got := []int{}
+		for _, nodeID := range nodeIDs {
+			if deliveries[nodeID] != 0 {
+				got = append(got, deliveries[nodeID])
+			}
+		}
+		if want == 0 && len(got) > 0 {
+			return fmt.Errorf("the %s group got deliveries: %v", group, deliveries)
+		}
+		if want > 0 && (len(got) != 1 || got[0] != want) {
+			return fmt.Errorf("the %s group did not get %d deliveries on a single node: %v", group, want, deliveries)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package eventgroups
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEventGroups(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Event Groups Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
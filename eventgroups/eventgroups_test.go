@@ -0,0 +1,190 @@
+package eventgroups
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// count is the number of events of each spec.
+const count = 20
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the Go and JS nodes once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// memoryBroker creates a broker with the listeners of groups on the memory transporter shared by the
+// brokers created from mem.
+func memoryBroker(nodeID string, mem *memory.SharedMemory, groups ...string) *broker.ServiceBroker {
+	bkr := broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return harness.MemoryTransporter(mem)()
+		},
+	})
+	for _, group := range groups {
+		bkr.Publish(ListenerService(group))
+	}
+	bkr.Start()
+	return bkr
+}
+
+// settled waits until the listeners of groups got total deliveries and no more, and returns them.
+func settled(bkr *broker.ServiceBroker, groups map[string][]string, total int) map[string]int {
+	sum := func() int {
+		deliveries, err := Deliveries(bkr, groups)
+		Expect(err).ShouldNot(HaveOccurred())
+		sum := 0
+		for _, count := range deliveries {
+			sum += count
+		}
+		return sum
+	}
+	Eventually(sum, 5*time.Second, 50*time.Millisecond).Should(Equal(total))
+	Consistently(sum, 300*time.Millisecond, 50*time.Millisecond).Should(Equal(total))
+	deliveries, err := Deliveries(bkr, groups)
+	Expect(err).ShouldNot(HaveOccurred())
+	return deliveries
+}
+
+// start starts a memory broker per node of groups, emitter first, and waits until emitter sees all the listeners.
+func start(mem *memory.SharedMemory, groups map[string][]string, emitter string) []*broker.ServiceBroker {
+	byNode := map[string][]string{emitter: nil}
+	for group, nodeIDs := range groups {
+		for _, nodeID := range nodeIDs {
+			byNode[nodeID] = append(byNode[nodeID], group)
+		}
+	}
+	brokers := []*broker.ServiceBroker{memoryBroker(emitter, mem, byNode[emitter]...)}
+	for nodeID, nodeGroups := range byNode {
+		if nodeID != emitter {
+			brokers = append(brokers, memoryBroker(nodeID, mem, nodeGroups...))
+		}
+	}
+	for group, nodeIDs := range groups {
+		Expect(harness.WaitForEndpoints(brokers[0], time.Now().Add(5*time.Second), group+".deliveries", nodeIDs...)).Should(Succeed())
+	}
+	return brokers
+}
+
+var _ = Describe("Go event groups", func() {
+	groups := map[string][]string{
+		"mail":    {"go-mail-1", "go-mail-2"},
+		"audit":   {"go-audit-1", "go-audit-2"},
+		"tracker": {"go-emitter", "go-tracker"},
+	}
+	var mem *memory.SharedMemory
+	var brokers []*broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		brokers = start(mem, groups, "go-emitter")
+	})
+
+	AfterEach(func() {
+		for _, bkr := range brokers {
+			bkr.Stop()
+		}
+	})
+
+	for _, item := range Emissions {
+		emission := item
+
+		It("a Go emitter should "+emission.Name+" as in the event table", func() {
+			if emission.Method == "broadcastLocal" {
+				Skip(emission.GoIssue)
+			}
+			Send(brokers[0], emission, count)
+			deliveries := settled(brokers[0], groups, emission.Total(groups, "go-emitter", count))
+			if emission.GoIssue == "" {
+				Expect(emission.Check(groups, deliveries, "go-emitter", count)).Should(Succeed())
+			} else {
+				Expect(emission.CheckGoBroadcast(groups, deliveries, count)).Should(Succeed(), emission.GoIssue)
+			}
+		})
+	}
+
+	Context("with a replayed JS emitter", func() {
+		var peer *harness.ReplayPeer
+
+		BeforeEach(func() {
+			peer = harness.NewReplayPeer("js-emitter", harness.MemoryTransporter(mem)(), harness.ReplayInfo())
+			Expect(peer.Start()).Should(Succeed())
+			for group, nodeIDs := range groups {
+				Eventually(peer.Services, 5*time.Second).Should(ContainElement(And(
+					HaveKeyWithValue("name", group),
+					HaveKeyWithValue("nodes", ConsistOf(nodeIDs)),
+				)))
+			}
+		})
+
+		AfterEach(func() {
+			peer.Kill()
+		})
+
+		for _, item := range Emissions {
+			emission := item
+			if len(emission.Groups) > 0 || emission.Method == "broadcastLocal" {
+				// The replay peer sends to all groups, and broadcastLocal does not cross the wire.
+				continue
+			}
+
+			It("the Go listeners should get the events of a JS "+emission.Name+" as in the event table", func() {
+				for index := 0; index < count; index++ {
+					if emission.Method == "broadcast" {
+						peer.Broadcast(Event, map[string]interface{}{"seq": index})
+					} else {
+						peer.Emit(Event, map[string]interface{}{"seq": index})
+					}
+				}
+				deliveries := settled(brokers[0], groups, emission.Total(groups, "js-emitter", count))
+				Expect(emission.Check(groups, deliveries, "js-emitter", count)).Should(Succeed())
+			})
+		}
+	})
+})
+
+var _ = Describe("Event groups with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+		Expect(Reset(cluster.Emitter, Groups)).Should(Succeed())
+	})
+
+	for _, item := range Emissions {
+		emission := item
+
+		It("Go → JS should "+emission.Name+" as in the event table", func() {
+			if emission.Method == "broadcastLocal" {
+				Skip(emission.GoIssue)
+			}
+			Send(cluster.Emitter, emission, count)
+			deliveries := settled(cluster.Emitter, Groups, emission.Total(Groups, GoEmitter, count))
+			if emission.GoIssue == "" {
+				Expect(emission.Check(Groups, deliveries, GoEmitter, count)).Should(Succeed())
+			} else {
+				Expect(emission.CheckGoBroadcast(Groups, deliveries, count)).Should(Succeed(), emission.GoIssue)
+			}
+		})
+
+		It("JS → Go should "+emission.Name+" as in the event table", func() {
+			Expect(cluster.SendJS(emission, count)).Should(Succeed())
+			deliveries := settled(cluster.Emitter, Groups, emission.Total(Groups, JSEmitter, count))
+			Expect(emission.Check(Groups, deliveries, JSEmitter, count)).Should(Succeed())
+		})
+	}
+})
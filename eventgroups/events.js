@@ -0,0 +1,71 @@
+"use strict";
+
+// The JS nodes of the event groups suite (see contract.go), with the NATS url as first argument:
+//   js-mail, js-audit and js-emitter, with the listener services of their groups. Like the Go
+//   ListenerService, <group>.deliveries returns the number of user.created events it got and
+//   <group>.reset sets it back to 0.
+//   js-emitter also has the jsemitter service:
+//     jsemitter.actions returns $node.actions of js-emitter;
+//     jsemitter.send    sends "count" user.created events with the "method" (emit, broadcast or
+//                       broadcastLocal) to the "groups", or to all groups when there are none.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS events with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const nodes = {
+	"js-mail": ["mail"],
+	"js-audit": ["audit"],
+	"js-emitter": ["tracker"]
+};
+
+function listener(group) {
+	let deliveries = 0;
+	return {
+		name: group,
+		actions: {
+			deliveries() {
+				return deliveries;
+			},
+			reset() {
+				deliveries = 0;
+				return deliveries;
+			}
+		},
+		events: {
+			"user.created"() {
+				deliveries++;
+			}
+		}
+	};
+}
+
+const brokers = Object.keys(nodes).map(nodeID => {
+	const broker = new ServiceBroker({ transporter, nodeID, logLevel: "warn" });
+	for (const group of nodes[nodeID]) {
+		broker.createService(listener(group));
+	}
+	return broker;
+});
+
+const emitter = brokers.find(broker => broker.nodeID === "js-emitter");
+emitter.createService({
+	name: "jsemitter",
+	actions: {
+		actions(ctx) {
+			return ctx.call("$node.actions", ctx.params);
+		},
+
+		send(ctx) {
+			const { method, groups, count } = ctx.params;
+			const opts = groups && groups.length > 0 ? { groups } : {};
+			for (let index = 0; index < count; index++) {
+				emitter[method]("user.created", { seq: index }, opts);
+			}
+			return count;
+		}
+	}
+});
+
+Promise.all(brokers.map(broker => broker.start()));
@@ -0,0 +1,183 @@
+package eventgroups
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+// ListenerService is the service of a group: it listens to Event and counts the deliveries.
+//   - <group>.deliveries returns the number of deliveries;
+//   - <group>.reset sets it back to 0.
+func ListenerService(group string) moleculer.ServiceSchema {
+	var mutex sync.Mutex
+	deliveries := 0
+	return moleculer.ServiceSchema{
+		Name: group,
+		Actions: []moleculer.Action{
+			{
+				Name: "deliveries",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					return deliveries
+				},
+			},
+			{
+				Name: "reset",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					deliveries = 0
+					return deliveries
+				},
+			},
+		},
+		Events: []moleculer.Event{
+			{
+				Name: Event,
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					deliveries++
+				},
+			},
+		},
+	}
+}
+
+// Send sends count events from a Go broker with emission.
+func Send(emitter *broker.ServiceBroker, emission Emission, count int) {
+	for index := 0; index < count; index++ {
+		data := map[string]interface{}{"seq": index}
+		if emission.Method == "broadcast" {
+			emitter.Broadcast(Event, data, emission.Groups...)
+		} else {
+			emitter.Emit(Event, data, emission.Groups...)
+		}
+	}
+}
+
+// Deliveries returns the deliveries by node of the listeners of groups, asked by bkr.
+func Deliveries(bkr *broker.ServiceBroker, groups map[string][]string) (map[string]int, error) {
+	deliveries := map[string]int{}
+	for group, nodeIDs := range groups {
+		for _, nodeID := range nodeIDs {
+			result := <-bkr.Call(group+".deliveries", map[string]interface{}{}, moleculer.Options{NodeID: nodeID})
+			if result.IsError() {
+				return nil, result.Error()
+			}
+			deliveries[nodeID] = result.Int()
+		}
+	}
+	return deliveries, nil
+}
+
+// Reset sets the deliveries of the listeners of groups back to 0, asked by bkr.
+func Reset(bkr *broker.ServiceBroker, groups map[string][]string) error {
+	for group, nodeIDs := range groups {
+		for _, nodeID := range nodeIDs {
+			result := <-bkr.Call(group+".reset", map[string]interface{}{}, moleculer.Options{NodeID: nodeID})
+			if result.IsError() {
+				return result.Error()
+			}
+		}
+	}
+	return nil
+}
+
+// Cluster is a Go broker per Go node of Groups and events.js with the JS nodes, connected over NATS.
+type Cluster struct {
+	// Emitter is the broker of GoEmitter.
+	Emitter *broker.ServiceBroker
+	Brokers []*broker.ServiceBroker
+	JS      *harness.Peer
+}
+
+// goNodes returns the groups of the Go nodes of Groups, by node.
+func goNodes() map[string][]string {
+	nodes := map[string][]string{}
+	for group, nodeIDs := range Groups {
+		for _, nodeID := range nodeIDs {
+			if strings.HasPrefix(nodeID, "go-") {
+				nodes[nodeID] = append(nodes[nodeID], group)
+			}
+		}
+	}
+	return nodes
+}
+
+// Start starts events.js and the Go nodes, all connected to the NATS server at url, and waits until
+// the Go emitter and the JS emitter see all the listeners.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "events.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js}
+	nodeIDs := []string{}
+	for nodeID := range goNodes() {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+	for _, nodeID := range nodeIDs {
+		id := nodeID
+		bkr := broker.New(&moleculer.Config{
+			LogLevel: "WARN",
+			DiscoverNodeID: func() string {
+				return id
+			},
+			TransporterFactory: func() interface{} {
+				return harness.NATSTransporter(url)()
+			},
+		})
+		for _, group := range goNodes()[id] {
+			bkr.Publish(ListenerService(group))
+		}
+		bkr.Start()
+		cluster.Brokers = append(cluster.Brokers, bkr)
+		if id == GoEmitter {
+			cluster.Emitter = bkr
+		}
+	}
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Emitter, deadline, "jsemitter"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	for group, nodeIDs := range Groups {
+		if err := harness.WaitForEndpoints(cluster.Emitter, deadline, group+".deliveries", nodeIDs...); err != nil {
+			cluster.Stop()
+			return nil, err
+		}
+		if err := harness.WaitForEndpointsIn(cluster.Emitter, deadline, "jsemitter.actions", group+".deliveries", nodeIDs...); err != nil {
+			cluster.Stop()
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+// SendJS asks the JS emitter to send count events with emission.
+func (cluster *Cluster) SendJS(emission Emission, count int) error {
+	result := <-cluster.Emitter.Call("jsemitter.send", map[string]interface{}{
+		"method": emission.Method,
+		"groups": emission.Groups,
+		"count":  count,
+	})
+	return result.Error()
+}
+
+// Stop stops the Go nodes and kills events.js.
+func (cluster *Cluster) Stop() {
+	for _, bkr := range cluster.Brokers {
+		bkr.Stop()
+	}
+	cluster.JS.Kill()
+}
@@ -0,0 +1,130 @@
+package fidelity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/payload"
+	"github.com/moleculer-go/moleculer/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+// Accessor is a column of the accessor table: a moleculer.Payload method.
+type Accessor struct {
+	Name string
+	Call func(value moleculer.Payload) interface{}
+}
+
+// Accessors are the columns of the accessor table.
+var Accessors = []Accessor{
+	{"Value", func(value moleculer.Payload) interface{} { return value.Value() }},
+	{"Int", func(value moleculer.Payload) interface{} { return value.Int() }},
+	{"String", func(value moleculer.Payload) interface{} { return value.String() }},
+	{"Time", func(value moleculer.Payload) interface{} { return value.Time() }},
+	{"Array", func(value moleculer.Payload) interface{} { return value.Array() }},
+	{"MapArray", func(value moleculer.Payload) interface{} { return value.MapArray() }},
+}
+
+// Read calls the accessor and formats its result. A panic is returned as "panic: <reason>".
+func (accessor Accessor) Read(value moleculer.Payload) (result string) {
+	defer func() {
+		if reason := recover(); reason != nil {
+			result = fmt.Sprint("panic: ", reason)
+		}
+	}()
+	return format(accessor.Call(value))
+}
+
+func format(result interface{}) string {
+	switch result := result.(type) {
+	case time.Time:
+		if result.IsZero() {
+			return "zero time"
+		}
+		return result.Format(time.RFC3339Nano)
+	case []moleculer.Payload:
+		if result == nil {
+			return "nil"
+		}
+		values := make([]string, len(result))
+		for index, item := range result {
+			values[index] = format(item.Value())
+		}
+		return "[" + strings.Join(values, " ") + "]"
+	case []map[string]interface{}:
+		if result == nil {
+			return "nil"
+		}
+		return fmt.Sprint(result)
+	case string:
+		return fmt.Sprintf("%q", result)
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprintf("%T %v", result, result)
+}
+
+// ReadAll returns the result of each accessor, by accessor name.
+func ReadAll(value moleculer.Payload) map[string]string {
+	results := map[string]string{}
+	for _, accessor := range Accessors {
+		results[accessor.Name] = accessor.Read(value)
+	}
+	return results
+}
+
+var jsonSerializer = serializer.CreateJSONSerializer(log.WithField("serializer", "json"))
+
+// ParamsFromJS returns the payload a Go action gets for value.Wire in its params. moleculer-go reads
+// the params of a REQUEST as plain Go values (float64, map[string]interface{}, ...) and wraps them with payload.New.
+func ParamsFromJS(value Value) moleculer.Payload {
+	packet := []byte(`{"params":{"value":` + value.Wire + `}}`)
+	params := jsonSerializer.BytesToPayload(&packet).RawMap()["params"]
+	return payload.New(params).Get("value")
+}
+
+// ResultFromJS returns the payload a Go caller gets for value.Wire in the result of a JS action.
+// moleculer-go keeps the data of a RESPONSE as JSON and reads it with gjson.
+func ResultFromJS(value Value) moleculer.Payload {
+	packet := []byte(`{"data":{"value":` + value.Wire + `}}`)
+	return jsonSerializer.BytesToPayload(&packet).Get("data").Get("value")
+}
+
+// ToJS returns the JSON moleculer-go sends for value.Go, or the error of its serializer.
+func ToJS(value Value) (string, error) {
+	packet := map[string]interface{}{"params": map[string]interface{}{"value": value.Go}}
+	message, err := jsonSerializer.MapToPayload(&packet)
+	if err != nil {
+		return "", err
+	}
+	var sent struct {
+		Params struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(jsonSerializer.PayloadToBytes(message), &sent); err != nil {
+		return "", err
+	}
+	return string(sent.Params.Value), nil
+}
+
+// Table renders what each accessor returns for the values sent by JS, read by read, as a markdown table.
+func Table(values []Value, read func(Value) moleculer.Payload) string {
+	lines := []string{"| Value | Wire |", "|---|---|"}
+	for _, accessor := range Accessors {
+		lines[0] += " " + accessor.Name + " |"
+		lines[1] += "---|"
+	}
+	for _, value := range values {
+		line := "| " + value.Name + " | `" + value.Wire + "` |"
+		results := ReadAll(read(value))
+		for _, accessor := range Accessors {
+			line += " " + strings.ReplaceAll(results[accessor.Name], "|", `\|`) + " |"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
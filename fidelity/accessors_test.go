@@ -0,0 +1,58 @@
+package fidelity
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/moleculer-go/moleculer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Payload accessors", func() {
+	It("should read the params sent by JS as in testdata/params.md", func() {
+		expectTable(Table(Values, ParamsFromJS), "testdata/params.md")
+	})
+
+	It("should read the results sent by JS as in testdata/results.md", func() {
+		expectTable(Table(Values, ResultFromJS), "testdata/results.md")
+	})
+
+	It("should report the panics of an accessor", func() {
+		accessor := Accessor{Name: "Panic", Call: func(value moleculer.Payload) interface{} {
+			panic("no value")
+		}}
+		Expect(accessor.Read(ParamsFromJS(Values[0]))).Should(Equal("panic: no value"))
+	})
+})
+
+var _ = Describe("Go serializer", func() {
+	for _, item := range Values {
+		value := item
+		It("should send "+value.Name+" as "+orFailure(value.GoWire), func() {
+			wire, err := ToJS(value)
+			if value.GoWire == "" {
+				Expect(err).Should(HaveOccurred())
+				return
+			}
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(wire).Should(Equal(value.GoWire))
+		})
+	}
+})
+
+// expectTable compares an accessor table with a golden file and prints it, to update the file.
+func expectTable(table, golden string) {
+	fmt.Fprint(GinkgoWriter, table)
+	expected, err := ioutil.ReadFile(golden)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(table).Should(Equal(string(expected)), "the accessor table changed, update "+golden+" with the table above")
+}
+
+func orFailure(wire string) string {
+	if wire == "" {
+		return "a serialization error"
+	}
+	return wire
+}
@@ -0,0 +1,95 @@
+package fidelity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+const (
+	// GoNodeID is the node of the Go echo service.
+	GoNodeID = "go-node"
+	// JSNodeID is the node of echo.js.
+	JSNodeID = "js-node"
+)
+
+// EchoService is the Go echo service:
+//   - goecho.echo returns the "value" param unchanged and what the Accessors read from it, by accessor name;
+//   - goecho.params returns the Shape of its params;
+//   - goecho.primitive returns the Go value of the primitive named by the "name" param.
+var EchoService = moleculer.ServiceSchema{
+	Name: "goecho",
+	Actions: []moleculer.Action{
+		{
+			Name: "echo",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				value := params.Get("value")
+				return map[string]interface{}{
+					"value":     value.Value(),
+					"accessors": ReadAll(value),
+				}
+			},
+		},
+		{
+			Name: "params",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return Shape(params)
+			},
+		},
+		{
+			Name: "primitive",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				primitive, found := findPrimitive(params.Get("name").String())
+				if !found {
+					return errors.New("unknown primitive: " + params.Get("name").String())
+				}
+				return primitive.Go
+			},
+		},
+	},
+}
+
+// Echo is a Go broker with the Go echo service and echo.js, connected over NATS.
+type Echo struct {
+	Broker *broker.ServiceBroker
+	JS     *harness.Peer
+}
+
+// Start starts echo.js and a Go broker with the EchoService, both connected to the NATS server at url,
+// and waits until each side sees the echo service of the other.
+func Start(dir, url string) (*Echo, error) {
+	js, err := harness.StartNode(dir, "echo.js", map[string]string{"NODE_ID": JSNodeID}, url)
+	if err != nil {
+		return nil, err
+	}
+	bkr := broker.New(&moleculer.Config{
+		LogLevel:    "WARN",
+		Transporter: url,
+		DiscoverNodeID: func() string {
+			return GoNodeID
+		},
+	})
+	bkr.Publish(EchoService)
+	bkr.Start()
+	echo := &Echo{Broker: bkr, JS: js}
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(bkr, deadline, "jsecho"); err != nil {
+		echo.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(bkr, deadline, "jsecho.services", "goecho"); err != nil {
+		echo.Stop()
+		return nil, err
+	}
+	return echo, nil
+}
+
+// Stop stops the Go broker and kills echo.js.
+func (echo *Echo) Stop() {
+	echo.Broker.Stop()
+	echo.JS.Kill()
+}
@@ -0,0 +1,102 @@
+"use strict";
+
+// The JS echo service of the fidelity suite (see values.go and primitives.go), with the NATS url as first argument.
+//   jsecho.echo      returns the "value" param unchanged and how JS sees it.
+//   jsecho.roundtrip builds the value named by the "name" param, sends it to goecho.echo and
+//                    returns how JS sees the value it gets back and what the Go accessors read.
+//   jsecho.params    returns how JS sees ctx.params.
+//   jsecho.primitive returns the primitive named by the "name" param.
+//   jsecho.callgo    calls goecho.params with the primitive named by the "name" param as params and
+//                    goecho.primitive, and returns the Go shape of the params and how JS sees the result.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS echo with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({ transporter, nodeID: process.env["NODE_ID"], logLevel: "info" });
+
+// The values of the fidelity table, by Value.Name.
+const values = {
+	"integer": 42,
+	"int64 beyond 2^53": 9007199254740993,
+	"float": 3.14159,
+	"NaN": NaN,
+	"Infinity": Infinity,
+	"date": new Date(Date.UTC(2021, 2, 4, 5, 6, 7, 8)),
+	"nested arrays of maps": [{ id: 1, tags: [{ name: "a" }] }, { id: 2, tags: [] }],
+	"null": null,
+	"empty object": {},
+	"empty array": [],
+	"unicode": "héllo wörld ✓ 日本語 🚀",
+	"binary": Buffer.from([0, 1, 2, 254, 255])
+};
+
+// The primitives of the primitive table, by Primitive.Name.
+const primitives = {
+	"true": true,
+	"false": false,
+	"number": 42,
+	"zero": 0,
+	"string": "hello",
+	"empty string": "",
+	"null": null,
+	"array": [1, "two", { three: 3 }]
+};
+
+// describe tells the JS type of a value, followed by the value.
+function describe(value) {
+	if (value === null) return "null";
+	if (value === undefined) return "undefined";
+	if (Buffer.isBuffer(value)) return "Buffer " + value.toString("hex");
+	if (value instanceof Date) return "Date " + value.toISOString();
+	if (Array.isArray(value)) return "Array " + JSON.stringify(value);
+	if (typeof value === "object") return "Object " + JSON.stringify(value);
+	return typeof value + " " + String(value);
+}
+
+broker.createService({
+	name: "jsecho",
+	actions: {
+		services(ctx) {
+			return ctx.call("$node.services");
+		},
+
+		echo(ctx) {
+			return { value: ctx.params.value, type: describe(ctx.params.value) };
+		},
+
+		async roundtrip(ctx) {
+			const name = ctx.params.name;
+			if (!(name in values)) {
+				throw new Error("unknown value: " + name);
+			}
+			const back = await ctx.call("goecho.echo", { value: values[name] });
+			return { type: describe(back.value), accessors: back.accessors };
+		},
+
+		params(ctx) {
+			return describe(ctx.params);
+		},
+
+		primitive(ctx) {
+			const name = ctx.params.name;
+			if (!(name in primitives)) {
+				throw new Error("unknown primitive: " + name);
+			}
+			return primitives[name];
+		},
+
+		async callgo(ctx) {
+			const name = ctx.params.name;
+			if (!(name in primitives)) {
+				throw new Error("unknown primitive: " + name);
+			}
+			const params = await ctx.call("goecho.params", primitives[name]);
+			const result = await ctx.call("goecho.primitive", { name });
+			return { params, result: describe(result) };
+		}
+	}
+});
+
+broker.start();
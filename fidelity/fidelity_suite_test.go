@@ -0,0 +1,20 @@
+package fidelity
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFidelity(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Payload Fidelity Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyEcho.Stop()
+	harness.StopEmbeddedNATS()
+})
@@ -0,0 +1,49 @@
+package fidelity
+
+import (
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// echo is started by lazyEcho.
+var echo *Echo
+
+// lazyEcho starts the echo services once, for all the specs that need moleculer JS.
+var lazyEcho = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	echo, err = Start(".", harness.NATSURL())
+	return echo, err
+})
+
+var _ = Describe("Round trips with moleculer JS", func() {
+	BeforeEach(lazyEcho.Start)
+
+	for _, item := range Values {
+		value := item
+
+		It("Go → JS → Go should keep "+value.Name, func() {
+			if value.GoWire == "" {
+				// moleculer-go panics in a goroutine when it cannot serialize a request, which ends the test binary.
+				Skip("moleculer-go cannot serialize " + value.Name)
+			}
+			result := <-echo.Broker.Call("jsecho.echo", map[string]interface{}{"value": value.Go})
+			Expect(result.Error()).ShouldNot(HaveOccurred())
+			Expect(result.Get("type").String()).Should(Equal(value.InJS))
+			Expect(result.Get("value").Value()).Should(Equal(value.BackInGo))
+		})
+
+		It("JS → Go → JS should keep "+value.Name, func() {
+			result := <-echo.Broker.Call("jsecho.roundtrip", map[string]interface{}{"name": value.Name})
+			Expect(result.Error()).ShouldNot(HaveOccurred())
+			Expect(result.Get("type").String()).Should(Equal(value.BackInJS))
+
+			accessors := map[string]string{}
+			for name, read := range result.Get("accessors").Map() {
+				accessors[name] = read.String()
+			}
+			Expect(accessors).Should(Equal(ReadAll(ParamsFromJS(value))))
+		})
+	}
+})
@@ -0,0 +1,160 @@
+package fidelity
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/payload"
+)
+
+// Primitive is a row of the primitive table: a value that is the whole params of a call or
+// the whole result of an action, instead of a field of an object.
+type Primitive struct {
+	// Name identifies the primitive in echo.js and in the spec descriptions.
+	Name string
+	// Go is the params of the Go calls and the result of goecho.primitive.
+	Go interface{}
+	// GoWire is the JSON moleculer-go sends for Go, as params and as result.
+	GoWire string
+	// Wire is the JSON moleculer JS sends for the value of echo.js, as params and as result.
+	Wire string
+	// ParamsInJS is how a JS action sees ctx.params when Go calls it with Go, see describe() in echo.js.
+	ParamsInJS string
+	// ResultInJS is how JS sees the result of goecho.primitive, see describe() in echo.js.
+	ResultInJS string
+	// ParamsInGo is the Shape of the params of a Go action called by JS with the value of echo.js.
+	ParamsInGo string
+	// ResultInGo is the Shape of the result Go gets from jsecho.primitive.
+	ResultInGo string
+}
+
+// Primitives is the primitive table. The falsy values are listed on their own: JS code often
+// replaces them with a default.
+var Primitives = []Primitive{
+	{
+		Name:       "true",
+		Go:         true,
+		GoWire:     `true`,
+		Wire:       `true`,
+		ParamsInJS: "boolean true",
+		ResultInJS: "boolean true",
+		ParamsInGo: "exists: true, map: false, array: false, value: bool true",
+		ResultInGo: "exists: true, map: false, array: false, value: bool true",
+	},
+	{
+		Name:       "false",
+		Go:         false,
+		GoWire:     `false`,
+		Wire:       `false`,
+		ParamsInJS: "boolean false",
+		ResultInJS: "boolean false",
+		ParamsInGo: "exists: true, map: false, array: false, value: bool false",
+		ResultInGo: "exists: true, map: false, array: false, value: bool false",
+	},
+	{
+		Name:       "number",
+		Go:         42,
+		GoWire:     `42`,
+		Wire:       `42`,
+		ParamsInJS: "number 42",
+		ResultInJS: "number 42",
+		ParamsInGo: "exists: true, map: false, array: false, value: float64 42",
+		ResultInGo: "exists: true, map: false, array: false, value: float64 42",
+	},
+	{
+		Name:       "zero",
+		Go:         0,
+		GoWire:     `0`,
+		Wire:       `0`,
+		ParamsInJS: "number 0",
+		ResultInJS: "number 0",
+		ParamsInGo: "exists: true, map: false, array: false, value: float64 0",
+		ResultInGo: "exists: true, map: false, array: false, value: float64 0",
+	},
+	{
+		Name:       "string",
+		Go:         "hello",
+		GoWire:     `"hello"`,
+		Wire:       `"hello"`,
+		ParamsInJS: "string hello",
+		ResultInJS: "string hello",
+		ParamsInGo: `exists: true, map: false, array: false, value: "hello"`,
+		ResultInGo: `exists: true, map: false, array: false, value: "hello"`,
+	},
+	{
+		Name:       "empty string",
+		Go:         "",
+		GoWire:     `""`,
+		Wire:       `""`,
+		ParamsInJS: "string ",
+		ResultInJS: "string ",
+		ParamsInGo: `exists: true, map: false, array: false, value: ""`,
+		ResultInGo: `exists: true, map: false, array: false, value: ""`,
+	},
+	{
+		// A nil payload does not exist in Go, but the null result of a JS action does.
+		Name:       "null",
+		Go:         nil,
+		GoWire:     `null`,
+		Wire:       `null`,
+		ParamsInJS: "null",
+		ResultInJS: "null",
+		ParamsInGo: "exists: false, map: false, array: false, value: nil",
+		ResultInGo: "exists: true, map: false, array: false, value: nil",
+	},
+	{
+		Name:       "array",
+		Go:         []interface{}{1, "two", map[string]interface{}{"three": 3}},
+		GoWire:     `[1,"two",{"three":3}]`,
+		Wire:       `[1,"two",{"three":3}]`,
+		ParamsInJS: `Array [1,"two",{"three":3}]`,
+		ResultInJS: `Array [1,"two",{"three":3}]`,
+		ParamsInGo: `exists: true, map: false, array: true, value: []interface {} [1 two map[three:3]]`,
+		ResultInGo: `exists: true, map: false, array: true, value: []interface {} [1 two map[three:3]]`,
+	},
+}
+
+// Shape tells whether a payload exists, is a map or an array, and its value.
+func Shape(value moleculer.Payload) string {
+	return fmt.Sprintf("exists: %v, map: %v, array: %v, value: %s", value.Exists(), value.IsMap(), value.IsArray(), format(value.Value()))
+}
+
+// PrimitiveParamsFromJS returns the params a Go action gets when JS calls it with primitive.Wire.
+func PrimitiveParamsFromJS(primitive Primitive) moleculer.Payload {
+	packet := []byte(`{"params":` + primitive.Wire + `}`)
+	return payload.New(jsonSerializer.BytesToPayload(&packet).RawMap()["params"])
+}
+
+// PrimitiveResultFromJS returns the result a Go caller gets when a JS action returns primitive.Wire.
+func PrimitiveResultFromJS(primitive Primitive) moleculer.Payload {
+	packet := []byte(`{"data":` + primitive.Wire + `}`)
+	return jsonSerializer.BytesToPayload(&packet).Get("data")
+}
+
+// PrimitiveToJS returns the JSON moleculer-go sends for primitive.Go as the params of a REQUEST.
+// The data of a RESPONSE is serialized the same way.
+func PrimitiveToJS(primitive Primitive) (string, error) {
+	packet := map[string]interface{}{"params": primitive.Go}
+	message, err := jsonSerializer.MapToPayload(&packet)
+	if err != nil {
+		return "", err
+	}
+	var sent struct {
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(jsonSerializer.PayloadToBytes(message), &sent); err != nil {
+		return "", err
+	}
+	return string(sent.Params), nil
+}
+
+// findPrimitive returns the primitive with the given name.
+func findPrimitive(name string) (Primitive, bool) {
+	for _, primitive := range Primitives {
+		if primitive.Name == name {
+			return primitive, true
+		}
+	}
+	return Primitive{}, false
+}
@@ -0,0 +1,49 @@
+package fidelity
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Primitive params and results", func() {
+	for _, item := range Primitives {
+		primitive := item
+
+		It("should be sent by Go as "+primitive.GoWire+" for "+primitive.Name, func() {
+			Expect(PrimitiveToJS(primitive)).Should(Equal(primitive.GoWire))
+		})
+
+		It("should read "+primitive.Name+" sent by JS as params", func() {
+			Expect(Shape(PrimitiveParamsFromJS(primitive))).Should(Equal(primitive.ParamsInGo))
+		})
+
+		It("should read "+primitive.Name+" returned by JS as result", func() {
+			Expect(Shape(PrimitiveResultFromJS(primitive))).Should(Equal(primitive.ResultInGo))
+		})
+	}
+})
+
+var _ = Describe("Primitive params and results with moleculer JS", func() {
+	BeforeEach(lazyEcho.Start)
+
+	for _, item := range Primitives {
+		primitive := item
+
+		It("Go → JS should send "+primitive.Name+" as params and get it as result", func() {
+			params := <-echo.Broker.Call("jsecho.params", primitive.Go)
+			Expect(params.Error()).ShouldNot(HaveOccurred())
+			Expect(params.String()).Should(Equal(primitive.ParamsInJS))
+
+			result := <-echo.Broker.Call("jsecho.primitive", map[string]interface{}{"name": primitive.Name})
+			Expect(result.Error()).ShouldNot(HaveOccurred())
+			Expect(Shape(result)).Should(Equal(primitive.ResultInGo))
+		})
+
+		It("JS → Go should send "+primitive.Name+" as params and get it as result", func() {
+			result := <-echo.Broker.Call("jsecho.callgo", map[string]interface{}{"name": primitive.Name})
+			Expect(result.Error()).ShouldNot(HaveOccurred())
+			Expect(result.Get("params").String()).Should(Equal(primitive.ParamsInGo))
+			Expect(result.Get("result").String()).Should(Equal(primitive.ResultInJS))
+		})
+	}
+})
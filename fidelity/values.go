@@ -0,0 +1,150 @@
+// Package fidelity checks how values keep, or lose, their type when they cross the boundary between
+// moleculer-go and moleculer JS, and what the moleculer.Payload accessors return for them.
+//
+// Each Value is sent from Go to the JS echo service and back (Go → JS → Go) and from JS to the Go
+// echo service and back (JS → Go → JS), see echo.js. The accessor tables (Table) are built from the
+// JSON moleculer JS sends, so they need no Node.js.
+package fidelity
+
+import (
+	"math"
+	"time"
+)
+
+// Value is a row of the fidelity table: a value sent as the "value" param of an echo call.
+type Value struct {
+	// Name identifies the value in echo.js and in the spec descriptions.
+	Name string
+	// Go is the value as a Go service sends it.
+	Go interface{}
+	// GoWire is the JSON moleculer-go sends for Go, empty when moleculer-go cannot serialize it.
+	GoWire string
+	// InJS is how the JS echo service sees the value sent by Go, see describe() in echo.js.
+	InJS string
+	// BackInGo is the Value() of the payload Go gets back from the JS echo service.
+	BackInGo interface{}
+	// Wire is the JSON moleculer JS sends for the value built by echo.js (JSON.stringify).
+	Wire string
+	// BackInJS is how JS sees the value it gets back from the Go echo service, see describe() in echo.js.
+	BackInJS string
+}
+
+// Date is the date of the "date" value, with millisecond precision as JS dates have.
+var Date = time.Date(2021, time.March, 4, 5, 6, 7, 8000000, time.UTC)
+
+// Values is the fidelity table. The echo calls nest each value in the params, as {value: ...}.
+var Values = []Value{
+	{
+		Name:     "integer",
+		Go:       42,
+		GoWire:   `42`,
+		InJS:     "number 42",
+		BackInGo: 42.0,
+		Wire:     `42`,
+		BackInJS: "number 42",
+	},
+	{
+		// 2^53 + 1 is not a JS number: JS rounds it to 2^53, and gjson reads the numbers as float64.
+		Name:     "int64 beyond 2^53",
+		Go:       int64(9007199254740993),
+		GoWire:   `9007199254740993`,
+		InJS:     "number 9007199254740992",
+		BackInGo: 9007199254740992.0,
+		Wire:     `9007199254740992`,
+		BackInJS: "number 9007199254740992",
+	},
+	{
+		Name:     "float",
+		Go:       3.14159,
+		GoWire:   `3.14159`,
+		InJS:     "number 3.14159",
+		BackInGo: 3.14159,
+		Wire:     `3.14159`,
+		BackInJS: "number 3.14159",
+	},
+	{
+		// JSON has no NaN: moleculer-go fails to serialize the request, JSON.stringify sends null.
+		Name:     "NaN",
+		Go:       math.NaN(),
+		Wire:     `null`,
+		BackInJS: "null",
+	},
+	{
+		Name:     "Infinity",
+		Go:       math.Inf(1),
+		Wire:     `null`,
+		BackInJS: "null",
+	},
+	{
+		// Dates travel as strings: JS gets back a string, not a Date.
+		Name:     "date",
+		Go:       Date,
+		GoWire:   `"2021-03-04T05:06:07.008Z"`,
+		InJS:     "string 2021-03-04T05:06:07.008Z",
+		BackInGo: "2021-03-04T05:06:07.008Z",
+		Wire:     `"2021-03-04T05:06:07.008Z"`,
+		BackInJS: "string 2021-03-04T05:06:07.008Z",
+	},
+	{
+		Name: "nested arrays of maps",
+		Go: []interface{}{
+			map[string]interface{}{"id": 1, "tags": []interface{}{map[string]interface{}{"name": "a"}}},
+			map[string]interface{}{"id": 2, "tags": []interface{}{}},
+		},
+		GoWire: `[{"id":1,"tags":[{"name":"a"}]},{"id":2,"tags":[]}]`,
+		InJS:   `Array [{"id":1,"tags":[{"name":"a"}]},{"id":2,"tags":[]}]`,
+		BackInGo: []interface{}{
+			map[string]interface{}{"id": 1.0, "tags": []interface{}{map[string]interface{}{"name": "a"}}},
+			map[string]interface{}{"id": 2.0, "tags": []interface{}{}},
+		},
+		Wire:     `[{"id":1,"tags":[{"name":"a"}]},{"id":2,"tags":[]}]`,
+		BackInJS: `Array [{"id":1,"tags":[{"name":"a"}]},{"id":2,"tags":[]}]`,
+	},
+	{
+		Name:     "null",
+		Go:       nil,
+		GoWire:   `null`,
+		InJS:     "null",
+		BackInGo: nil,
+		Wire:     `null`,
+		BackInJS: "null",
+	},
+	{
+		Name:     "empty object",
+		Go:       map[string]interface{}{},
+		GoWire:   `{}`,
+		InJS:     "Object {}",
+		BackInGo: map[string]interface{}{},
+		Wire:     `{}`,
+		BackInJS: "Object {}",
+	},
+	{
+		Name:     "empty array",
+		Go:       []interface{}{},
+		GoWire:   `[]`,
+		InJS:     "Array []",
+		BackInGo: []interface{}{},
+		Wire:     `[]`,
+		BackInJS: "Array []",
+	},
+	{
+		Name:     "unicode",
+		Go:       "héllo wörld ✓ 日本語 🚀",
+		GoWire:   `"héllo wörld ✓ 日本語 🚀"`,
+		InJS:     "string héllo wörld ✓ 日本語 🚀",
+		BackInGo: "héllo wörld ✓ 日本語 🚀",
+		Wire:     `"héllo wörld ✓ 日本語 🚀"`,
+		BackInJS: "string héllo wörld ✓ 日本語 🚀",
+	},
+	{
+		// moleculer-go sends []byte as a string of the raw bytes (invalid UTF-8 becomes U+FFFD) and JS
+		// sends a Buffer as {type: "Buffer", data: [...]}: neither side gets a Buffer or a []byte back.
+		Name:     "binary",
+		Go:       []byte{0, 1, 2, 254, 255},
+		GoWire:   "\"\\u0000\\u0001\\u0002\uFFFD\uFFFD\"",
+		InJS:     "string \x00\x01\x02\uFFFD\uFFFD",
+		BackInGo: "\x00\x01\x02\uFFFD\uFFFD",
+		Wire:     `{"type":"Buffer","data":[0,1,2,254,255]}`,
+		BackInJS: `Object {"data":[0,1,2,254,255],"type":"Buffer"}`,
+	},
+}
@@ -3,7 +3,13 @@ module github.com/moleculer-go/compatibility
 go 1.12
 
 require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/go-redis/redis/v8 v8.11.2
 	github.com/moleculer-go/moleculer v0.3.10
+	github.com/nats-io/nats-server/v2 v2.8.2
+	github.com/nats-io/nats.go v1.15.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.18.1
+	github.com/sirupsen/logrus v1.4.2
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,70 @@
+package harness
+
+import (
+	"sync"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+// StartTimeout is how long the suites wait for their nodes to discover each other.
+var StartTimeout = 30 * time.Second
+
+// Fixture is what a suite starts once for all its specs, e.g. its Go brokers and JS nodes.
+type Fixture interface {
+	Stop()
+}
+
+// LazyFixture is a fixture started by the first spec that needs it and stopped by the AfterSuite,
+// so that a suite run with only its Go specs never starts moleculer JS:
+//
+//	var cluster *Cluster
+//	var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+//		var err error
+//		cluster, err = Start(".", harness.NATSURL())
+//		return cluster, err
+//	})
+//
+//	BeforeEach(lazyCluster.Start)
+//	AfterSuite(lazyCluster.Stop)
+type LazyFixture struct {
+	start func() (Fixture, error)
+
+	mutex   sync.Mutex
+	fixture Fixture
+}
+
+// NewLazyFixture returns a fixture started with start on first use.
+func NewLazyFixture(start func() (Fixture, error)) *LazyFixture {
+	return &LazyFixture{start: start}
+}
+
+// Start starts the fixture unless it is running. The spec fails when the fixture cannot start, and
+// the next spec tries again.
+func (lazy *LazyFixture) Start() {
+	lazy.mutex.Lock()
+	defer lazy.mutex.Unlock()
+	if lazy.fixture != nil {
+		return
+	}
+	fixture, err := lazy.start()
+	gomega.ExpectWithOffset(1, err).ShouldNot(gomega.HaveOccurred())
+	lazy.fixture = fixture
+}
+
+// Started tells whether a spec started the fixture.
+func (lazy *LazyFixture) Started() bool {
+	lazy.mutex.Lock()
+	defer lazy.mutex.Unlock()
+	return lazy.fixture != nil
+}
+
+// Stop stops the fixture if it was started.
+func (lazy *LazyFixture) Stop() {
+	lazy.mutex.Lock()
+	defer lazy.mutex.Unlock()
+	if lazy.fixture != nil {
+		lazy.fixture.Stop()
+		lazy.fixture = nil
+	}
+}
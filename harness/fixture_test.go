@@ -0,0 +1,45 @@
+package harness
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// counter counts the starts and stops of a fixture.
+type counter struct {
+	starts, stops int
+}
+
+func (c *counter) Stop() {
+	c.stops++
+}
+
+var _ = Describe("LazyFixture", func() {
+	It("should start the fixture once and stop it once", func() {
+		fixture := &counter{}
+		lazy := NewLazyFixture(func() (Fixture, error) {
+			fixture.starts++
+			return fixture, nil
+		})
+		Expect(lazy.Started()).Should(BeFalse())
+
+		lazy.Start()
+		lazy.Start()
+		Expect(lazy.Started()).Should(BeTrue())
+		Expect(fixture.starts).Should(Equal(1))
+
+		lazy.Stop()
+		lazy.Stop()
+		Expect(lazy.Started()).Should(BeFalse())
+		Expect(fixture.stops).Should(Equal(1))
+	})
+
+	It("should not stop a fixture that was never started", func() {
+		fixture := &counter{}
+		lazy := NewLazyFixture(func() (Fixture, error) {
+			return fixture, nil
+		})
+		lazy.Stop()
+		Expect(fixture.stops).Should(Equal(0))
+	})
+})
@@ -0,0 +1,154 @@
+package harness
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// ConvergeTimeout is the default time EventuallyConverge waits for the registry to match.
+var ConvergeTimeout = 10 * time.Second
+
+// EventuallyConverge polls the broker registry until the matcher passes, e.g.
+//
+//	EventuallyConverge(bkr).Should(HaveExactlyServices("$node", "user", "profile"))
+//
+// Optional intervals follow gomega.Eventually (timeout, polling interval) and default to ConvergeTimeout.
+func EventuallyConverge(bkr *broker.ServiceBroker, intervals ...interface{}) gomega.AsyncAssertion {
+	if len(intervals) == 0 {
+		intervals = []interface{}{ConvergeTimeout, PollInterval}
+	}
+	return gomega.EventuallyWithOffset(1, func() (Snapshot, error) {
+		return TakeSnapshot(bkr)
+	}, intervals...)
+}
+
+// HaveExactlyServices succeeds when the available services of a Snapshot are exactly the given names,
+// each listed once. The actual value may also be a []Service.
+func HaveExactlyServices(names ...string) types.GomegaMatcher {
+	expected := append([]string{}, names...)
+	sort.Strings(expected)
+	return &exactlyServicesMatcher{expected: expected}
+}
+
+type exactlyServicesMatcher struct {
+	expected []string
+	snapshot Snapshot
+	missing  []string
+	extra    []string
+	repeated []string
+}
+
+func toSnapshot(actual interface{}) (Snapshot, error) {
+	switch value := actual.(type) {
+	case Snapshot:
+		return value, nil
+	case *Snapshot:
+		return *value, nil
+	case []Service:
+		return Snapshot{Services: value}, nil
+	}
+	return Snapshot{}, fmt.Errorf("expected a harness.Snapshot or []harness.Service, got %T", actual)
+}
+
+func (matcher *exactlyServicesMatcher) Match(actual interface{}) (bool, error) {
+	snapshot, err := toSnapshot(actual)
+	if err != nil {
+		return false, err
+	}
+	matcher.snapshot = snapshot
+	matcher.missing, matcher.extra, matcher.repeated = []string{}, []string{}, []string{}
+
+	counts := map[string]int{}
+	for _, name := range snapshot.AvailableServices() {
+		counts[name]++
+	}
+	for _, name := range matcher.expected {
+		if counts[name] == 0 {
+			matcher.missing = append(matcher.missing, name)
+		}
+	}
+	for name, count := range counts {
+		if !contains(matcher.expected, name) {
+			matcher.extra = append(matcher.extra, name)
+		}
+		if count > 1 {
+			matcher.repeated = append(matcher.repeated, fmt.Sprint(name, " (x", count, ")"))
+		}
+	}
+	sort.Strings(matcher.extra)
+	sort.Strings(matcher.repeated)
+	return len(matcher.missing) == 0 && len(matcher.extra) == 0 && len(matcher.repeated) == 0, nil
+}
+
+func (matcher *exactlyServicesMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the registry to have exactly the services %v\n%s\n\nactual registry:\n%s",
+		matcher.expected, matcher.diff(), matcher.snapshot)
+}
+
+func (matcher *exactlyServicesMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected the registry not to have exactly the services %v\n\nactual registry:\n%s",
+		matcher.expected, matcher.snapshot)
+}
+
+func (matcher *exactlyServicesMatcher) diff() string {
+	lines := []string{}
+	for _, name := range matcher.missing {
+		lines = append(lines, "  - "+name+"  (missing)")
+	}
+	for _, name := range matcher.extra {
+		lines = append(lines, "  + "+name+"  (unexpected)")
+	}
+	for _, name := range matcher.repeated {
+		lines = append(lines, "  ! "+name+"  (duplicated)")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// HaveEndpointOn succeeds when a Service, Action or Event has an available endpoint on nodeID.
+// Use it with the lookups of Snapshot, e.g.
+//
+//	Expect(snapshot.Service("profile")).To(HaveEndpointOn("js-node"))
+func HaveEndpointOn(nodeID string) types.GomegaMatcher {
+	return &endpointMatcher{nodeID: nodeID}
+}
+
+type endpointMatcher struct {
+	nodeID    string
+	name      string
+	endpoints []Endpoint
+}
+
+func (matcher *endpointMatcher) Match(actual interface{}) (bool, error) {
+	switch value := actual.(type) {
+	case Service:
+		matcher.name, matcher.endpoints = "service "+value.Name, value.Endpoints
+	case Action:
+		matcher.name, matcher.endpoints = "action "+value.Name, value.Endpoints
+	case Event:
+		matcher.name, matcher.endpoints = "event "+value.Name, value.Endpoints
+	default:
+		return false, fmt.Errorf("HaveEndpointOn expects a harness.Service, harness.Action or harness.Event, got %T", actual)
+	}
+	for _, endpoint := range matcher.endpoints {
+		if endpoint.NodeID == matcher.nodeID && endpoint.Available {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (matcher *endpointMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected %s to have an available endpoint on node %s\n  - %s  (missing)\nactual endpoints: [%s]",
+		matcher.name, matcher.nodeID, matcher.nodeID, endpointsString(matcher.endpoints))
+}
+
+func (matcher *endpointMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected %s not to have an available endpoint on node %s\n  + %s  (unexpected)\nactual endpoints: [%s]",
+		matcher.name, matcher.nodeID, matcher.nodeID, endpointsString(matcher.endpoints))
+}
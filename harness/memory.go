@@ -0,0 +1,16 @@
+package harness
+
+import (
+	"github.com/moleculer-go/moleculer/transit"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+)
+
+// MemoryTransporter returns a factory of memory transports that all share mem, for the Go-only
+// specs: the nodes created with it see each other, and only each other.
+func MemoryTransporter(mem *memory.SharedMemory) func() transit.Transport {
+	return func() transit.Transport {
+		transport := memory.Create(log.WithField("transport", "memory"), mem)
+		return &transport
+	}
+}
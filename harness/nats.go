@@ -0,0 +1,123 @@
+package harness
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/moleculer/serializer"
+	"github.com/moleculer-go/moleculer/transit"
+	"github.com/moleculer-go/moleculer/transit/nats"
+	"github.com/nats-io/nats-server/v2/server"
+	log "github.com/sirupsen/logrus"
+)
+
+// NATSReadyTimeout is how long StartNATS waits for the server to accept connections.
+var NATSReadyTimeout = 10 * time.Second
+
+// NATSServer is a NATS server running in the test process.
+type NATSServer struct {
+	server *server.Server
+}
+
+// StartNATS starts an in-process NATS server on a random local port.
+func StartNATS() (*NATSServer, error) {
+	s, err := server.NewServer(&server.Options{
+		Host:   "127.0.0.1",
+		Port:   server.RANDOM_PORT,
+		NoLog:  true,
+		NoSigs: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.Start()
+	if !s.ReadyForConnections(NATSReadyTimeout) {
+		s.Shutdown()
+		return nil, fmt.Errorf("embedded NATS server not ready after %s", NATSReadyTimeout)
+	}
+	return &NATSServer{server: s}, nil
+}
+
+// URL returns the nats:// URL of the server.
+func (n *NATSServer) URL() string {
+	return n.server.ClientURL()
+}
+
+// Host returns the host the server listens on.
+func (n *NATSServer) Host() string {
+	return n.server.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the port the server listens on.
+func (n *NATSServer) Port() int {
+	return n.server.Addr().(*net.TCPAddr).Port
+}
+
+// Shutdown stops the server and closes its connections.
+func (n *NATSServer) Shutdown() {
+	n.server.Shutdown()
+	n.server.WaitForShutdown()
+}
+
+var embeddedNATS struct {
+	sync.Mutex
+	server *NATSServer
+}
+
+// EmbeddedNATS returns the shared in-process NATS server of the test binary, starting it on first use.
+// Suites use it when NATS_HOST is not set; StopEmbeddedNATS shuts it down.
+func EmbeddedNATS() (*NATSServer, error) {
+	embeddedNATS.Lock()
+	defer embeddedNATS.Unlock()
+	if embeddedNATS.server == nil {
+		s, err := StartNATS()
+		if err != nil {
+			return nil, err
+		}
+		embeddedNATS.server = s
+	}
+	return embeddedNATS.server, nil
+}
+
+// NATSURL returns the URL of the NATS server at NATS_HOST, or of the embedded NATS server when
+// NATS_HOST is not set. It panics when the embedded server cannot start: in a spec Ginkgo reports
+// the panic as a failure, in a package variable the test binary stops with the error.
+func NATSURL() string {
+	if host := os.Getenv("NATS_HOST"); host != "" {
+		return "nats://" + host + ":4222"
+	}
+	server, err := EmbeddedNATS()
+	if err != nil {
+		panic("could not start the embedded NATS server: " + err.Error())
+	}
+	return server.URL()
+}
+
+// StopEmbeddedNATS shuts down the shared server, if it was started.
+func StopEmbeddedNATS() {
+	embeddedNATS.Lock()
+	defer embeddedNATS.Unlock()
+	if embeddedNATS.server != nil {
+		embeddedNATS.server.Shutdown()
+		embeddedNATS.server = nil
+	}
+}
+
+// NATSTransporter returns a factory of the NATS transports of the Go nodes connected to url, with the
+// JSON serializer and reconnecting forever. The connections are named after the test package.
+func NATSTransporter(url string) func() transit.Transport {
+	return func() transit.Transport {
+		return nats.CreateNatsTransporter(nats.NATSOptions{
+			URL:            url,
+			Name:           testPackage,
+			Logger:         log.WithField("transport", "nats"),
+			Serializer:     serializer.CreateJSONSerializer(log.WithField("serializer", "json")),
+			AllowReconnect: true,
+			ReconnectWait:  2 * time.Second,
+			MaxReconnect:   -1,
+		})
+	}
+}
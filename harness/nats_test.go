@@ -0,0 +1,46 @@
+package harness
+
+import (
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Embedded NATS", func() {
+	It("should connect moleculer brokers on a random port", func() {
+		server, err := StartNATS()
+		Expect(err).ShouldNot(HaveOccurred())
+		defer server.Shutdown()
+		Expect(server.Port()).ShouldNot(Equal(4222))
+		Expect(server.URL()).Should(HavePrefix("nats://" + server.Host() + ":"))
+
+		local := broker.New(&moleculer.Config{LogLevel: "ERROR", Transporter: server.URL()})
+		remote := broker.New(&moleculer.Config{LogLevel: "ERROR", Transporter: server.URL()})
+		remote.Publish(mathService)
+		local.Start()
+		remote.Start()
+		defer local.Stop()
+		defer remote.Stop()
+
+		Expect(WaitForServices(local, time.Now().Add(10*time.Second), "math")).Should(Succeed())
+		Expect((<-local.Call("math.add", map[string]interface{}{"a": 1, "b": 2})).Int()).Should(Equal(3))
+	})
+
+	It("should share one embedded server", func() {
+		first, err := EmbeddedNATS()
+		Expect(err).ShouldNot(HaveOccurred())
+		second, err := EmbeddedNATS()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(second).Should(BeIdenticalTo(first))
+
+		StopEmbeddedNATS()
+		third, err := EmbeddedNATS()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(third.URL()).ShouldNot(BeEmpty())
+		StopEmbeddedNATS()
+	})
+})
@@ -0,0 +1,44 @@
+package harness
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// outputBuffer keeps a copy of a process output stream and forwards it,
+// line by line and prefixed with the peer name, to another writer.
+type outputBuffer struct {
+	mutex   sync.Mutex
+	buffer  bytes.Buffer
+	pending []byte
+	prefix  []byte
+	forward io.Writer
+}
+
+func newOutputBuffer(name string, forward io.Writer) *outputBuffer {
+	return &outputBuffer{prefix: []byte("[" + name + "] "), forward: forward}
+}
+
+func (out *outputBuffer) Write(data []byte) (int, error) {
+	out.mutex.Lock()
+	defer out.mutex.Unlock()
+	out.buffer.Write(data)
+	out.pending = append(out.pending, data...)
+	for {
+		index := bytes.IndexByte(out.pending, '\n')
+		if index < 0 {
+			break
+		}
+		line := append(append([]byte{}, out.prefix...), out.pending[:index+1]...)
+		out.forward.Write(line)
+		out.pending = out.pending[index+1:]
+	}
+	return len(data), nil
+}
+
+func (out *outputBuffer) String() string {
+	out.mutex.Lock()
+	defer out.mutex.Unlock()
+	return out.buffer.String()
+}
@@ -0,0 +1,191 @@
+package harness
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+// PacketDir is where packet recordings are written: PACKETS_DIR, or moleculer-packets in the temp dir.
+var PacketDir = packetDir()
+
+func packetDir() string {
+	if dir := os.Getenv("PACKETS_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "moleculer-packets")
+}
+
+// packetTypes maps the commands of the Go transit layer to the protocol packet names.
+var packetTypes = map[string]string{
+	"REQ": "REQUEST",
+	"RES": "RESPONSE",
+}
+
+// Packet is a moleculer protocol packet sent or received by the Go node.
+type Packet struct {
+	Time time.Time `json:"time"`
+	// Direction is "out" for packets sent by the Go node and "in" for packets it received.
+	Direction   string `json:"direction"`
+	Transporter string `json:"transporter"`
+	Type        string `json:"type"`
+	Sender      string `json:"sender"`
+	// Target is the node the packet is sent to, empty for broadcasts.
+	Target  string      `json:"target"`
+	Payload interface{} `json:"payload"`
+}
+
+// PacketRecorder writes the packets of a transport to a JSONL file, one packet per line.
+type PacketRecorder struct {
+	transporter string
+	path        string
+
+	mutex   sync.Mutex
+	file    *os.File
+	writer  *bufio.Writer
+	packets []Packet
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// testPackage is the package of the running test binary, e.g. "scenario" for scenario.test.
+var testPackage = strings.TrimSuffix(filepath.Base(os.Args[0]), ".test")
+
+// PacketFile returns the recording file of a spec, e.g. PacketFile(CurrentGinkgoTestDescription().FullTestText).
+// The files are in a directory per test package, so packages tested in parallel never overwrite each other.
+func PacketFile(name string) string {
+	return filepath.Join(PacketDir, fileName(testPackage), fileName(name)+".jsonl")
+}
+
+func fileName(name string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_")
+}
+
+// NewPacketRecorder creates the file at path and records the packets of the transporter named transporter.
+func NewPacketRecorder(transporter, path string) (*PacketRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &PacketRecorder{transporter: transporter, path: path, file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// Path returns the recording file.
+func (r *PacketRecorder) Path() string {
+	return r.path
+}
+
+// Record adds a packet to the recording. The type, sender and target are completed from the payload when empty.
+func (r *PacketRecorder) Record(direction, command, target string, message moleculer.Payload) {
+	packet := Packet{
+		Time:        time.Now(),
+		Direction:   direction,
+		Transporter: r.transporter,
+		Type:        command,
+		Target:      target,
+	}
+	if name, exists := packetTypes[command]; exists {
+		packet.Type = name
+	}
+	if message != nil {
+		packet.Payload = message.Value()
+		if message.IsMap() && message.Get("sender").Exists() {
+			packet.Sender = message.Get("sender").String()
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.packets = append(r.packets, packet)
+	if r.writer == nil {
+		return
+	}
+	line, err := json.Marshal(packet)
+	if err != nil {
+		line, _ = json.Marshal(Packet{Time: packet.Time, Direction: direction, Transporter: r.transporter, Type: packet.Type, Payload: err.Error()})
+	}
+	r.writer.Write(append(line, '\n'))
+	r.writer.Flush()
+}
+
+// Packets returns the packets recorded so far.
+func (r *PacketRecorder) Packets() []Packet {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Packet{}, r.packets...)
+}
+
+// Close stops writing the recording and deletes the file unless keep is true (e.g. the spec failed).
+func (r *PacketRecorder) Close(keep bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	r.writer.Flush()
+	err := r.file.Close()
+	r.file, r.writer = nil, nil
+	if !keep {
+		return os.Remove(r.path)
+	}
+	return err
+}
+
+// ReadPackets reads a recording file.
+func ReadPackets(path string) ([]Packet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	packets := []Packet{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		packet := Packet{}
+		if err := json.Unmarshal(scanner.Bytes(), &packet); err != nil {
+			return nil, err
+		}
+		packets = append(packets, packet)
+	}
+	return packets, scanner.Err()
+}
+
+// Wrap returns a transport that records every packet published and received through transport.
+// Use it in a moleculer.Config TransporterFactory.
+//
+// The recording is the traffic between the transporter and the Go transit layer. For NATS and Redis
+// this is what goes over the wire. The TCP transporter handles UDP discovery and the GOSSIP_HELLO,
+// GOSSIP_REQ and GOSSIP_RES packets internally: they are recorded as the DISCOVER, INFO and HEARTBEAT
+// packets they carry to and from the transit layer.
+func (r *PacketRecorder) Wrap(transport transit.Transport) transit.Transport {
+	return &recordingTransport{Transport: transport, recorder: r}
+}
+
+type recordingTransport struct {
+	transit.Transport
+	recorder *PacketRecorder
+}
+
+func (t *recordingTransport) Subscribe(command, nodeID string, handler transit.TransportHandler) {
+	t.Transport.Subscribe(command, nodeID, func(message moleculer.Payload) {
+		t.recorder.Record("in", command, nodeID, message)
+		handler(message)
+	})
+}
+
+func (t *recordingTransport) Publish(command, nodeID string, message moleculer.Payload) {
+	t.recorder.Record("out", command, nodeID, message)
+	t.Transport.Publish(command, nodeID, message)
+}
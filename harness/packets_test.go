@@ -0,0 +1,100 @@
+package harness
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Packet recorder", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "packets")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should name one file per spec", func() {
+		Expect(filepath.Base(PacketFile("NATS scenarios catalog discovery"))).Should(Equal("NATS_scenarios_catalog_discovery.jsonl"))
+		Expect(filepath.Base(PacketFile("TCP Moleculer JS ↔ Go scenarios files profile.mistake"))).Should(Equal("TCP_Moleculer_JS_Go_scenarios_files_profile.mistake.jsonl"))
+	})
+
+	It("should keep the files of each test package apart", func() {
+		Expect(filepath.Dir(PacketFile("NATS scenarios catalog discovery"))).Should(Equal(filepath.Join(PacketDir, "harness")))
+	})
+
+	It("should record the packets sent and received by a Go broker", func() {
+		recorder, err := NewPacketRecorder("Memory", filepath.Join(dir, "spec.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		mem := &memory.SharedMemory{}
+		local := broker.New(&moleculer.Config{
+			LogLevel: "ERROR",
+			DiscoverNodeID: func() string {
+				return "local-node"
+			},
+			TransporterFactory: func() interface{} {
+				transport := memory.Create(log.WithField("transport", "memory"), mem)
+				return recorder.Wrap(&transport)
+			},
+		})
+		remote := memoryBroker("remote-node", mem)
+		remote.Publish(mathService)
+		local.Start()
+		remote.Start()
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "math")).Should(Succeed())
+		Expect((<-local.Call("math.add", map[string]interface{}{"a": 1, "b": 2})).Int()).Should(Equal(3))
+		local.Stop()
+		remote.Stop()
+		Expect(recorder.Close(true)).Should(Succeed())
+
+		packets, err := ReadPackets(recorder.Path())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(packets).Should(HaveLen(len(recorder.Packets())))
+
+		types := map[string]bool{}
+		for _, packet := range packets {
+			types[packet.Direction+" "+packet.Type] = true
+			Expect(packet.Transporter).Should(Equal("Memory"))
+			Expect(packet.Time).ShouldNot(BeZero())
+			if packet.Direction == "out" {
+				Expect(packet.Sender).Should(Equal("local-node"))
+			}
+		}
+		Expect(types).Should(HaveKey("out DISCOVER"))
+		Expect(types).Should(HaveKey("in INFO"))
+		Expect(types).Should(HaveKey("out REQUEST"))
+		Expect(types).Should(HaveKey("in RESPONSE"))
+		Expect(types).Should(HaveKey("out DISCONNECT"))
+
+		for _, packet := range packets {
+			if packet.Type == "REQUEST" {
+				Expect(packet.Target).Should(Equal("remote-node"))
+				Expect(packet.Payload).Should(HaveKeyWithValue("action", "math.add"))
+			}
+		}
+	})
+
+	It("should delete the file unless it is kept", func() {
+		recorder, err := NewPacketRecorder("Memory", filepath.Join(dir, "passed", "spec.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(recorder.Path()).Should(BeAnExistingFile())
+		Expect(recorder.Close(false)).Should(Succeed())
+		Expect(recorder.Path()).ShouldNot(BeAnExistingFile())
+		Expect(recorder.Close(false)).Should(Succeed())
+	})
+})
@@ -0,0 +1,59 @@
+package harness
+
+import (
+	"sync"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+// Plug connects a Go broker to its transporter until it is pulled. A broker with a pulled plug
+// neither sends nor receives packets, not even the DISCONNECT of bkr.Stop(): to the other nodes it
+// is a process that crashed, like a killed moleculer JS node.
+type Plug struct {
+	mutex  sync.Mutex
+	pulled bool
+}
+
+// NewPlug creates a plug that is plugged in.
+func NewPlug() *Plug {
+	return &Plug{}
+}
+
+// Wrap returns transport connected through the plug.
+func (p *Plug) Wrap(transport transit.Transport) transit.Transport {
+	return &pluggedTransport{Transport: transport, plug: p}
+}
+
+// Pull disconnects the broker from the others, for good.
+func (p *Plug) Pull() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.pulled = true
+}
+
+// Pulled returns true once the plug was pulled.
+func (p *Plug) Pulled() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.pulled
+}
+
+type pluggedTransport struct {
+	transit.Transport
+	plug *Plug
+}
+
+func (t *pluggedTransport) Subscribe(command, nodeID string, handler transit.TransportHandler) {
+	t.Transport.Subscribe(command, nodeID, func(message moleculer.Payload) {
+		if !t.plug.Pulled() {
+			handler(message)
+		}
+	})
+}
+
+func (t *pluggedTransport) Publish(command, nodeID string, message moleculer.Payload) {
+	if !t.plug.Pulled() {
+		t.Transport.Publish(command, nodeID, message)
+	}
+}
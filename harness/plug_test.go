@@ -0,0 +1,72 @@
+package harness
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Plug", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "plug")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should cut a Go broker off like a crash, without DISCONNECT", func() {
+		recorder, err := NewPacketRecorder("Memory", filepath.Join(dir, "spec.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer recorder.Close(false)
+
+		mem := &memory.SharedMemory{}
+		plug := NewPlug()
+		crashing := broker.New(&moleculer.Config{
+			LogLevel:           "ERROR",
+			HeartbeatFrequency: 500 * time.Millisecond,
+			DiscoverNodeID: func() string {
+				return "crashing-node"
+			},
+			TransporterFactory: func() interface{} {
+				transport := memory.Create(log.WithField("transport", "memory"), mem)
+				return plug.Wrap(recorder.Wrap(&transport))
+			},
+		})
+		crashing.Publish(mathService)
+		survivor := broker.New(&moleculer.Config{
+			LogLevel:         "ERROR",
+			HeartbeatTimeout: time.Second,
+			DiscoverNodeID: func() string {
+				return "surviving-node"
+			},
+			TransporterFactory: func() interface{} {
+				transport := memory.Create(log.WithField("transport", "memory"), mem)
+				return &transport
+			},
+		})
+		crashing.Start()
+		survivor.Start()
+		defer survivor.Stop()
+		Expect(WaitForServices(survivor, time.Now().Add(5*time.Second), "math")).Should(Succeed())
+
+		plug.Pull()
+		sent := len(recorder.Packets())
+		crashing.Stop()
+		Expect(recorder.Packets()).Should(HaveLen(sent))
+		Expect(WaitForServicesGone(survivor, time.Now().Add(10*time.Second), "math")).Should(Succeed())
+	})
+})
@@ -0,0 +1,210 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// InstallTimeout is how long Install waits for npm before giving up.
+var InstallTimeout = 2 * time.Minute
+
+// StopTimeout is how long Stop waits for a peer to exit after SIGTERM before it kills the process group.
+var StopTimeout = 5 * time.Second
+
+// Config describes an external peer process.
+type Config struct {
+	// Name is used to prefix the peer output. Defaults to the command name.
+	Name string
+	// Dir is the working directory of the process.
+	Dir string
+	// Command is the executable to run, e.g. "node".
+	Command string
+	// Args are passed to the command.
+	Args []string
+	// Env is added to the current process environment.
+	Env map[string]string
+	// Output receives a copy of stdout and stderr, prefixed with the peer name.
+	// Defaults to os.Stdout.
+	Output io.Writer
+}
+
+// Peer is a handle on a running peer process.
+type Peer struct {
+	Name string
+
+	cmd    *exec.Cmd
+	stdout *outputBuffer
+	stderr *outputBuffer
+	exited chan struct{}
+	err    error
+}
+
+var installed = struct {
+	sync.Mutex
+	dirs map[string]error
+}{dirs: make(map[string]error)}
+
+// Install installs the npm dependencies of dir. It uses npm ci when a
+// package-lock.json is present and npm install otherwise.
+// Each directory is installed only once per test binary.
+func Install(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	installed.Lock()
+	defer installed.Unlock()
+	if err, done := installed.dirs[abs]; done {
+		return err
+	}
+
+	npmCmd := "install"
+	if _, err := os.Stat(filepath.Join(abs, "package-lock.json")); err == nil {
+		npmCmd = "ci"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), InstallTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "npm", npmCmd)
+	cmd.Dir = abs
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		err = fmt.Errorf("npm %s failed in %s - error: %s", npmCmd, abs, err)
+	}
+	installed.dirs[abs] = err
+	return err
+}
+
+// Start starts the process described by config in its own process group.
+func Start(config Config) (*Peer, error) {
+	if config.Command == "" {
+		return nil, errors.New("harness: Config.Command is required")
+	}
+	name := config.Name
+	if name == "" {
+		name = filepath.Base(config.Command)
+	}
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	cmd := exec.Command(config.Command, config.Args...)
+	cmd.Dir = config.Dir
+	cmd.Env = os.Environ()
+	for key, value := range config.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	setProcessGroup(cmd)
+
+	peer := &Peer{
+		Name:   name,
+		cmd:    cmd,
+		stdout: newOutputBuffer(name, output),
+		stderr: newOutputBuffer(name, output),
+		exited: make(chan struct{}),
+	}
+	cmd.Stdout = peer.stdout
+	cmd.Stderr = peer.stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting %s - error: %s", name, err)
+	}
+	go func() {
+		peer.err = cmd.Wait()
+		close(peer.exited)
+	}()
+	return peer, nil
+}
+
+// StartNode installs the npm dependencies of dir and starts script with node.
+// NODE_ID in env, when present, is used as the peer name.
+func StartNode(dir, script string, env map[string]string, args ...string) (*Peer, error) {
+	if err := Install(dir); err != nil {
+		return nil, err
+	}
+	name := env["NODE_ID"]
+	if name == "" {
+		name = script
+	}
+	return Start(Config{
+		Name:    name,
+		Dir:     dir,
+		Command: "node",
+		Args:    append([]string{script}, args...),
+		Env:     env,
+	})
+}
+
+// Pid returns the process id of the peer.
+func (peer *Peer) Pid() int {
+	return peer.cmd.Process.Pid
+}
+
+// Exited is closed when the peer process ends.
+func (peer *Peer) Exited() <-chan struct{} {
+	return peer.exited
+}
+
+// Running returns true while the peer process has not ended.
+func (peer *Peer) Running() bool {
+	select {
+	case <-peer.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// Wait blocks until the peer ends and returns its exit error.
+func (peer *Peer) Wait() error {
+	<-peer.exited
+	return peer.err
+}
+
+// Stdout returns everything the peer wrote to stdout so far.
+func (peer *Peer) Stdout() string {
+	return peer.stdout.String()
+}
+
+// Stderr returns everything the peer wrote to stderr so far.
+func (peer *Peer) Stderr() string {
+	return peer.stderr.String()
+}
+
+// Stop asks the peer process group to terminate (SIGTERM) and kills it
+// if it is still running after StopTimeout.
+func (peer *Peer) Stop() error {
+	if !peer.Running() {
+		return nil
+	}
+	if err := signalGroup(peer.cmd, terminateSignal); err != nil {
+		return peer.Kill()
+	}
+	select {
+	case <-peer.exited:
+		return nil
+	case <-time.After(StopTimeout):
+		return peer.Kill()
+	}
+}
+
+// Kill kills the peer process group (SIGKILL) and waits for it to end.
+func (peer *Peer) Kill() error {
+	if !peer.Running() {
+		return nil
+	}
+	if err := signalGroup(peer.cmd, killSignal); err != nil {
+		return err
+	}
+	<-peer.exited
+	return nil
+}
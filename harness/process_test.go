@@ -0,0 +1,60 @@
+package harness
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Peer process", func() {
+
+	It("should capture stdout and stderr per process", func() {
+		output := &bytes.Buffer{}
+		peer, err := Start(Config{
+			Name:    "echo-peer",
+			Command: "sh",
+			Args:    []string{"-c", "echo out-$PEER_VALUE; echo err-$PEER_VALUE 1>&2"},
+			Env:     map[string]string{"PEER_VALUE": "42"},
+			Output:  output,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(peer.Wait()).Should(Succeed())
+		Expect(peer.Running()).Should(BeFalse())
+		Expect(peer.Stdout()).Should(Equal("out-42\n"))
+		Expect(peer.Stderr()).Should(Equal("err-42\n"))
+		Expect(output.String()).Should(ContainSubstring("[echo-peer] out-42\n"))
+	})
+
+	It("should kill the whole process group", func() {
+		peer, err := Start(Config{
+			Command: "sh",
+			Args:    []string{"-c", "sleep 30 & sleep 30; wait"},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(peer.Running()).Should(BeTrue())
+
+		Expect(peer.Kill()).Should(Succeed())
+		Eventually(peer.Exited(), time.Second).Should(BeClosed())
+	})
+
+	It("should stop a process that handles SIGTERM", func() {
+		peer, err := Start(Config{
+			Command: "sh",
+			Args:    []string{"-c", "trap 'echo bye; exit 0' TERM; while true; do sleep 0.1; done"},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		time.Sleep(200 * time.Millisecond)
+
+		Expect(peer.Stop()).Should(Succeed())
+		Expect(peer.Running()).Should(BeFalse())
+		Expect(peer.Stdout()).Should(ContainSubstring("bye"))
+	})
+
+	It("should fail to start an unknown command", func() {
+		peer, err := Start(Config{Command: "this-command-does-not-exist"})
+		Expect(err).Should(HaveOccurred())
+		Expect(peer).Should(BeNil())
+	})
+})
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package harness
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const terminateSignal = syscall.SIGTERM
+const killSignal = syscall.SIGKILL
+
+// setProcessGroup makes the command the leader of a new process group,
+// so npm/node children are signalled together with it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func signalGroup(cmd *exec.Cmd, signal syscall.Signal) error {
+	return syscall.Kill(-cmd.Process.Pid, signal)
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package harness
+
+import (
+	"os"
+	"os/exec"
+)
+
+// process groups are not available on windows, signals go to the process only.
+var terminateSignal = os.Kill
+var killSignal = os.Kill
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+func signalGroup(cmd *exec.Cmd, signal os.Signal) error {
+	return cmd.Process.Signal(signal)
+}
@@ -0,0 +1,295 @@
+package harness
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+// ServicesChanged is the moleculer JS event raised when the registry services change.
+// moleculer-go has no such event, so WaitForEvent treats it as an alias that is satisfied by
+// $registry.service.added or $registry.service.removed on the Go bus.
+const ServicesChanged = "$services.changed"
+
+// PollInterval is how often the readiness checks re-inspect the registry
+// when no registry event arrives (e.g. a node becoming unavailable).
+var PollInterval = 250 * time.Millisecond
+
+// registryEvents are the local bus events that may change the readiness state.
+var registryEvents = []string{
+	"$node.connected",
+	"$node.updated",
+	"$node.reconnected",
+	"$node.disconnected",
+	"$registry.service.added",
+	"$registry.service.removed",
+}
+
+// watcher fans out the registry events of one broker to all current waiters.
+type watcher struct {
+	mutex       sync.Mutex
+	subscribers map[chan string]bool
+}
+
+var watchers = struct {
+	sync.Mutex
+	byBroker map[*broker.ServiceBroker]*watcher
+}{byBroker: make(map[*broker.ServiceBroker]*watcher)}
+
+// watch returns the watcher of bkr. Listeners are added to the broker bus only once,
+// since the bus can't reliably remove closures.
+func watch(bkr *broker.ServiceBroker) *watcher {
+	watchers.Lock()
+	defer watchers.Unlock()
+	w, exists := watchers.byBroker[bkr]
+	if exists {
+		return w
+	}
+	w = &watcher{subscribers: make(map[chan string]bool)}
+	for _, event := range registryEvents {
+		name := event
+		bkr.LocalBus().On(name, func(...interface{}) {
+			w.notify(name)
+		})
+	}
+	watchers.byBroker[bkr] = w
+	return w
+}
+
+func (w *watcher) subscribe() chan string {
+	ch := make(chan string, 32)
+	w.mutex.Lock()
+	w.subscribers[ch] = true
+	w.mutex.Unlock()
+	return ch
+}
+
+func (w *watcher) unsubscribe(ch chan string) {
+	w.mutex.Lock()
+	delete(w.subscribers, ch)
+	w.mutex.Unlock()
+}
+
+func (w *watcher) notify(event string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for ch := range w.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// matchesEvent checks if a local bus event satisfies the awaited event name.
+func matchesEvent(awaited, event string) bool {
+	if awaited == ServicesChanged {
+		return event == "$registry.service.added" || event == "$registry.service.removed"
+	}
+	return awaited == event
+}
+
+// until re-evaluates check every time the registry changes, until it passes or the deadline is reached.
+// check returns whether the condition holds and a description of the current state used in the timeout error.
+func until(bkr *broker.ServiceBroker, deadline time.Time, description string, check func() (bool, string)) error {
+	w := watch(bkr)
+	events := w.subscribe()
+	defer w.unsubscribe(events)
+
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
+	for {
+		ok, state := check()
+		if ok {
+			return nil
+		}
+		select {
+		case <-events:
+		case <-time.After(PollInterval):
+		case <-timeout.C:
+			return fmt.Errorf("timeout waiting for %s - current state: %s", description, state)
+		}
+	}
+}
+
+// callWithDeadline calls an action and gives up when the deadline is reached.
+func callWithDeadline(bkr *broker.ServiceBroker, deadline time.Time, action string, params interface{}) (moleculer.Payload, error) {
+	select {
+	case result := <-bkr.Call(action, params):
+		if result.IsError() {
+			return nil, result.Error()
+		}
+		return result, nil
+	case <-time.After(time.Until(deadline)):
+		return nil, fmt.Errorf("timeout calling %s", action)
+	}
+}
+
+// availableNames calls a $node.services like action and returns the names of the available services.
+func availableNames(bkr *broker.ServiceBroker, deadline time.Time, action string) ([]string, error) {
+	services, err := callWithDeadline(bkr, deadline, action, map[string]interface{}{
+		"onlyAvailable": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, item := range services.Array() {
+		if item.Get("available").Exists() && !item.Get("available").Bool() {
+			continue
+		}
+		names = append(names, item.Get("name").String())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func waitForNames(bkr *broker.ServiceBroker, deadline time.Time, action string, present bool, names []string) error {
+	verb := "services"
+	if !present {
+		verb = "removal of services"
+	}
+	description := fmt.Sprint(verb, " ", names, " in ", action)
+	return until(bkr, deadline, description, func() (bool, string) {
+		available, err := availableNames(bkr, deadline, action)
+		if err != nil {
+			return false, err.Error()
+		}
+		for _, name := range names {
+			if contains(available, name) != present {
+				return false, strings.Join(available, ", ")
+			}
+		}
+		return true, ""
+	})
+}
+
+// WaitForServices blocks until all services are available in $node.services or the deadline is reached.
+func WaitForServices(bkr *broker.ServiceBroker, deadline time.Time, services ...string) error {
+	return waitForNames(bkr, deadline, "$node.services", true, services)
+}
+
+// WaitForServicesGone blocks until none of the services is available in $node.services or the deadline is reached.
+func WaitForServicesGone(bkr *broker.ServiceBroker, deadline time.Time, services ...string) error {
+	return waitForNames(bkr, deadline, "$node.services", false, services)
+}
+
+// WaitForServicesIn is like WaitForServices but asks a remote action that returns the
+// $node.services list of another node (e.g. profile.listServices on the JS side).
+// Use it to wait until the other side has discovered services published by Go.
+func WaitForServicesIn(bkr *broker.ServiceBroker, deadline time.Time, action string, services ...string) error {
+	return waitForNames(bkr, deadline, action, true, services)
+}
+
+// WaitForNodes blocks until all nodes are available in $node.list or the deadline is reached.
+func WaitForNodes(bkr *broker.ServiceBroker, deadline time.Time, nodeIDs ...string) error {
+	description := fmt.Sprint("nodes ", nodeIDs, " in $node.list")
+	return until(bkr, deadline, description, func() (bool, string) {
+		nodes, err := callWithDeadline(bkr, deadline, "$node.list", map[string]interface{}{
+			"onlyAvailable": true,
+		})
+		if err != nil {
+			return false, err.Error()
+		}
+		available := []string{}
+		for _, node := range nodes.Array() {
+			available = append(available, node.Get("id").String())
+		}
+		sort.Strings(available)
+		for _, nodeID := range nodeIDs {
+			if !contains(available, nodeID) {
+				return false, strings.Join(available, ", ")
+			}
+		}
+		return true, ""
+	})
+}
+
+// availableEndpoints calls a $node.actions like action and returns the nodes on which action is available.
+func availableEndpoints(bkr *broker.ServiceBroker, deadline time.Time, list, action string) ([]string, error) {
+	actions, err := callWithDeadline(bkr, deadline, list, map[string]interface{}{
+		"onlyAvailable": true,
+		"withEndpoints": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodeIDs := []string{}
+	for _, item := range actions.Array() {
+		if item.Get("name").String() != action {
+			continue
+		}
+		for _, endpoint := range item.Get("endpoints").Array() {
+			if endpoint.Get("available").Exists() && !endpoint.Get("available").Bool() {
+				continue
+			}
+			nodeIDs = append(nodeIDs, endpoint.Get("nodeID").String())
+		}
+	}
+	sort.Strings(nodeIDs)
+	return nodeIDs, nil
+}
+
+func waitForEndpoints(bkr *broker.ServiceBroker, deadline time.Time, list, action string, nodeIDs []string) error {
+	description := fmt.Sprint("endpoints ", nodeIDs, " of ", action, " in ", list)
+	return until(bkr, deadline, description, func() (bool, string) {
+		available, err := availableEndpoints(bkr, deadline, list, action)
+		if err != nil {
+			return false, err.Error()
+		}
+		for _, nodeID := range nodeIDs {
+			if !contains(available, nodeID) {
+				return false, strings.Join(available, ", ")
+			}
+		}
+		return true, ""
+	})
+}
+
+// WaitForEndpoints blocks until the action is available on all nodes in $node.actions or the deadline is reached.
+// Use it before balancing calls among the nodes: WaitForServices returns as soon as one node has the service.
+func WaitForEndpoints(bkr *broker.ServiceBroker, deadline time.Time, action string, nodeIDs ...string) error {
+	return waitForEndpoints(bkr, deadline, "$node.actions", action, nodeIDs)
+}
+
+// WaitForEndpointsIn is like WaitForEndpoints but asks a remote action that returns the
+// $node.actions list of another node, called with onlyAvailable and withEndpoints.
+func WaitForEndpointsIn(bkr *broker.ServiceBroker, deadline time.Time, list, action string, nodeIDs ...string) error {
+	return waitForEndpoints(bkr, deadline, list, action, nodeIDs)
+}
+
+// WaitForEvent blocks until the local bus of the broker raises the event or the deadline is reached.
+// Only registry events are observed: $node.connected, $node.updated, $node.reconnected,
+// $node.disconnected, $registry.service.added, $registry.service.removed and ServicesChanged.
+func WaitForEvent(bkr *broker.ServiceBroker, deadline time.Time, event string) error {
+	w := watch(bkr)
+	events := w.subscribe()
+	defer w.unsubscribe(events)
+
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
+	for {
+		select {
+		case received := <-events:
+			if matchesEvent(event, received) {
+				return nil
+			}
+		case <-timeout.C:
+			return fmt.Errorf("timeout waiting for event %s", event)
+		}
+	}
+}
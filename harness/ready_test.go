@@ -0,0 +1,118 @@
+package harness
+
+import (
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// memoryBroker creates a broker that shares an in-process memory transporter with the other brokers created from mem.
+func memoryBroker(nodeID string, mem *memory.SharedMemory) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := memory.Create(log.WithField("transport", "memory"), mem)
+			return &transport
+		},
+	})
+}
+
+var mathService = moleculer.ServiceSchema{
+	Name: "math",
+	Actions: []moleculer.Action{
+		{
+			Name: "add",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return params.Get("a").Int() + params.Get("b").Int()
+			},
+		},
+	},
+}
+
+var _ = Describe("Readiness", func() {
+	var mem *memory.SharedMemory
+	var local, remote *broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		local = memoryBroker("local-node", mem)
+		local.Start()
+	})
+
+	AfterEach(func() {
+		local.Stop()
+		if remote != nil {
+			remote.Stop()
+			remote = nil
+		}
+	})
+
+	It("should return as soon as a remote service is discovered", func() {
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+			remote = memoryBroker("remote-node", mem)
+			remote.Publish(mathService)
+			remote.Start()
+		}()
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "math")).Should(Succeed())
+		Expect(WaitForNodes(local, time.Now().Add(5*time.Second), "remote-node")).Should(Succeed())
+	})
+
+	It("should fail with the current state when the deadline is reached", func() {
+		err := WaitForServices(local, time.Now().Add(500*time.Millisecond), "math")
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring("math"))
+		Expect(err.Error()).Should(ContainSubstring("$node"))
+	})
+
+	It("should wait for $node.connected and $services.changed events", func() {
+		connected := make(chan error, 1)
+		changed := make(chan error, 1)
+		go func() {
+			connected <- WaitForEvent(local, time.Now().Add(5*time.Second), "$node.connected")
+		}()
+		go func() {
+			changed <- WaitForEvent(local, time.Now().Add(5*time.Second), ServicesChanged)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		remote = memoryBroker("remote-node", mem)
+		remote.Publish(mathService)
+		remote.Start()
+
+		Expect(<-connected).Should(Succeed())
+		Expect(<-changed).Should(Succeed())
+	})
+
+	It("should wait for the endpoints of an action on each node", func() {
+		remote = memoryBroker("remote-node", mem)
+		remote.Publish(mathService)
+		remote.Start()
+		other := memoryBroker("other-node", mem)
+		other.Publish(mathService)
+		other.Start()
+		defer other.Stop()
+
+		Expect(WaitForEndpoints(local, time.Now().Add(5*time.Second), "math.add", "remote-node", "other-node")).Should(Succeed())
+		Expect(WaitForEndpoints(local, time.Now().Add(500*time.Millisecond), "math.add", "missing-node")).Should(MatchError(ContainSubstring("other-node, remote-node")))
+	})
+
+	It("should wait for services to be gone", func() {
+		remote = memoryBroker("remote-node", mem)
+		remote.Publish(mathService)
+		remote.Start()
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "math")).Should(Succeed())
+
+		remote.Stop()
+		remote = nil
+		Expect(WaitForServicesGone(local, time.Now().Add(5*time.Second), "math")).Should(Succeed())
+	})
+})
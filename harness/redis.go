@@ -0,0 +1,74 @@
+package harness
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// RedisServer is a Redis protocol server (miniredis) running in the test process.
+// It supports the pub/sub commands used by the Go and JS Redis transporters.
+type RedisServer struct {
+	server *miniredis.Miniredis
+}
+
+// StartRedis starts an in-process Redis server on a random local port.
+func StartRedis() (*RedisServer, error) {
+	s, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+	return &RedisServer{server: s}, nil
+}
+
+// URL returns the redis:// URL of the server.
+func (r *RedisServer) URL() string {
+	return "redis://" + r.server.Addr()
+}
+
+// Host returns the host the server listens on.
+func (r *RedisServer) Host() string {
+	return r.server.Host()
+}
+
+// Port returns the port the server listens on.
+func (r *RedisServer) Port() int {
+	port, _ := strconv.Atoi(r.server.Port())
+	return port
+}
+
+// Shutdown stops the server and closes its connections.
+func (r *RedisServer) Shutdown() {
+	r.server.Close()
+}
+
+var embeddedRedis struct {
+	sync.Mutex
+	server *RedisServer
+}
+
+// EmbeddedRedis returns the shared in-process Redis server of the test binary, starting it on first use.
+// Suites use it when REDIS_HOST is not set; StopEmbeddedRedis shuts it down.
+func EmbeddedRedis() (*RedisServer, error) {
+	embeddedRedis.Lock()
+	defer embeddedRedis.Unlock()
+	if embeddedRedis.server == nil {
+		s, err := StartRedis()
+		if err != nil {
+			return nil, err
+		}
+		embeddedRedis.server = s
+	}
+	return embeddedRedis.server, nil
+}
+
+// StopEmbeddedRedis shuts down the shared server, if it was started.
+func StopEmbeddedRedis() {
+	embeddedRedis.Lock()
+	defer embeddedRedis.Unlock()
+	if embeddedRedis.server != nil {
+		embeddedRedis.server.Shutdown()
+		embeddedRedis.server = nil
+	}
+}
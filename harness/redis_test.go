@@ -0,0 +1,49 @@
+package harness
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/redis"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Embedded Redis", func() {
+	It("should carry the pub/sub traffic of the Go Redis transporter", func() {
+		server, err := StartRedis()
+		Expect(err).ShouldNot(HaveOccurred())
+		defer server.Shutdown()
+		Expect(server.URL()).Should(Equal("redis://" + server.Host() + ":" + fmt.Sprint(server.Port())))
+
+		redisBroker := func() *broker.ServiceBroker {
+			return broker.New(&moleculer.Config{
+				LogLevel: "ERROR",
+				TransporterFactory: func() interface{} {
+					return redis.NewRedisTransporter(&redis.RedisConfig{Host: server.Host(), Port: server.Port()})
+				},
+			})
+		}
+		local, remote := redisBroker(), redisBroker()
+		remote.Publish(mathService)
+		local.Start()
+		remote.Start()
+		defer local.Stop()
+		defer remote.Stop()
+
+		Expect(WaitForServices(local, time.Now().Add(10*time.Second), "math")).Should(Succeed())
+		Expect((<-local.Call("math.add", map[string]interface{}{"a": 1, "b": 2})).Int()).Should(Equal(3))
+	})
+
+	It("should share one embedded server", func() {
+		first, err := EmbeddedRedis()
+		Expect(err).ShouldNot(HaveOccurred())
+		second, err := EmbeddedRedis()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(second).Should(BeIdenticalTo(first))
+		StopEmbeddedRedis()
+	})
+})
@@ -0,0 +1,652 @@
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/payload"
+	"github.com/moleculer-go/moleculer/transit"
+	"github.com/moleculer-go/moleculer/util"
+)
+
+// ReplayHeartbeatInterval is how often a ReplayPeer sends HEARTBEAT packets, the default of moleculer JS.
+var ReplayHeartbeatInterval = 5 * time.Second
+
+// ReplayCallTimeout is how long ReplayPeer.Call waits for a RESPONSE.
+var ReplayCallTimeout = 5 * time.Second
+
+// SubscribeDelay is the pause of a replay peer between two subscriptions, see Start.
+var SubscribeDelay = 20 * time.Millisecond
+
+// ReplayHandler scripts the answer of a ReplayPeer to a REQUEST: the data of the RESPONSE or its error.
+// The request is the whole REQUEST packet, its params are request.Get("params").
+type ReplayHandler func(request moleculer.Payload) (interface{}, error)
+
+// ReplayError is an error a ReplayHandler returns to send the error of the RESPONSE as is,
+// e.g. the name, message, code, type, data and retryable fields of a moleculer JS error.
+// The other errors are sent as a MoleculerError with code 500.
+type ReplayError map[string]interface{}
+
+func (err ReplayError) Error() string {
+	message, _ := err["message"].(string)
+	return message
+}
+
+// ReplayPeer impersonates a moleculer JS node on a transporter, without Node.js.
+// It answers DISCOVER with a recorded INFO, responds to REQUESTs with scripted handlers
+// or recorded responses and emits events, all with the packets moleculer JS sends.
+// Like moleculer JS, it sends DISCOVER to the nodes it gets a HEARTBEAT from before their INFO.
+//
+// The transport must not need a registry to connect: NATS, Redis or memory. The TCP transporter
+// of moleculer-go builds its gossip packets from the registry of a broker.
+type ReplayPeer struct {
+	nodeID    string
+	transport transit.Transport
+	prefix    string
+
+	mutex     sync.Mutex
+	info      map[string]interface{}
+	handlers  map[string]ReplayHandler
+	responses []RecordedResponse
+	events    []map[string]interface{}
+	nodes     map[string]map[string]interface{}
+	pending   map[string]chan moleculer.Payload
+	exited    chan struct{}
+}
+
+// ReplayInfo returns the INFO of a moleculer JS 0.14 node with services, the schemas of its services
+// as in the services field of INFO. Each call gets a new instanceID, like a restarted JS node.
+func ReplayInfo(services ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"services":   append([]interface{}{}, services...),
+		"ipList":     []interface{}{"127.0.0.1"},
+		"hostname":   "js-host",
+		"client":     map[string]interface{}{"type": "nodejs", "version": "0.14.35", "langVersion": "v20.11.0"},
+		"config":     map[string]interface{}{},
+		"instanceID": "replay-" + time.Now().Format(time.RFC3339Nano),
+		"metadata":   map[string]interface{}{},
+		"seq":        1,
+	}
+}
+
+// NewReplayPeer creates a peer with the nodeID that sends info, an INFO packet (e.g. Recording.Info),
+// over transport. Its ver and sender are replaced.
+func NewReplayPeer(nodeID string, transport transit.Transport, info map[string]interface{}) *ReplayPeer {
+	copied, _ := plain(info).(map[string]interface{})
+	if copied == nil {
+		copied = map[string]interface{}{}
+	}
+	return &ReplayPeer{
+		nodeID:    nodeID,
+		transport: transport,
+		prefix:    "MOL",
+		info:      copied,
+		handlers:  map[string]ReplayHandler{},
+		nodes:     map[string]map[string]interface{}{},
+		pending:   map[string]chan moleculer.Payload{},
+		exited:    make(chan struct{}),
+	}
+}
+
+// NodeID returns the nodeID the peer impersonates.
+func (peer *ReplayPeer) NodeID() string {
+	return peer.nodeID
+}
+
+// SetNamespace makes the peer join the nodes of namespace, like the namespace option of a moleculer JS
+// broker: its topics start with MOL-<namespace> instead of MOL. Call it before Start.
+func (peer *ReplayPeer) SetNamespace(namespace string) {
+	peer.prefix = "MOL"
+	if namespace != "" {
+		peer.prefix += "-" + namespace
+	}
+}
+
+// Handle scripts the answer to the REQUESTs for action. Scripted handlers take precedence over recorded responses.
+func (peer *ReplayPeer) Handle(action string, handler ReplayHandler) {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	peer.handlers[action] = handler
+}
+
+// Replay answers the REQUESTs with the responses of recording and keeps its events for ReplayEvents.
+func (peer *ReplayPeer) Replay(recording *Recording) {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	peer.responses = append(peer.responses, recording.Responses...)
+	peer.events = append(peer.events, recording.Events...)
+}
+
+// Start connects the transport, announces the peer with DISCOVER and INFO and starts sending HEARTBEAT.
+func (peer *ReplayPeer) Start() error {
+	peer.transport.SetPrefix(peer.prefix)
+	peer.transport.SetNodeID(peer.nodeID)
+	if err := <-peer.transport.Connect(nil); err != nil {
+		return err
+	}
+	// Some transports (Redis) subscribe asynchronously: the responses are subscribed before the requests
+	// that lead to them, and everything before the INFO that makes the peer known.
+	subscriptions := []struct {
+		command, nodeID string
+		handler         func(message moleculer.Payload)
+	}{
+		{"RES", peer.nodeID, peer.onResponse},
+		{"REQ", peer.nodeID, peer.onRequest},
+		{"PING", peer.nodeID, peer.onPing},
+		{"INFO", peer.nodeID, peer.onInfo},
+		{"DISCOVER", peer.nodeID, peer.onDiscover},
+		{"DISCONNECT", "", peer.onDisconnect},
+		{"HEARTBEAT", "", peer.onHeartbeat},
+		{"INFO", "", peer.onInfo},
+		{"DISCOVER", "", peer.onDiscover},
+	}
+	for _, subscription := range subscriptions {
+		peer.transport.Subscribe(subscription.command, subscription.nodeID, peer.received(subscription.handler))
+		// The Redis transporter of moleculer-go reads the channel of a subscription from a field that the
+		// next Subscribe overwrites, so back to back subscriptions can lose all but the last one.
+		time.Sleep(SubscribeDelay)
+	}
+
+	peer.publish("DISCOVER", "", map[string]interface{}{})
+	peer.publish("INFO", "", peer.nodeInfo())
+	go peer.heartbeat()
+	return nil
+}
+
+// Exited is closed when the peer stopped or was killed.
+func (peer *ReplayPeer) Exited() <-chan struct{} {
+	return peer.exited
+}
+
+// Running returns true until the peer stopped or was killed.
+func (peer *ReplayPeer) Running() bool {
+	select {
+	case <-peer.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// Stop sends DISCONNECT and disconnects, like a moleculer JS broker stopping.
+func (peer *ReplayPeer) Stop() error {
+	if !peer.Running() {
+		return nil
+	}
+	peer.publish("DISCONNECT", "", map[string]interface{}{})
+	return peer.Kill()
+}
+
+// Kill disconnects without DISCONNECT, like a moleculer JS process exiting: the other nodes
+// only notice when the heartbeats stop.
+func (peer *ReplayPeer) Kill() error {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	select {
+	case <-peer.exited:
+		return nil
+	default:
+	}
+	close(peer.exited)
+	return <-peer.transport.Disconnect()
+}
+
+// RemoveService removes a service from the INFO of the peer and sends the new INFO, like broker.destroyService.
+func (peer *ReplayPeer) RemoveService(name string) {
+	peer.mutex.Lock()
+	services, _ := peer.info["services"].([]interface{})
+	kept := []interface{}{}
+	for _, service := range services {
+		if schema, isMap := service.(map[string]interface{}); !isMap || schema["name"] != name {
+			kept = append(kept, service)
+		}
+	}
+	peer.info["services"] = kept
+	peer.increaseSeq()
+	peer.mutex.Unlock()
+	peer.publish("INFO", "", peer.nodeInfo())
+}
+
+// AddService adds a service to the INFO of the peer and sends the new INFO, like broker.createService.
+func (peer *ReplayPeer) AddService(schema map[string]interface{}) {
+	peer.mutex.Lock()
+	services, _ := peer.info["services"].([]interface{})
+	peer.info["services"] = append(services, schema)
+	peer.increaseSeq()
+	peer.mutex.Unlock()
+	peer.publish("INFO", "", peer.nodeInfo())
+}
+
+// increaseSeq increases the seq of the INFO of the peer, which must be locked.
+// The INFO went through plain, so seq is a float64 when set.
+func (peer *ReplayPeer) increaseSeq() {
+	seq, _ := peer.info["seq"].(float64)
+	peer.info["seq"] = seq + 1
+}
+
+// Services returns the services of the cluster as seen by the peer, in the format of $node.services:
+// one entry per service name with the nodes that host it.
+func (peer *ReplayPeer) Services() []map[string]interface{} {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	infos := map[string]map[string]interface{}{peer.nodeID: peer.info}
+	for nodeID, info := range peer.nodes {
+		infos[nodeID] = info
+	}
+	result := []map[string]interface{}{}
+	index := map[string]map[string]interface{}{}
+	for nodeID, info := range infos {
+		for _, schema := range services(info) {
+			name, _ := schema["name"].(string)
+			entry, exists := index[name]
+			if !exists {
+				entry = map[string]interface{}{"name": name, "available": true, "nodes": []string{}}
+				index[name] = entry
+				result = append(result, entry)
+			}
+			entry["nodes"] = append(entry["nodes"].([]string), nodeID)
+		}
+	}
+	return result
+}
+
+// Call sends a REQUEST to a node that has the action and waits for its RESPONSE. A failed RESPONSE
+// is returned as an error with the message of the error.
+func (peer *ReplayPeer) Call(action string, params, meta interface{}) (moleculer.Payload, error) {
+	return peer.request(action, params, meta, 0)
+}
+
+// CallTimeout is Call with the per-call timeout of moleculer JS: the REQUEST carries the timeout in
+// milliseconds and, when no RESPONSE arrives in time, the call fails with the message of a
+// RequestTimeoutError. A RESPONSE that arrives later is discarded.
+func (peer *ReplayPeer) CallTimeout(action string, params, meta interface{}, timeout time.Duration) (moleculer.Payload, error) {
+	return peer.request(action, params, meta, timeout)
+}
+
+func (peer *ReplayPeer) request(action string, params, meta interface{}, timeout time.Duration) (moleculer.Payload, error) {
+	target := peer.nodeWith(func(schema map[string]interface{}) bool {
+		return hasKey(schema["actions"], action)
+	})
+	if target == "" {
+		return nil, fmt.Errorf("no node has the action %s", action)
+	}
+	if meta == nil {
+		meta = map[string]interface{}{}
+	}
+	id := util.RandomString(12)
+	response := make(chan moleculer.Payload, 1)
+	peer.mutex.Lock()
+	peer.pending[id] = response
+	peer.mutex.Unlock()
+	defer func() {
+		peer.mutex.Lock()
+		delete(peer.pending, id)
+		peer.mutex.Unlock()
+	}()
+
+	peer.publish("REQ", target, map[string]interface{}{
+		"id":        id,
+		"action":    action,
+		"params":    params,
+		"meta":      meta,
+		"timeout":   int64(timeout / time.Millisecond),
+		"level":     1,
+		"tracing":   nil,
+		"parentID":  nil,
+		"requestID": id,
+		"caller":    nil,
+		"stream":    false,
+	})
+	wait := ReplayCallTimeout
+	if timeout > 0 {
+		wait = timeout
+	}
+	select {
+	case message := <-response:
+		if !message.Get("success").Bool() {
+			return nil, errors.New(message.Get("error").Get("message").String())
+		}
+		return message.Get("data"), nil
+	case <-time.After(wait):
+		if timeout > 0 {
+			return nil, fmt.Errorf("Request is timed out when call '%s' action on '%s' node.", action, target)
+		}
+		return nil, fmt.Errorf("timeout calling %s on %s", action, target)
+	}
+}
+
+// Emit sends an EVENT to one node per group subscribed to the event, with the patterns of the
+// listeners matched like moleculer JS does, see MatchEvent.
+func (peer *ReplayPeer) Emit(event string, data interface{}) {
+	peer.sendEvent(event, data, false)
+}
+
+// Broadcast sends an EVENT to every node subscribed to the event, see Emit.
+func (peer *ReplayPeer) Broadcast(event string, data interface{}) {
+	peer.sendEvent(event, data, true)
+}
+
+// ReplayEvents emits the recorded events again, in the order they were recorded.
+func (peer *ReplayPeer) ReplayEvents() {
+	peer.mutex.Lock()
+	events := append([]map[string]interface{}{}, peer.events...)
+	peer.mutex.Unlock()
+	for _, event := range events {
+		name, _ := event["event"].(string)
+		broadcast, _ := event["broadcast"].(bool)
+		peer.sendEvent(name, event["data"], broadcast)
+	}
+}
+
+func (peer *ReplayPeer) sendEvent(event string, data interface{}, broadcast bool) {
+	groups := map[string][]string{}
+	for nodeID, info := range peer.knownNodes() {
+		for _, schema := range services(info) {
+			definitions, _ := schema["events"].(map[string]interface{})
+			for pattern, value := range definitions {
+				definition, isMap := value.(map[string]interface{})
+				if !isMap || !MatchEvent(event, pattern) {
+					continue
+				}
+				group, _ := definition["group"].(string)
+				if group == "" {
+					group, _ = schema["name"].(string)
+				}
+				if broadcast || len(groups[group]) == 0 {
+					groups[group] = appendUnique(groups[group], nodeID)
+				}
+			}
+		}
+	}
+	targets := map[string][]string{}
+	for group, nodeIDs := range groups {
+		for _, nodeID := range nodeIDs {
+			targets[nodeID] = append(targets[nodeID], group)
+		}
+	}
+	for nodeID, nodeGroups := range targets {
+		id := util.RandomString(12)
+		peer.publish("EVENT", nodeID, map[string]interface{}{
+			"id":        id,
+			"event":     event,
+			"data":      data,
+			"groups":    nodeGroups,
+			"broadcast": broadcast,
+			"meta":      map[string]interface{}{},
+			"level":     1,
+			"tracing":   nil,
+			"parentID":  nil,
+			"requestID": id,
+			"caller":    nil,
+			"needAck":   nil,
+		})
+	}
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, item := range list {
+		if item == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func (peer *ReplayPeer) knownNodes() map[string]map[string]interface{} {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	nodes := map[string]map[string]interface{}{}
+	for nodeID, info := range peer.nodes {
+		nodes[nodeID] = info
+	}
+	return nodes
+}
+
+func (peer *ReplayPeer) nodeWith(matches func(schema map[string]interface{}) bool) string {
+	for nodeID, info := range peer.knownNodes() {
+		for _, schema := range services(info) {
+			if matches(schema) {
+				return nodeID
+			}
+		}
+	}
+	return ""
+}
+
+func services(info map[string]interface{}) []map[string]interface{} {
+	list, _ := info["services"].([]interface{})
+	result := []map[string]interface{}{}
+	for _, item := range list {
+		if schema, isMap := item.(map[string]interface{}); isMap {
+			result = append(result, schema)
+		}
+	}
+	return result
+}
+
+func hasKey(value interface{}, key string) bool {
+	object, _ := value.(map[string]interface{})
+	_, exists := object[key]
+	return exists
+}
+
+func (peer *ReplayPeer) nodeInfo() map[string]interface{} {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	info := map[string]interface{}{}
+	for key, value := range peer.info {
+		info[key] = value
+	}
+	return info
+}
+
+func (peer *ReplayPeer) heartbeat() {
+	ticker := time.NewTicker(ReplayHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-peer.exited:
+			return
+		case <-ticker.C:
+			peer.publish("HEARTBEAT", "", map[string]interface{}{"cpu": 0})
+		}
+	}
+}
+
+// publish sends a packet with the ver and sender of the peer, unless the peer exited.
+func (peer *ReplayPeer) publish(command, target string, values map[string]interface{}) {
+	if !peer.Running() {
+		return
+	}
+	values["ver"] = "4"
+	values["sender"] = peer.nodeID
+	peer.transport.Publish(command, target, payload.New(plain(values)))
+}
+
+// plain turns values into JSON values, so that all transports serialize them alike.
+func plain(value interface{}) interface{} {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var result interface{}
+	if json.Unmarshal(content, &result) != nil {
+		return value
+	}
+	return result
+}
+
+// received filters the packets sent by the peer itself, or received after it exited.
+func (peer *ReplayPeer) received(handler func(message moleculer.Payload)) transit.TransportHandler {
+	return func(message moleculer.Payload) {
+		if peer.Running() && message.Get("sender").String() != peer.nodeID {
+			handler(message)
+		}
+	}
+}
+
+func (peer *ReplayPeer) onDiscover(message moleculer.Payload) {
+	peer.publish("INFO", message.Get("sender").String(), peer.nodeInfo())
+}
+
+func (peer *ReplayPeer) onInfo(message moleculer.Payload) {
+	info, _ := plain(message.Value()).(map[string]interface{})
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	peer.nodes[message.Get("sender").String()] = info
+}
+
+// onHeartbeat asks an unknown node for its INFO, like moleculer JS does.
+func (peer *ReplayPeer) onHeartbeat(message moleculer.Payload) {
+	sender := message.Get("sender").String()
+	peer.mutex.Lock()
+	_, known := peer.nodes[sender]
+	peer.mutex.Unlock()
+	if !known {
+		peer.publish("DISCOVER", sender, map[string]interface{}{})
+	}
+}
+
+func (peer *ReplayPeer) onDisconnect(message moleculer.Payload) {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	delete(peer.nodes, message.Get("sender").String())
+}
+
+func (peer *ReplayPeer) onResponse(message moleculer.Payload) {
+	peer.mutex.Lock()
+	response, exists := peer.pending[message.Get("id").String()]
+	peer.mutex.Unlock()
+	if exists {
+		response <- message
+	}
+}
+
+func (peer *ReplayPeer) onPing(message moleculer.Payload) {
+	peer.publish("PONG", message.Get("sender").String(), map[string]interface{}{
+		"id":      message.Get("id").String(),
+		"time":    message.Get("time").Value(),
+		"arrived": time.Now().UnixNano() / int64(time.Millisecond),
+	})
+}
+
+func (peer *ReplayPeer) onRequest(message moleculer.Payload) {
+	// The handlers may call back the sender, which answers on the goroutine of the transport.
+	go peer.respond(message)
+}
+
+func (peer *ReplayPeer) respond(message moleculer.Payload) {
+	action := message.Get("action").String()
+	response := map[string]interface{}{
+		"id":   message.Get("id").String(),
+		"meta": message.Get("meta").Value(),
+	}
+	if response["meta"] == nil {
+		response["meta"] = map[string]interface{}{}
+	}
+	data, err := peer.answer(action, message)
+	if replayError, structured := err.(ReplayError); structured {
+		response["success"] = false
+		response["error"] = map[string]interface{}(replayError)
+	} else if err != nil {
+		response["success"] = false
+		response["error"] = map[string]interface{}{
+			"name":    "MoleculerError",
+			"message": err.Error(),
+			"code":    500,
+			"type":    nil,
+			"data":    nil,
+		}
+	} else {
+		if result, isPayload := data.(moleculer.Payload); isPayload {
+			data = result.Value()
+		}
+		response["success"] = true
+		response["data"] = data
+	}
+	peer.publish("RES", message.Get("sender").String(), response)
+}
+
+func (peer *ReplayPeer) answer(action string, message moleculer.Payload) (interface{}, error) {
+	peer.mutex.Lock()
+	handler, scripted := peer.handlers[action]
+	responses := peer.responses
+	peer.mutex.Unlock()
+	if scripted {
+		return handler(message)
+	}
+	params := plain(message.Get("params").Value())
+	for _, recorded := range responses {
+		if recorded.Action == action && reflect.DeepEqual(plain(recorded.Params), params) {
+			if recorded.Error != "" {
+				return nil, errors.New(recorded.Error)
+			}
+			return recorded.Data, nil
+		}
+	}
+	return nil, fmt.Errorf("Service '%s' is not found on '%s' node.", action, peer.nodeID)
+}
+
+// RecordedResponse is the RESPONSE a node sent to a REQUEST for Action with Params.
+type RecordedResponse struct {
+	Action string
+	Params interface{}
+	Data   interface{}
+	// Error is the message of a failed RESPONSE.
+	Error string
+}
+
+// Recording is what a node sent in a packet recording of the Go broker (see PacketRecorder), to be replayed by a ReplayPeer.
+type Recording struct {
+	// Info is the last INFO of the node.
+	Info map[string]interface{}
+	// Responses are the RESPONSEs of the node to the REQUESTs of the Go broker.
+	Responses []RecordedResponse
+	// Events are the EVENT packets the node sent.
+	Events []map[string]interface{}
+}
+
+// LoadRecording reads the packets nodeID sent to the Go broker from a recording file.
+func LoadRecording(path, nodeID string) (*Recording, error) {
+	packets, err := ReadPackets(path)
+	if err != nil {
+		return nil, err
+	}
+	recording := &Recording{}
+	requests := map[string]map[string]interface{}{}
+	for _, packet := range packets {
+		values, _ := plain(packet.Payload).(map[string]interface{})
+		if values == nil {
+			continue
+		}
+		id, _ := values["id"].(string)
+		switch {
+		case packet.Direction == "out" && packet.Type == "REQUEST" && packet.Target == nodeID:
+			requests[id] = values
+		case packet.Direction != "in" || packet.Sender != nodeID:
+		case packet.Type == "INFO":
+			recording.Info = values
+		case packet.Type == "EVENT":
+			recording.Events = append(recording.Events, values)
+		case packet.Type == "RESPONSE" && requests[id] != nil:
+			response := RecordedResponse{Params: requests[id]["params"], Data: values["data"]}
+			response.Action, _ = requests[id]["action"].(string)
+			if success, _ := values["success"].(bool); !success {
+				response.Error = fmt.Sprint(values["error"])
+				if failure, isMap := values["error"].(map[string]interface{}); isMap {
+					response.Error = fmt.Sprint(failure["message"])
+				}
+			}
+			recording.Responses = append(recording.Responses, response)
+		}
+	}
+	if recording.Info == nil {
+		return nil, fmt.Errorf("no INFO from %s in %s", nodeID, path)
+	}
+	return recording, nil
+}
@@ -0,0 +1,244 @@
+package harness
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// jsInfo is the INFO of a moleculer JS node with a greeter and an account service.
+var jsInfo = ReplayInfo(
+	map[string]interface{}{
+		"name":     "greeter",
+		"fullName": "greeter",
+		"settings": map[string]interface{}{},
+		"metadata": map[string]interface{}{},
+		"actions": map[string]interface{}{
+			"greeter.hello": map[string]interface{}{"rawName": "hello", "name": "greeter.hello"},
+			"greeter.add":   map[string]interface{}{"rawName": "add", "name": "greeter.add"},
+		},
+		"events": map[string]interface{}{},
+	},
+	map[string]interface{}{
+		"name":     "account",
+		"fullName": "account",
+		"settings": map[string]interface{}{},
+		"metadata": map[string]interface{}{},
+		"actions": map[string]interface{}{
+			"account.unregister": map[string]interface{}{"rawName": "unregister", "name": "account.unregister"},
+		},
+		"events": map[string]interface{}{},
+	},
+)
+
+var _ = Describe("Replay peer", func() {
+	var mem *memory.SharedMemory
+	var local *broker.ServiceBroker
+	var peer *ReplayPeer
+	var added chan moleculer.Payload
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		added = make(chan moleculer.Payload, 1)
+		local = memoryBroker("local-node", mem)
+		local.Publish(mathService, moleculer.ServiceSchema{
+			Name: "listener",
+			Events: []moleculer.Event{
+				{
+					Name: "math.added",
+					Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+						added <- params
+					},
+				},
+			},
+		})
+		local.Start()
+
+		transport := memory.Create(log.WithField("transport", "memory"), mem)
+		peer = NewReplayPeer("js-node", &transport, jsInfo)
+		peer.Handle("greeter.add", func(request moleculer.Payload) (interface{}, error) {
+			return peer.Call("math.add", request.Get("params").Value(), nil)
+		})
+		Expect(peer.Start()).Should(Succeed())
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "greeter")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		peer.Kill()
+		local.Stop()
+	})
+
+	It("should answer REQUESTs with the scripted handlers, calling back the Go node", func() {
+		Eventually(func() []map[string]interface{} {
+			return peer.Services()
+		}, 5*time.Second).Should(ContainElement(HaveKeyWithValue("name", "math")))
+
+		result := <-local.Call("greeter.add", map[string]interface{}{"a": 1, "b": 2})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Int()).Should(Equal(3))
+	})
+
+	It("should answer REQUESTs with the recorded responses", func() {
+		peer.Replay(&Recording{Responses: []RecordedResponse{
+			{Action: "greeter.hello", Params: map[string]interface{}{"name": "John"}, Data: "Hello John"},
+			{Action: "greeter.hello", Params: map[string]interface{}{"name": "Jane"}, Error: "unknown user"},
+		}})
+
+		Expect((<-local.Call("greeter.hello", map[string]interface{}{"name": "John"})).String()).Should(Equal("Hello John"))
+		Expect((<-local.Call("greeter.hello", map[string]interface{}{"name": "Jane"})).Error()).Should(MatchError("unknown user"))
+		Expect((<-local.Call("greeter.hello", map[string]interface{}{"name": "Bob"})).Error()).Should(HaveOccurred())
+	})
+
+	It("should send the fields of a ReplayError as the error of the RESPONSE", func() {
+		peer.Handle("greeter.hello", func(request moleculer.Payload) (interface{}, error) {
+			return nil, ReplayError{"name": "ValidationError", "message": "Parameters validation error!", "code": 422, "type": "VALIDATION_ERROR"}
+		})
+
+		result := <-local.Call("greeter.hello", map[string]interface{}{})
+		Expect(result.Error()).Should(MatchError("Parameters validation error!"))
+	})
+
+	It("should emit events and replay the recorded ones", func() {
+		Eventually(func() []map[string]interface{} {
+			return peer.Services()
+		}, 5*time.Second).Should(ContainElement(HaveKeyWithValue("name", "listener")))
+
+		peer.Emit("math.added", map[string]interface{}{"sum": 3})
+		Eventually(added, 5*time.Second).Should(Receive(WithTransform(func(params moleculer.Payload) int {
+			return params.Get("sum").Int()
+		}, Equal(3))))
+
+		peer.Replay(&Recording{Events: []map[string]interface{}{
+			{"event": "math.added", "data": map[string]interface{}{"sum": 5}, "broadcast": false},
+		}})
+		peer.ReplayEvents()
+		Eventually(added, 5*time.Second).Should(Receive(WithTransform(func(params moleculer.Payload) int {
+			return params.Get("sum").Int()
+		}, Equal(5))))
+	})
+
+	It("should add a service to the Go registry when a service is created", func() {
+		peer.AddService(map[string]interface{}{
+			"name":     "audit",
+			"fullName": "audit",
+			"settings": map[string]interface{}{},
+			"metadata": map[string]interface{}{},
+			"actions":  map[string]interface{}{"audit.log": map[string]interface{}{"rawName": "log", "name": "audit.log"}},
+			"events":   map[string]interface{}{},
+		})
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "audit")).Should(Succeed())
+	})
+
+	It("should remove its services from the Go registry when a service is destroyed or it stops", func() {
+		peer.RemoveService("account")
+		Expect(WaitForServicesGone(local, time.Now().Add(5*time.Second), "account")).Should(Succeed())
+
+		Expect(peer.Stop()).Should(Succeed())
+		Eventually(peer.Exited()).Should(BeClosed())
+		Expect(WaitForServicesGone(local, time.Now().Add(5*time.Second), "greeter")).Should(Succeed())
+	})
+
+	It("should only meet the nodes of its namespace", func() {
+		spaced := broker.New(&moleculer.Config{
+			LogLevel:  "ERROR",
+			Namespace: "other",
+			DiscoverNodeID: func() string {
+				return "spaced-node"
+			},
+			TransporterFactory: func() interface{} {
+				transport := memory.Create(log.WithField("transport", "memory"), mem)
+				return &transport
+			},
+		})
+		spaced.Start()
+		defer spaced.Stop()
+
+		transport := memory.Create(log.WithField("transport", "memory"), mem)
+		other := NewReplayPeer("other-js-node", &transport, jsInfo)
+		other.SetNamespace("other")
+		Expect(other.Start()).Should(Succeed())
+		defer other.Kill()
+
+		Expect(WaitForNodes(spaced, time.Now().Add(5*time.Second), "other-js-node")).Should(Succeed())
+		Expect(WaitForNodes(local, time.Now().Add(time.Second), "other-js-node")).ShouldNot(Succeed())
+		Expect(WaitForNodes(spaced, time.Now().Add(time.Second), "js-node")).ShouldNot(Succeed())
+	})
+})
+
+var _ = Describe("Recording", func() {
+	It("should load the INFO, the responses and the events of a node from a packet recording", func() {
+		dir, err := ioutil.TempDir("", "recording")
+		Expect(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		recorder, err := NewPacketRecorder("Memory", filepath.Join(dir, "recording.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		mem := &memory.SharedMemory{}
+		local := broker.New(&moleculer.Config{
+			LogLevel: "ERROR",
+			DiscoverNodeID: func() string {
+				return "local-node"
+			},
+			TransporterFactory: func() interface{} {
+				transport := memory.Create(log.WithField("transport", "memory"), mem)
+				return recorder.Wrap(&transport)
+			},
+		})
+		local.Publish(moleculer.ServiceSchema{
+			Name:   "listener",
+			Events: []moleculer.Event{{Name: "math.added", Handler: func(ctx moleculer.Context, params moleculer.Payload) {}}},
+		})
+		local.Start()
+		defer local.Stop()
+
+		transport := memory.Create(log.WithField("transport", "memory"), mem)
+		peer := NewReplayPeer("js-node", &transport, jsInfo)
+		peer.Handle("greeter.hello", func(request moleculer.Payload) (interface{}, error) {
+			if request.Get("params").Get("name").String() == "Jane" {
+				return nil, errors.New("unknown user")
+			}
+			return "Hello " + request.Get("params").Get("name").String(), nil
+		})
+		Expect(peer.Start()).Should(Succeed())
+		defer peer.Kill()
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "greeter")).Should(Succeed())
+		Eventually(peer.Services, 5*time.Second).Should(ContainElement(HaveKeyWithValue("name", "listener")))
+
+		<-local.Call("greeter.hello", map[string]interface{}{"name": "John"})
+		<-local.Call("greeter.hello", map[string]interface{}{"name": "Jane"})
+		peer.Emit("math.added", map[string]interface{}{"sum": 3})
+		Eventually(func() int {
+			count := 0
+			for _, packet := range recorder.Packets() {
+				if packet.Type == "EVENT" {
+					count++
+				}
+			}
+			return count
+		}, 5*time.Second).Should(Equal(1))
+		Expect(recorder.Close(true)).Should(Succeed())
+
+		recording, err := LoadRecording(recorder.Path(), "js-node")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(recording.Info).Should(HaveKeyWithValue("hostname", "js-host"))
+		Expect(recording.Responses).Should(ConsistOf(
+			RecordedResponse{Action: "greeter.hello", Params: map[string]interface{}{"name": "John"}, Data: "Hello John"},
+			RecordedResponse{Action: "greeter.hello", Params: map[string]interface{}{"name": "Jane"}, Error: "unknown user"},
+		))
+		Expect(recording.Events).Should(HaveLen(1))
+		Expect(recording.Events[0]).Should(HaveKeyWithValue("event", "math.added"))
+
+		_, err = LoadRecording(recorder.Path(), "other-node")
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,358 @@
+package harness
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+// SnapshotTimeout is how long TakeSnapshot waits for each $node action.
+var SnapshotTimeout = 5 * time.Second
+
+// Endpoint is a node that provides a service, action or event.
+type Endpoint struct {
+	NodeID    string
+	Available bool
+}
+
+// Service is an item of $node.services.
+type Service struct {
+	Name      string
+	Version   string
+	Available bool
+	HasLocal  bool
+	Actions   []string
+	Events    []string
+	Endpoints []Endpoint
+}
+
+// Action is an item of $node.actions.
+type Action struct {
+	Name      string
+	Count     int
+	Available bool
+	HasLocal  bool
+	Endpoints []Endpoint
+}
+
+// Event is an item of $node.events.
+type Event struct {
+	Name      string
+	Group     string
+	Count     int
+	Available bool
+	HasLocal  bool
+	Endpoints []Endpoint
+}
+
+// Node is an item of $node.list.
+type Node struct {
+	ID         string
+	InstanceID string
+	Available  bool
+	Local      bool
+	Hostname   string
+	Seq        int64
+	ClientType string
+	Services   []string
+}
+
+// Snapshot is a typed view of a broker registry, as returned by the $node actions.
+type Snapshot struct {
+	Services []Service
+	Actions  []Action
+	Events   []Event
+	Nodes    []Node
+}
+
+// TakeSnapshot calls $node.services, $node.actions, $node.events and $node.list on the broker.
+func TakeSnapshot(bkr *broker.ServiceBroker) (Snapshot, error) {
+	deadline := time.Now().Add(SnapshotTimeout)
+	snapshot := Snapshot{}
+	all := map[string]interface{}{"withEndpoints": true, "withActions": true, "withEvents": true, "withServices": true}
+
+	services, err := callWithDeadline(bkr, deadline, "$node.services", all)
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Services, err = ParseServices(services); err != nil {
+		return snapshot, err
+	}
+
+	actions, err := callWithDeadline(bkr, deadline, "$node.actions", all)
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Actions, err = ParseActions(actions); err != nil {
+		return snapshot, err
+	}
+
+	events, err := callWithDeadline(bkr, deadline, "$node.events", all)
+	if err != nil {
+		return snapshot, err
+	}
+	if snapshot.Events, err = ParseEvents(events); err != nil {
+		return snapshot, err
+	}
+
+	nodes, err := callWithDeadline(bkr, deadline, "$node.list", all)
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.Nodes, err = ParseNodes(nodes)
+	return snapshot, err
+}
+
+// listItems checks that a $node result is a list of objects.
+func listItems(action string, list moleculer.Payload) ([]moleculer.Payload, error) {
+	if !list.IsArray() {
+		return nil, fmt.Errorf("%s result is not a list: %v", action, list.Value())
+	}
+	items := list.Array()
+	for index, item := range items {
+		if !item.IsMap() {
+			return nil, fmt.Errorf("%s item %d is not an object: %v", action, index, item.Value())
+		}
+	}
+	return items, nil
+}
+
+func optionalBool(item moleculer.Payload, field string) bool {
+	value := item.Get(field)
+	return value.Exists() && value.Bool()
+}
+
+func optionalString(item moleculer.Payload, field string) string {
+	value := item.Get(field)
+	if !value.Exists() || value.Value() == nil {
+		return ""
+	}
+	return value.String()
+}
+
+func parseEndpoints(item moleculer.Payload) []Endpoint {
+	endpoints := []Endpoint{}
+	list := item.Get("endpoints")
+	if !list.Exists() || !list.IsArray() {
+		return endpoints
+	}
+	for _, endpoint := range list.Array() {
+		endpoints = append(endpoints, Endpoint{
+			NodeID:    optionalString(endpoint, "nodeID"),
+			Available: optionalBool(endpoint, "available"),
+		})
+	}
+	return endpoints
+}
+
+// names returns the names of a field that is either a list of names/objects or an object keyed by name.
+func names(item moleculer.Payload, field string) []string {
+	result := []string{}
+	value := item.Get(field)
+	if !value.Exists() {
+		return result
+	}
+	if value.IsMap() {
+		for name := range value.Map() {
+			result = append(result, name)
+		}
+	} else if value.IsArray() {
+		for _, entry := range value.Array() {
+			if entry.IsMap() {
+				result = append(result, optionalString(entry, "name"))
+			} else {
+				result = append(result, entry.String())
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ParseServices converts a $node.services result (from Go or JS) to a list of services.
+func ParseServices(list moleculer.Payload) ([]Service, error) {
+	items, err := listItems("$node.services", list)
+	if err != nil {
+		return nil, err
+	}
+	services := []Service{}
+	for _, item := range items {
+		services = append(services, Service{
+			Name:      optionalString(item, "name"),
+			Version:   optionalString(item, "version"),
+			Available: optionalBool(item, "available"),
+			HasLocal:  optionalBool(item, "hasLocal"),
+			Actions:   names(item, "actions"),
+			Events:    names(item, "events"),
+			Endpoints: parseEndpoints(item),
+		})
+	}
+	sort.SliceStable(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
+// ParseActions converts a $node.actions result to a list of actions.
+func ParseActions(list moleculer.Payload) ([]Action, error) {
+	items, err := listItems("$node.actions", list)
+	if err != nil {
+		return nil, err
+	}
+	actions := []Action{}
+	for _, item := range items {
+		actions = append(actions, Action{
+			Name:      optionalString(item, "name"),
+			Count:     item.Get("count").Int(),
+			Available: optionalBool(item, "available"),
+			HasLocal:  optionalBool(item, "hasLocal"),
+			Endpoints: parseEndpoints(item),
+		})
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].Name < actions[j].Name })
+	return actions, nil
+}
+
+// ParseEvents converts a $node.events result to a list of events.
+func ParseEvents(list moleculer.Payload) ([]Event, error) {
+	items, err := listItems("$node.events", list)
+	if err != nil {
+		return nil, err
+	}
+	events := []Event{}
+	for _, item := range items {
+		events = append(events, Event{
+			Name:      optionalString(item, "name"),
+			Group:     optionalString(item, "group"),
+			Count:     item.Get("count").Int(),
+			Available: optionalBool(item, "available"),
+			HasLocal:  optionalBool(item, "hasLocal"),
+			Endpoints: parseEndpoints(item),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Name < events[j].Name })
+	return events, nil
+}
+
+// ParseNodes converts a $node.list result to a list of nodes.
+func ParseNodes(list moleculer.Payload) ([]Node, error) {
+	items, err := listItems("$node.list", list)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{}
+	for _, item := range items {
+		nodes = append(nodes, Node{
+			ID:         optionalString(item, "id"),
+			InstanceID: optionalString(item, "instanceID"),
+			Available:  optionalBool(item, "available"),
+			Local:      optionalBool(item, "local"),
+			Hostname:   optionalString(item, "hostname"),
+			Seq:        item.Get("seq").Int64(),
+			ClientType: optionalString(item.Get("client"), "type"),
+			Services:   names(item, "services"),
+		})
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// Service returns the service with the given name, or an empty Service with only the name set.
+func (snapshot Snapshot) Service(name string) Service {
+	for _, service := range snapshot.Services {
+		if service.Name == name {
+			return service
+		}
+	}
+	return Service{Name: name}
+}
+
+// Action returns the action with the given name, or an empty Action with only the name set.
+func (snapshot Snapshot) Action(name string) Action {
+	for _, action := range snapshot.Actions {
+		if action.Name == name {
+			return action
+		}
+	}
+	return Action{Name: name}
+}
+
+// Event returns the event with the given name, or an empty Event with only the name set.
+func (snapshot Snapshot) Event(name string) Event {
+	for _, event := range snapshot.Events {
+		if event.Name == name {
+			return event
+		}
+	}
+	return Event{Name: name}
+}
+
+// Node returns the node with the given id, or an empty Node with only the id set.
+func (snapshot Snapshot) Node(nodeID string) Node {
+	for _, node := range snapshot.Nodes {
+		if node.ID == nodeID {
+			return node
+		}
+	}
+	return Node{ID: nodeID}
+}
+
+// AvailableServices returns the names of the available services, in order and including duplicates.
+func (snapshot Snapshot) AvailableServices() []string {
+	result := []string{}
+	for _, service := range snapshot.Services {
+		if service.Available {
+			result = append(result, service.Name)
+		}
+	}
+	return result
+}
+
+func (endpoint Endpoint) String() string {
+	if endpoint.Available {
+		return endpoint.NodeID
+	}
+	return endpoint.NodeID + "(unavailable)"
+}
+
+func endpointsString(endpoints []Endpoint) string {
+	list := make([]string, len(endpoints))
+	for index, endpoint := range endpoints {
+		list[index] = endpoint.String()
+	}
+	return strings.Join(list, ", ")
+}
+
+func flags(available, local bool) string {
+	result := "unavailable"
+	if available {
+		result = "available"
+	}
+	if local {
+		result = result + ",local"
+	}
+	return result
+}
+
+// String renders the snapshot as a table, one line per registry item.
+func (snapshot Snapshot) String() string {
+	lines := []string{"services:"}
+	for _, service := range snapshot.Services {
+		lines = append(lines, fmt.Sprintf("  %-20s %-22s endpoints: [%s]", service.Name, flags(service.Available, service.HasLocal), endpointsString(service.Endpoints)))
+	}
+	lines = append(lines, "actions:")
+	for _, action := range snapshot.Actions {
+		lines = append(lines, fmt.Sprintf("  %-30s %-22s endpoints: [%s]", action.Name, flags(action.Available, action.HasLocal), endpointsString(action.Endpoints)))
+	}
+	lines = append(lines, "events:")
+	for _, event := range snapshot.Events {
+		lines = append(lines, fmt.Sprintf("  %-30s %-22s group: %s endpoints: [%s]", event.Name, flags(event.Available, event.HasLocal), event.Group, endpointsString(event.Endpoints)))
+	}
+	lines = append(lines, "nodes:")
+	for _, node := range snapshot.Nodes {
+		lines = append(lines, fmt.Sprintf("  %-30s %-22s seq: %d services: [%s]", node.ID, flags(node.Available, node.Local), node.Seq, strings.Join(node.Services, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
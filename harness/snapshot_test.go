@@ -0,0 +1,105 @@
+package harness
+
+import (
+	"time"
+
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/payload"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry snapshot", func() {
+	var local, remote *broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem := &memory.SharedMemory{}
+		local = memoryBroker("local-node", mem)
+		remote = memoryBroker("remote-node", mem)
+		remote.Publish(mathService)
+		local.Start()
+		remote.Start()
+		Expect(WaitForServices(local, time.Now().Add(5*time.Second), "math")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		local.Stop()
+		remote.Stop()
+	})
+
+	It("should read services, actions and nodes from the registry", func() {
+		snapshot, err := TakeSnapshot(local)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(snapshot).Should(HaveExactlyServices("$node", "math"))
+		Expect(snapshot.Service("math")).Should(HaveEndpointOn("remote-node"))
+		Expect(snapshot.Service("math")).ShouldNot(HaveEndpointOn("local-node"))
+		Expect(snapshot.Service("$node")).Should(HaveEndpointOn("local-node"))
+		Expect(snapshot.Action("math.add")).Should(HaveEndpointOn("remote-node"))
+		Expect(snapshot.Node("remote-node").Available).Should(BeTrue())
+		Expect(snapshot.Node("unknown-node").Available).Should(BeFalse())
+	})
+
+	It("should converge once a service is removed", func() {
+		remote.Stop()
+		EventuallyConverge(local).Should(HaveExactlyServices("$node"))
+	})
+
+	It("should report malformed results instead of panicking", func() {
+		_, err := ParseServices(payload.New("not a list"))
+		Expect(err).Should(HaveOccurred())
+
+		_, err = ParseServices(payload.New([]interface{}{"not an object"}))
+		Expect(err).Should(HaveOccurred())
+
+		services, err := ParseServices(payload.New([]interface{}{
+			map[string]interface{}{"name": "profile", "available": true, "endpoints": "unexpected"},
+		}))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(services).Should(HaveLen(1))
+		Expect(services[0].Endpoints).Should(BeEmpty())
+	})
+})
+
+var _ = Describe("Registry matchers", func() {
+	services := []Service{
+		{Name: "$node", Available: true, Endpoints: []Endpoint{{"go-node", true}, {"js-node", true}}},
+		{Name: "profile", Available: true, Endpoints: []Endpoint{{"js-node", true}}},
+		{Name: "profile", Available: true, Endpoints: []Endpoint{{"js-node-2", true}}},
+		{Name: "account", Available: false, Endpoints: []Endpoint{{"js-node", false}}},
+	}
+
+	It("should fail on missing, extra and duplicated services with a diff", func() {
+		matcher := HaveExactlyServices("$node", "profile", "user")
+		ok, err := matcher.Match(services)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(ok).Should(BeFalse())
+
+		message := matcher.FailureMessage(services)
+		Expect(message).Should(ContainSubstring("- user  (missing)"))
+		Expect(message).Should(ContainSubstring("! profile (x2)  (duplicated)"))
+		Expect(message).ShouldNot(ContainSubstring("+ account"))
+		Expect(message).Should(ContainSubstring("endpoints: [js-node(unavailable)]"))
+	})
+
+	It("should report unexpected services", func() {
+		matcher := HaveExactlyServices("$node")
+		ok, _ := matcher.Match(Snapshot{Services: services[:2]})
+		Expect(ok).Should(BeFalse())
+		Expect(matcher.FailureMessage(nil)).Should(ContainSubstring("+ profile  (unexpected)"))
+	})
+
+	It("should not match unavailable endpoints", func() {
+		Expect(services[3]).ShouldNot(HaveEndpointOn("js-node"))
+		Expect(services[0]).Should(HaveEndpointOn("js-node"))
+	})
+
+	It("should reject values that are not registry items", func() {
+		_, err := HaveEndpointOn("js-node").Match("profile")
+		Expect(err).Should(HaveOccurred())
+		_, err = HaveExactlyServices("profile").Match(42)
+		Expect(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,63 @@
+package harness
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var wildcardPatterns = struct {
+	sync.Mutex
+	byPattern map[string]*regexp.Regexp
+}{byPattern: map[string]*regexp.Regexp{}}
+
+// MatchEvent tells whether the event name matches the pattern of a listener the way moleculer JS
+// does (utils.match): "*" matches any characters but a dot, "**" any characters, "?" one character,
+// and a pattern without wildcards only the same name. moleculer-go has no wildcards: its listeners
+// get the events with the same name as their pattern only.
+func MatchEvent(name, pattern string) bool {
+	if !strings.Contains(pattern, "?") {
+		star := strings.Index(pattern, "*")
+		length := len(pattern)
+		switch {
+		case star == -1:
+			return pattern == name
+		case length > 2 && strings.HasSuffix(pattern, "**") && star > length-3:
+			// e.g. "prefix**"
+			return strings.HasPrefix(name, pattern[:length-2])
+		case length > 1 && strings.HasSuffix(pattern, "*") && star > length-2:
+			// e.g. "prefix*": no dot after the prefix. Like moleculer JS, the dot is looked for
+			// from the length of the whole pattern.
+			if !strings.HasPrefix(name, pattern[:length-1]) {
+				return false
+			}
+			return length > len(name) || !strings.Contains(name[length:], ".")
+		case pattern == "*":
+			return !strings.Contains(name, ".")
+		case pattern == "**":
+			return true
+		}
+	}
+	return wildcardRegexp(pattern).MatchString(name)
+}
+
+// wildcardRegexp converts a pattern to a regular expression, once.
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	wildcardPatterns.Lock()
+	defer wildcardPatterns.Unlock()
+	if expression, exists := wildcardPatterns.byPattern[pattern]; exists {
+		return expression
+	}
+	// Like moleculer JS, only a leading "$" is escaped: a dot matches any character.
+	expression := pattern
+	if strings.HasPrefix(expression, "$") {
+		expression = `\` + expression
+	}
+	expression = strings.Replace(expression, "?", ".", -1)
+	expression = strings.Replace(expression, "**", "§§§", -1)
+	expression = strings.Replace(expression, "*", `[^\.]*`, -1)
+	expression = strings.Replace(expression, "§§§", ".*", -1)
+	compiled := regexp.MustCompile("^" + expression + "$")
+	wildcardPatterns.byPattern[pattern] = compiled
+	return compiled
+}
@@ -0,0 +1,45 @@
+package harness
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MatchEvent", func() {
+	// The names each pattern matches among the names, as utils.match of moleculer JS 0.14 tells.
+	names := []string{"user.created", "user.updated", "user.profile.updated", "ping", "account.created", "$user.created"}
+	matches := map[string][]string{
+		"user.created": {"user.created"},
+		"user.*":       {"user.created", "user.updated"},
+		"user.**":      {"user.created", "user.updated", "user.profile.updated"},
+		"user.*ed":     {"user.created", "user.updated"},
+		"*.created":    {"user.created", "account.created", "$user.created"},
+		"*":            {"ping"},
+		"**":           names,
+		"$user.*":      {"$user.created"},
+		"user.?pdated": {"user.updated"},
+	}
+
+	for pattern, expected := range matches {
+		pattern, expected := pattern, expected
+
+		It("should match "+pattern+" like moleculer JS", func() {
+			matched := []string{}
+			for _, name := range names {
+				if MatchEvent(name, pattern) {
+					matched = append(matched, name)
+				}
+			}
+			Expect(matched).Should(Equal(expected))
+		})
+	}
+
+	It("should keep the quirks of moleculer JS", func() {
+		// The dots of a pattern with a wildcard in the middle match any character.
+		Expect(MatchEvent("userXcreated", "user.*ed")).Should(BeTrue())
+		Expect(MatchEvent("user.", "user.*")).Should(BeTrue())
+		Expect(MatchEvent("user", "user.**")).Should(BeFalse())
+		Expect(MatchEvent("user.created.x", "user.*")).Should(BeFalse())
+		Expect(MatchEvent("xuser.created", "$user.*")).Should(BeFalse())
+	})
+})
@@ -0,0 +1,127 @@
+// Package internalevents checks the internal events of the registry that a node gets about another
+// node, the subject: $node.connected, $node.updated, $node.disconnected and $services.changed. A Go
+// observer and a JS observer record them while a Go subject and a JS subject start, publish a
+// service, stop, restart and crash.
+package internalevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// The internal events of the registry of moleculer JS 0.14.
+const (
+	NodeConnected    = "$node.connected"
+	NodeUpdated      = "$node.updated"
+	NodeDisconnected = "$node.disconnected"
+	ServicesChanged  = "$services.changed"
+)
+
+// Events are the internal events the observers listen to.
+var Events = []string{NodeConnected, NodeUpdated, NodeDisconnected, ServicesChanged}
+
+// Step is what happened to a subject.
+type Step string
+
+const (
+	// Started is the subject started, for the first time under its nodeID.
+	Started Step = "start"
+	// Published is a service added to the running subject.
+	Published Step = "publish"
+	// Stopped is the subject stopped: it sent DISCONNECT.
+	Stopped Step = "stop"
+	// Restarted is the subject started again under the same nodeID, after Stopped.
+	Restarted Step = "restart"
+	// Killed is the subject crashed: the observers notice when its heartbeats stop.
+	Killed Step = "kill"
+)
+
+// Lives are the steps of each spec, in order.
+var Lives = map[string][]Step{
+	"graceful": {Started, Published, Stopped, Restarted, Stopped},
+	"crash":    {Started, Killed},
+}
+
+// Observation is an internal event recorded by an observer. The payload of the $node events of
+// moleculer JS has the whole node in the "node" field: the observer records only its id.
+type Observation struct {
+	Event   string      `json:"event"`
+	Payload interface{} `json:"payload"`
+}
+
+func (observation Observation) String() string {
+	payload, _ := json.Marshal(observation.Payload)
+	return observation.Event + " " + string(payload)
+}
+
+// About returns true when the observation is about the node nodeID. $services.changed does not name
+// the node whose services changed: it is about any node.
+func (observation Observation) About(nodeID string) bool {
+	switch payload := observation.Payload.(type) {
+	case string:
+		return payload == nodeID
+	case map[string]interface{}:
+		node, named := payload["node"]
+		return !named || node == nodeID
+	}
+	return false
+}
+
+// Expected returns the internal events an observer gets about the subject nodeID for a step, in order.
+// A Go observer gets those of moleculer-go v0.3.10: they are fired from the local bus of the broker
+// with the nodeID as payload, there is no $services.changed, and a node that comes back is
+// $node.updated, since the registry keeps the node when it disconnects.
+func Expected(step Step, nodeID string, goObserver bool) []Observation {
+	if goObserver {
+		switch step {
+		case Started:
+			return []Observation{{NodeConnected, nodeID}}
+		case Published, Restarted:
+			return []Observation{{NodeUpdated, nodeID}}
+		case Stopped, Killed:
+			return []Observation{{NodeDisconnected, nodeID}}
+		}
+		return nil
+	}
+	changed := Observation{ServicesChanged, map[string]interface{}{"localService": false}}
+	switch step {
+	case Started, Restarted:
+		connected := Observation{NodeConnected, map[string]interface{}{"node": nodeID, "reconnected": step == Restarted}}
+		return []Observation{changed, connected}
+	case Published:
+		return []Observation{changed, {NodeUpdated, map[string]interface{}{"node": nodeID}}}
+	case Stopped, Killed:
+		disconnected := Observation{NodeDisconnected, map[string]interface{}{"node": nodeID, "unexpected": step == Killed}}
+		return []Observation{disconnected, changed}
+	}
+	return nil
+}
+
+// InOrder checks that the observations about nodeID contain the expected ones in the same order.
+// Other observations may come in between: a node sends its INFO again when it discovers a node.
+func InOrder(observations, expected []Observation, nodeID string) error {
+	next := 0
+	for _, observation := range observations {
+		if next < len(expected) && observation.About(nodeID) && equal(observation, expected[next]) {
+			next++
+		}
+	}
+	if next < len(expected) {
+		return fmt.Errorf("expected %v in this order, got %v: %v is missing", expected, observations, expected[next])
+	}
+	return nil
+}
+
+// equal compares observations as they are on the wire.
+func equal(observation, expected Observation) bool {
+	if observation.Event != expected.Event {
+		return false
+	}
+	actual, _ := json.Marshal(observation.Payload)
+	wanted, _ := json.Marshal(expected.Payload)
+	var actualValue, wantedValue interface{}
+	json.Unmarshal(actual, &actualValue)
+	json.Unmarshal(wanted, &wantedValue)
+	return reflect.DeepEqual(actualValue, wantedValue)
+}
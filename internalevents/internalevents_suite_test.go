@@ -0,0 +1,20 @@
+package internalevents
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestInternalEvents(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Internal Events Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
@@ -0,0 +1,193 @@
+package internalevents
+
+import (
+	"sort"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the observers once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// lives returns the names of Lives, sorted.
+func lives() []string {
+	names := []string{}
+	for name := range Lives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// observer is an observer service, asked by bkr.
+type observer struct {
+	bkr        *broker.ServiceBroker
+	service    string
+	goObserver bool
+}
+
+// live takes subject through steps and checks after each step that the observers got the expected
+// internal events about it.
+func live(subject Subject, steps []Step, observers ...observer) {
+	for _, step := range steps {
+		for _, item := range observers {
+			Expect(Reset(item.bkr, item.service)).Should(Succeed())
+		}
+		switch step {
+		case Started, Restarted:
+			Expect(subject.Start()).Should(Succeed())
+		case Published:
+			Expect(subject.Publish()).Should(Succeed())
+		case Stopped:
+			Expect(subject.Stop()).Should(Succeed())
+		case Killed:
+			Expect(subject.Kill()).Should(Succeed())
+		}
+		for _, item := range observers {
+			observer := item
+			expected := Expected(step, subject.NodeID(), observer.goObserver)
+			Eventually(func() error {
+				observations, err := Observed(observer.bkr, observer.service)
+				if err != nil {
+					return err
+				}
+				return InOrder(observations, expected, subject.NodeID())
+			}, 4*HeartbeatTimeout+harness.StartTimeout, 100*time.Millisecond).Should(Succeed(), "%s after %s of %s", observer.service, step, subject.NodeID())
+		}
+	}
+}
+
+// replaySubject is a replayed JS subject, with the jssubject service.
+type replaySubject struct {
+	nodeID   string
+	mem      *memory.SharedMemory
+	observer *broker.ServiceBroker
+	peer     *harness.ReplayPeer
+}
+
+func (subject *replaySubject) NodeID() string {
+	return subject.nodeID
+}
+
+func (subject *replaySubject) Start() error {
+	subject.peer = harness.NewReplayPeer(subject.nodeID, harness.MemoryTransporter(subject.mem)(), harness.ReplayInfo(replayService("jssubject", "publish")))
+	if err := subject.peer.Start(); err != nil {
+		return err
+	}
+	return harness.WaitForServices(subject.observer, time.Now().Add(5*time.Second), "jssubject")
+}
+
+func (subject *replaySubject) Publish() error {
+	subject.peer.AddService(replayService("jsextra", "ping"))
+	return nil
+}
+
+func (subject *replaySubject) Stop() error {
+	return subject.peer.Stop()
+}
+
+func (subject *replaySubject) Kill() error {
+	return subject.peer.Kill()
+}
+
+// replayService returns the INFO of a JS service named name with an action.
+func replayService(name, action string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":     name,
+		"fullName": name,
+		"settings": map[string]interface{}{},
+		"metadata": map[string]interface{}{},
+		"actions": map[string]interface{}{
+			name + "." + action: map[string]interface{}{"rawName": action, "name": name + "." + action},
+		},
+		"events": map[string]interface{}{},
+	}
+}
+
+var _ = Describe("Go internal events", func() {
+	var mem *memory.SharedMemory
+	var goObserver *broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		goObserver = broker.New(Config(GoObserver, harness.MemoryTransporter(mem)))
+		goObserver.Publish(ObserverService("goobserver"))
+		goObserver.Start()
+	})
+
+	AfterEach(func() {
+		goObserver.Stop()
+	})
+
+	for _, item := range lives() {
+		life := item
+
+		It("a Go observer should get the internal events of a Go subject with a "+life+" life", func() {
+			subject := NewGoSubject(SubjectID(true, life), harness.MemoryTransporter(mem))
+			defer subject.Kill()
+			live(subject, Lives[life], observer{goObserver, "goobserver", true})
+		})
+	}
+
+	Context("with a replayed JS subject", func() {
+		var heartbeatInterval time.Duration
+
+		BeforeEach(func() {
+			heartbeatInterval = harness.ReplayHeartbeatInterval
+			harness.ReplayHeartbeatInterval = HeartbeatInterval
+		})
+
+		AfterEach(func() {
+			harness.ReplayHeartbeatInterval = heartbeatInterval
+		})
+
+		for _, item := range lives() {
+			life := item
+
+			It("a Go observer should get the internal events of a JS subject with a "+life+" life", func() {
+				subject := &replaySubject{nodeID: SubjectID(false, life), mem: mem, observer: goObserver}
+				defer subject.Kill()
+				live(subject, Lives[life], observer{goObserver, "goobserver", true})
+			})
+		}
+	})
+})
+
+var _ = Describe("Internal events with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	for _, item := range lives() {
+		life := item
+
+		It("the Go and JS observers should get the internal events of a Go subject with a "+life+" life", func() {
+			subject := NewGoSubject(SubjectID(true, life), harness.NATSTransporter(harness.NATSURL()))
+			defer subject.Kill()
+			live(subject, Lives[life],
+				observer{cluster.Observer, "goobserver", true},
+				observer{cluster.Observer, "jsobserver", false})
+		})
+
+		It("the Go and JS observers should get the internal events of a JS subject with a "+life+" life", func() {
+			subject := NewJSSubject(SubjectID(false, life), ".", harness.NATSURL(), cluster.Observer)
+			defer subject.Kill()
+			live(subject, Lives[life],
+				observer{cluster.Observer, "goobserver", true},
+				observer{cluster.Observer, "jsobserver", false})
+		})
+	}
+})
@@ -0,0 +1,51 @@
+"use strict";
+
+// The JS observer of the internalevents suite (see services.go), with the NATS url as first argument:
+//   jsobserver.observed returns the internal events it got in order, as {event, payload}, with the
+//                       id of the node in place of the node in the payload;
+//   jsobserver.reset    forgets them;
+//   jsobserver.services returns $node.services of js-observer.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS observer with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-observer",
+	logLevel: "warn",
+	heartbeatInterval: 1,
+	heartbeatTimeout: 2
+});
+
+let observed = [];
+const events = {};
+for (const event of ["$node.connected", "$node.updated", "$node.disconnected", "$services.changed"]) {
+	events[event] = function(ctx) {
+		const payload = Object.assign({}, ctx.params);
+		if (payload.node) {
+			payload.node = payload.node.id;
+		}
+		observed.push({ event, payload });
+	};
+}
+
+broker.createService({
+	name: "jsobserver",
+	actions: {
+		observed() {
+			return observed;
+		},
+		reset() {
+			observed = [];
+			return observed.length;
+		},
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		}
+	},
+	events
+});
+
+broker.start();
@@ -0,0 +1,283 @@
+package internalevents
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+const (
+	// GoObserver is the Go node with the goobserver service.
+	GoObserver = "go-observer"
+	// JSObserver is the JS node of observer.js, with the jsobserver service.
+	JSObserver = "js-observer"
+)
+
+// The heartbeats of all the nodes, short so that a crash is noticed within seconds.
+const (
+	HeartbeatInterval = time.Second
+	HeartbeatTimeout  = 2 * time.Second
+)
+
+// SubjectID returns the nodeID of the subject of a life, for a Go subject or a JS subject. Each life
+// has its own subject, so that the observers have never seen it when it starts.
+func SubjectID(goSubject bool, life string) string {
+	if goSubject {
+		return "go-subject-" + life
+	}
+	return "js-subject-" + life
+}
+
+// ObserverService returns the service named name that records the internal Events:
+//   - <name>.observed returns the Observations in the order of the events;
+//   - <name>.reset forgets them.
+func ObserverService(name string) moleculer.ServiceSchema {
+	var mutex sync.Mutex
+	observed := []Observation{}
+	service := moleculer.ServiceSchema{
+		Name: name,
+		Actions: []moleculer.Action{
+			{
+				Name: "observed",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					list := []interface{}{}
+					for _, observation := range observed {
+						list = append(list, map[string]interface{}{"event": observation.Event, "payload": observation.Payload})
+					}
+					return list
+				},
+			},
+			{
+				Name: "reset",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					observed = []Observation{}
+					return len(observed)
+				},
+			},
+		},
+	}
+	for _, item := range Events {
+		event := item
+		service.Events = append(service.Events, moleculer.Event{
+			Name: event,
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				observed = append(observed, Observation{Event: event, Payload: params.Value()})
+			},
+		})
+	}
+	return service
+}
+
+// Observed returns the observations of the observer service name, asked by bkr.
+func Observed(bkr *broker.ServiceBroker, name string) ([]Observation, error) {
+	result := <-bkr.Call(name+".observed", map[string]interface{}{})
+	if result.IsError() {
+		return nil, result.Error()
+	}
+	bytes, err := json.Marshal(result.Value())
+	if err != nil {
+		return nil, err
+	}
+	observations := []Observation{}
+	return observations, json.Unmarshal(bytes, &observations)
+}
+
+// Reset forgets the observations of the observer service name, asked by bkr.
+func Reset(bkr *broker.ServiceBroker, name string) error {
+	return (<-bkr.Call(name+".reset", map[string]interface{}{})).Error()
+}
+
+// Config returns the config of a Go node with the heartbeats of the suite.
+func Config(nodeID string, transporter func() transit.Transport) *moleculer.Config {
+	return &moleculer.Config{
+		LogLevel:           "ERROR",
+		HeartbeatFrequency: HeartbeatInterval,
+		HeartbeatTimeout:   HeartbeatTimeout,
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	}
+}
+
+// Subject is a node the observers watch during its life.
+type Subject interface {
+	NodeID() string
+	// Start starts the node, and starts it again after Stop.
+	Start() error
+	// Publish adds a service to the node.
+	Publish() error
+	// Stop stops the node gracefully.
+	Stop() error
+	// Kill crashes the node.
+	Kill() error
+}
+
+// GoSubject is a Go subject with the gosubject service. Publish adds the goextra service, and Kill
+// pulls the plug of the broker (see harness.Plug).
+type GoSubject struct {
+	nodeID      string
+	transporter func() transit.Transport
+	bkr         *broker.ServiceBroker
+	plug        *harness.Plug
+}
+
+// NewGoSubject creates a Go subject connected with transporter.
+func NewGoSubject(nodeID string, transporter func() transit.Transport) *GoSubject {
+	return &GoSubject{nodeID: nodeID, transporter: transporter}
+}
+
+// NodeID returns the nodeID of the subject.
+func (subject *GoSubject) NodeID() string {
+	return subject.nodeID
+}
+
+// Start starts a new broker for the subject.
+func (subject *GoSubject) Start() error {
+	subject.plug = harness.NewPlug()
+	subject.bkr = broker.New(Config(subject.nodeID, func() transit.Transport {
+		return subject.plug.Wrap(subject.transporter())
+	}))
+	subject.bkr.Publish(pingService("gosubject"))
+	subject.bkr.Start()
+	return nil
+}
+
+// Publish adds the goextra service.
+func (subject *GoSubject) Publish() error {
+	subject.bkr.Publish(pingService("goextra"))
+	return nil
+}
+
+// Stop stops the broker.
+func (subject *GoSubject) Stop() error {
+	if subject.bkr != nil {
+		subject.bkr.Stop()
+		subject.bkr = nil
+	}
+	return nil
+}
+
+// Kill pulls the plug of the broker and stops it.
+func (subject *GoSubject) Kill() error {
+	if subject.plug != nil {
+		subject.plug.Pull()
+	}
+	return subject.Stop()
+}
+
+// pingService returns a service named name with a ping action.
+func pingService(name string) moleculer.ServiceSchema {
+	return moleculer.ServiceSchema{
+		Name: name,
+		Actions: []moleculer.Action{
+			{
+				Name: "ping",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					return "pong"
+				},
+			},
+		},
+	}
+}
+
+// JSSubject is a JS subject: subject.js with the jssubject service. Publish calls jssubject.publish,
+// which creates the jsextra service. Stop terminates the process, which moleculer JS handles with
+// broker.stop(), and Kill kills it.
+type JSSubject struct {
+	nodeID string
+	dir    string
+	url    string
+	caller *broker.ServiceBroker
+	peer   *harness.Peer
+}
+
+// NewJSSubject creates a JS subject connected to the NATS server at url, that caller asks to publish.
+func NewJSSubject(nodeID, dir, url string, caller *broker.ServiceBroker) *JSSubject {
+	return &JSSubject{nodeID: nodeID, dir: dir, url: url, caller: caller}
+}
+
+// NodeID returns the nodeID of the subject.
+func (subject *JSSubject) NodeID() string {
+	return subject.nodeID
+}
+
+// Start starts subject.js and waits until the caller sees jssubject.
+func (subject *JSSubject) Start() error {
+	peer, err := harness.StartNode(subject.dir, "subject.js", map[string]string{"NODE_ID": subject.nodeID}, subject.url)
+	if err != nil {
+		return err
+	}
+	subject.peer = peer
+	return harness.WaitForEndpoints(subject.caller, time.Now().Add(harness.StartTimeout), "jssubject.publish", subject.nodeID)
+}
+
+// Publish asks the subject to create the jsextra service.
+func (subject *JSSubject) Publish() error {
+	result := <-subject.caller.Call("jssubject.publish", map[string]interface{}{}, moleculer.Options{NodeID: subject.nodeID})
+	return result.Error()
+}
+
+// Stop terminates subject.js.
+func (subject *JSSubject) Stop() error {
+	if subject.peer == nil {
+		return nil
+	}
+	return subject.peer.Stop()
+}
+
+// Kill kills subject.js.
+func (subject *JSSubject) Kill() error {
+	if subject.peer == nil {
+		return nil
+	}
+	return subject.peer.Kill()
+}
+
+// Cluster is the Go observer and observer.js with the JS observer, connected over NATS.
+type Cluster struct {
+	Observer *broker.ServiceBroker
+	JS       *harness.Peer
+}
+
+// Start starts observer.js and the Go observer, connected to the NATS server at url, and waits
+// until they see each other.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "observer.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Observer: broker.New(Config(GoObserver, harness.NATSTransporter(url)))}
+	cluster.Observer.Publish(ObserverService("goobserver"))
+	cluster.Observer.Start()
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Observer, deadline, "jsobserver"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(cluster.Observer, deadline, "jsobserver.services", "goobserver"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// Stop stops the Go observer and kills observer.js.
+func (cluster *Cluster) Stop() {
+	cluster.Observer.Stop()
+	cluster.JS.Kill()
+}
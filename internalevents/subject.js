@@ -0,0 +1,38 @@
+"use strict";
+
+// The JS subject of the internalevents suite (see services.go), with the NATS url as first argument
+// and its nodeID in the NODE_ID environment variable:
+//   jssubject.publish creates the jsextra service.
+// moleculer JS stops the broker, with a DISCONNECT, when the process gets SIGTERM.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS subject " + process.env["NODE_ID"] + " with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: process.env["NODE_ID"],
+	logLevel: "warn",
+	heartbeatInterval: 1,
+	heartbeatTimeout: 2
+});
+
+broker.createService({
+	name: "jssubject",
+	actions: {
+		publish() {
+			broker.createService({
+				name: "jsextra",
+				actions: {
+					ping() {
+						return "pong";
+					}
+				}
+			});
+			return true;
+		}
+	}
+});
+
+broker.start();
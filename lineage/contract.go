@@ -0,0 +1,230 @@
+// Package lineage checks the context of each hop of a chain of calls and events across Go and JS
+// nodes: its requestID, parentID, level, caller and nodeID must follow from the hop before it, the
+// way moleculer JS builds them, and maxCallLevel must stop chains that go too deep.
+package lineage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxCallLevel is the maxCallLevel of all the nodes of the suite.
+const MaxCallLevel = 5
+
+// Node is a node of the suite with its lineage service.
+type Node struct {
+	ID      string
+	Service string
+	Go      bool
+}
+
+// The nodes of the suite. GoRelay stands in for JSLineage in the specs without Node.js.
+var (
+	GoLineage = Node{ID: "go-lineage", Service: "golineage", Go: true}
+	GoRelay   = Node{ID: "go-relay", Service: "gorelay", Go: true}
+	JSLineage = Node{ID: "js-lineage", Service: "jslineage", Go: false}
+)
+
+// Hop is a step of a chain: a call or an event handled by a node.
+type Hop struct {
+	Node  Node
+	Event bool
+}
+
+// Chain is a sequence of hops. The first hop is a call from the broker of its node, each of the
+// others is made by the handler of the hop before it.
+type Chain []Hop
+
+// Calls returns the chain of calls through nodes.
+func Calls(nodes ...Node) Chain {
+	chain := Chain{}
+	for _, node := range nodes {
+		chain = append(chain, Hop{Node: node})
+	}
+	return chain
+}
+
+// Events returns the chain through nodes where each handler emits an event to the next node.
+func Events(nodes ...Node) Chain {
+	chain := Calls(nodes...)
+	for index := 1; index < len(chain); index++ {
+		chain[index].Event = true
+	}
+	return chain
+}
+
+// Route returns the params of the first hop of the chain, which each handler passes on to the next
+// hop without its first step: { hop, route: [{ service, event }] }.
+func (chain Chain) Route() map[string]interface{} {
+	route := []interface{}{}
+	for _, hop := range chain[1:] {
+		route = append(route, map[string]interface{}{"service": hop.Node.Service, "event": hop.Event})
+	}
+	return map[string]interface{}{"hop": 0, "route": route}
+}
+
+// String describes the chain, e.g. "Go → call JS → call Go".
+func (chain Chain) String() string {
+	steps := []string{}
+	for index, hop := range chain {
+		side := "JS"
+		if hop.Node.Go {
+			side = "Go"
+		}
+		switch {
+		case index == 0:
+			steps = append(steps, side)
+		case hop.Event:
+			steps = append(steps, "event "+side)
+		default:
+			steps = append(steps, "call "+side)
+		}
+	}
+	return strings.Join(steps, " → ")
+}
+
+// Failure is an error a handler got from the next hop.
+type Failure struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Record is the context of a hop, recorded by its handler.
+type Record struct {
+	Hop  int    `json:"hop"`
+	Node string `json:"node"`
+	// Service is the service of the handler.
+	Service   string `json:"service"`
+	ID        string `json:"id"`
+	RequestID string `json:"requestID"`
+	ParentID  string `json:"parentID"`
+	Level     int    `json:"level"`
+	Caller    string `json:"caller"`
+	// NodeID is the node the hop came from.
+	NodeID string `json:"nodeID"`
+	// Failure is the error of the next hop, if it failed.
+	Failure *Failure `json:"failure,omitempty"`
+}
+
+// Differences returns, for each hop, the sorted fields of its context that moleculer JS would have
+// built otherwise. The first hop is a call from the broker: level 1, no parentID nor caller, its own
+// id as requestID and its own node as nodeID. The context of each other hop has the requestID of the
+// first hop, and the id as parentID, the level plus one, the service as caller and the node as nodeID
+// of the hop before it.
+func (chain Chain) Differences(records []Record) ([][]string, error) {
+	if len(records) != len(chain) {
+		return nil, fmt.Errorf("%d hops of %s were recorded, not %d: %+v", len(records), chain, len(chain), records)
+	}
+	differences := [][]string{}
+	for index, record := range records {
+		if record.Hop != index || record.Node != chain[index].Node.ID {
+			return nil, fmt.Errorf("hop %d of %s was recorded by %s as hop %d: %+v", index, chain, record.Node, record.Hop, records)
+		}
+		expected := Record{Level: 1, RequestID: record.ID, NodeID: record.Node}
+		if index > 0 {
+			parent := records[index-1]
+			expected = Record{
+				RequestID: records[0].RequestID,
+				ParentID:  parent.ID,
+				Level:     parent.Level + 1,
+				Caller:    parent.Service,
+				NodeID:    parent.Node,
+			}
+		}
+		fields := []string{}
+		if record.RequestID != expected.RequestID {
+			fields = append(fields, "requestID")
+		}
+		if record.ParentID != expected.ParentID {
+			fields = append(fields, "parentID")
+		}
+		if record.Level != expected.Level {
+			fields = append(fields, "level")
+		}
+		if record.Caller != expected.Caller {
+			fields = append(fields, "caller")
+		}
+		if record.NodeID != expected.NodeID {
+			fields = append(fields, "nodeID")
+		}
+		sort.Strings(fields)
+		differences = append(differences, fields)
+	}
+	return differences, nil
+}
+
+// GoIssues returns the Differences of the chain that come from moleculer-go v0.3.10:
+//   - a call from a Go broker has level 2 and the id of the context of the broker as parentID, and
+//     the handler of a local call has no nodeID;
+//   - a Go handler gets neither the requestID nor the caller of a REQUEST, nor the requestID,
+//     the parentID, the level or the caller of an EVENT: the calls and events that follow have
+//     another requestID;
+//   - a Go handler sends the name of its action or event as caller, not the name of its service.
+func (chain Chain) GoIssues() [][]string {
+	issues := [][]string{}
+	for index, hop := range chain {
+		fields := []string{}
+		switch {
+		case index == 0:
+			if hop.Node.Go {
+				fields = append(fields, "level", "nodeID", "parentID")
+			}
+		case hop.Node.Go && hop.Event:
+			fields = append(fields, "caller", "level", "parentID", "requestID")
+		case hop.Node.Go:
+			fields = append(fields, "caller", "requestID")
+		default:
+			if chain[index-1].Node.Go {
+				fields = append(fields, "caller")
+			}
+			for _, before := range chain[1:index] {
+				if before.Node.Go {
+					fields = append(fields, "requestID")
+					break
+				}
+			}
+		}
+		issues = append(issues, fields)
+	}
+	return issues
+}
+
+// Limited returns the first hop whose level reached MaxCallLevel, or -1: its call to the next hop
+// must fail with a MaxCallLevelError and no hop may follow it.
+func Limited(records []Record) int {
+	for index, record := range records {
+		if record.Level >= MaxCallLevel {
+			return index
+		}
+	}
+	return -1
+}
+
+// MaxCallLevelName is the name of the error of moleculer JS for a call beyond maxCallLevel.
+const MaxCallLevelName = "MaxCallLevelError"
+
+// CheckLimit checks that no hop followed the Limited hop, whose call failed with the MaxCallLevelError
+// of its node: "Request level is reached, the limit is '<level>' on '<nodeID>' node."
+func CheckLimit(records []Record) error {
+	limited := Limited(records)
+	if limited < 0 {
+		return fmt.Errorf("no hop reached level %d: %+v", MaxCallLevel, records)
+	}
+	if len(records) > limited+1 {
+		return fmt.Errorf("%d hops followed hop %d at level %d: %+v", len(records)-limited-1, limited, records[limited].Level, records)
+	}
+	failure := records[limited].Failure
+	if failure == nil {
+		return fmt.Errorf("the call of hop %d at level %d did not fail: %+v", limited, records[limited].Level, records)
+	}
+	if failure.Name != MaxCallLevelName ||
+		!strings.HasPrefix(failure.Message, "Request level is reached, the limit is '") ||
+		!strings.HasSuffix(failure.Message, fmt.Sprintf("' on '%s' node.", records[limited].Node)) {
+		return fmt.Errorf("the call of hop %d at level %d failed with %s: %s", limited, records[limited].Level, failure.Name, failure.Message)
+	}
+	return nil
+}
+
+// GoMaxCallLevelIssue is why a Go handler calls beyond MaxCallLevel.
+const GoMaxCallLevelIssue = "moleculer-go v0.3.10 has a MaxCallLevel setting but never checks it"
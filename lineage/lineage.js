@@ -0,0 +1,75 @@
+"use strict";
+
+// The JS lineage node of the lineage suite (see services.go), with the NATS url as first argument:
+//   jslineage.hop and the jslineage.hopped event record their context as hop params.hop and make the
+//                 next hop of params.route: a call to <service>.hop or a <service>.hopped event;
+//   jslineage.records  returns the records: { hop, node, service, id, requestID, parentID, level,
+//                      caller, nodeID, failure }, failure being the error of the next hop;
+//   jslineage.reset    deletes them;
+//   jslineage.services returns $node.services of js-lineage.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS lineage with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-lineage",
+	logLevel: "warn",
+	maxCallLevel: 5
+});
+
+let records = [];
+
+async function hop(ctx) {
+	const record = {
+		hop: ctx.params.hop,
+		node: broker.nodeID,
+		service: "jslineage",
+		id: ctx.id,
+		requestID: ctx.requestID || "",
+		parentID: ctx.parentID || "",
+		level: ctx.level,
+		caller: ctx.caller || "",
+		nodeID: ctx.nodeID
+	};
+	try {
+		const [step, ...route] = ctx.params.route;
+		if (step && step.event) {
+			ctx.emit(step.service + ".hopped", { hop: ctx.params.hop + 1, route });
+		} else if (step) {
+			await ctx.call(step.service + ".hop", { hop: ctx.params.hop + 1, route });
+		}
+	} catch (err) {
+		record.failure = { name: err.name, message: err.message };
+		throw err;
+	} finally {
+		records.push(record);
+	}
+	return broker.nodeID;
+}
+
+broker.createService({
+	name: "jslineage",
+	actions: {
+		hop,
+		records() {
+			return records;
+		},
+		reset() {
+			records = [];
+			return true;
+		},
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		}
+	},
+	events: {
+		"jslineage.hopped"(ctx) {
+			return hop(ctx).catch(() => {});
+		}
+	}
+});
+
+broker.start();
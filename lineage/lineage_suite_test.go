@@ -0,0 +1,20 @@
+package lineage
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLineage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lineage Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
@@ -0,0 +1,111 @@
+package lineage
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the nodes once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// records waits until all the hops of chain are recorded, asked by bkr.
+func records(bkr *broker.ServiceBroker, chain Chain, hops int) []Record {
+	var list []Record
+	Eventually(func() ([]Record, error) {
+		var err error
+		list, err = Records(bkr, chain)
+		return list, err
+	}, harness.StartTimeout, 100*time.Millisecond).Should(HaveLen(hops))
+	return list
+}
+
+// lineage runs chain from bkr and checks that the context of each hop only differs from the one of
+// moleculer JS by the GoIssues of the chain.
+func lineage(bkr *broker.ServiceBroker, chain Chain) {
+	Expect(Run(bkr, chain)).Should(Succeed())
+	list := records(bkr, chain, len(chain))
+	differences, err := chain.Differences(list)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(differences).Should(Equal(chain.GoIssues()), "%+v", list)
+}
+
+var _ = Describe("Go lineage", func() {
+	var golineage, relay *broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem := &memory.SharedMemory{}
+		golineage = StartNode(GoLineage, harness.MemoryTransporter(mem))
+		relay = StartNode(GoRelay, harness.MemoryTransporter(mem))
+		Expect(harness.WaitForServices(golineage, time.Now().Add(harness.StartTimeout), GoRelay.Service)).Should(Succeed())
+		Expect(harness.WaitForServices(relay, time.Now().Add(harness.StartTimeout), GoLineage.Service)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		golineage.Stop()
+		relay.Stop()
+	})
+
+	for _, item := range []Chain{
+		Calls(GoLineage, GoRelay, GoLineage, GoRelay),
+		Events(GoLineage, GoRelay, GoLineage, GoRelay),
+	} {
+		chain := item
+
+		It("Go nodes should pass the context on along "+chain.String(), func() {
+			lineage(golineage, chain)
+		})
+	}
+
+	It("Go nodes should stop a chain of calls at MaxCallLevel", func() {
+		chain := Calls(GoLineage, GoRelay, GoLineage, GoRelay, GoLineage, GoRelay)
+		Expect(Run(golineage, chain)).Should(Succeed(), GoMaxCallLevelIssue)
+		list := records(golineage, chain, len(chain))
+		Expect(Limited(list)).Should(BeNumerically(">=", 0))
+		Expect(CheckLimit(list)).ShouldNot(Succeed(), GoMaxCallLevelIssue)
+	})
+})
+
+var _ = Describe("Lineage with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	for _, item := range []Chain{
+		Calls(GoLineage, JSLineage, GoLineage, JSLineage),
+		Events(GoLineage, JSLineage, GoLineage, JSLineage),
+	} {
+		chain := item
+
+		It("Go and JS nodes should pass the context on along "+chain.String(), func() {
+			lineage(cluster.Go, chain)
+		})
+	}
+
+	It("a JS node should stop a chain of calls at maxCallLevel", func() {
+		chain := Calls(GoLineage, JSLineage, GoLineage, JSLineage, GoLineage, JSLineage)
+		Expect(Run(cluster.Go, chain)).ShouldNot(Succeed())
+		var list []Record
+		Eventually(func() error {
+			var err error
+			list, err = Records(cluster.Go, chain)
+			if err != nil {
+				return err
+			}
+			return CheckLimit(list)
+		}, harness.StartTimeout, 100*time.Millisecond).Should(Succeed())
+		Expect(chain[Limited(list)].Node).Should(Equal(JSLineage), "the first hop at maxCallLevel")
+	})
+})
@@ -0,0 +1,218 @@
+package lineage
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+// LineageService returns the lineage service of node:
+//   - <service>.hop and the <service>.hopped event record their context as hop params.hop and make
+//     the next hop of params.route, see Chain.Route;
+//   - <service>.records returns the records;
+//   - <service>.reset deletes them.
+func LineageService(node Node) moleculer.ServiceSchema {
+	var mutex sync.Mutex
+	records := []Record{}
+	hop := func(ctx moleculer.Context, params moleculer.Payload) error {
+		record := contextRecord(node, ctx, params.Get("hop").Int())
+		err := next(ctx, params)
+		if err != nil {
+			record.Failure = &Failure{Message: err.Error()}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		records = append(records, record)
+		return err
+	}
+	return moleculer.ServiceSchema{
+		Name: node.Service,
+		Actions: []moleculer.Action{
+			{
+				Name: "hop",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					if err := hop(ctx, params); err != nil {
+						return err
+					}
+					return node.ID
+				},
+			},
+			{
+				Name: "records",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					list := []interface{}{}
+					for _, record := range records {
+						list = append(list, record)
+					}
+					return list
+				},
+			},
+			{
+				Name: "reset",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					records = []Record{}
+					return true
+				},
+			},
+		},
+		Events: []moleculer.Event{
+			{
+				Name: node.Service + ".hopped",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+					hop(ctx, params)
+				},
+			},
+		},
+	}
+}
+
+// contextRecord returns the Record of the context of a Go handler of node.
+func contextRecord(node Node, ctx moleculer.Context, hop int) Record {
+	record := Record{Hop: hop, Node: node.ID, Service: node.Service}
+	if brokerContext, isBrokerContext := ctx.(moleculer.BrokerContext); isBrokerContext {
+		values := brokerContext.AsMap()
+		record.ID, _ = values["id"].(string)
+		record.RequestID, _ = values["requestID"].(string)
+		record.ParentID, _ = values["parentID"].(string)
+		record.Level, _ = values["level"].(int)
+		record.Caller, _ = values["caller"].(string)
+	}
+	if source, hasSource := ctx.(interface{ SourceNodeID() string }); hasSource {
+		record.NodeID = source.SourceNodeID()
+	}
+	return record
+}
+
+// next makes the first hop of params.route with the rest of the route.
+func next(ctx moleculer.Context, params moleculer.Payload) error {
+	route := params.Get("route").Array()
+	if len(route) == 0 {
+		return nil
+	}
+	rest := []interface{}{}
+	for _, step := range route[1:] {
+		rest = append(rest, step.Value())
+	}
+	nextParams := map[string]interface{}{"hop": params.Get("hop").Int() + 1, "route": rest}
+	service := route[0].Get("service").String()
+	if route[0].Get("event").Bool() {
+		ctx.Emit(service+".hopped", nextParams)
+		return nil
+	}
+	return (<-ctx.Call(service+".hop", nextParams)).Error()
+}
+
+// Run resets the records of the nodes of chain, makes the first hop from bkr and returns the error of
+// the first hop once it returns.
+func Run(bkr *broker.ServiceBroker, chain Chain) error {
+	for _, service := range services(chain) {
+		if result := <-bkr.Call(service+".reset", map[string]interface{}{}); result.IsError() {
+			return result.Error()
+		}
+	}
+	return (<-bkr.Call(chain[0].Node.Service+".hop", chain.Route())).Error()
+}
+
+// Records returns the records of the nodes of chain, asked by bkr, sorted by hop.
+func Records(bkr *broker.ServiceBroker, chain Chain) ([]Record, error) {
+	records := []Record{}
+	for _, service := range services(chain) {
+		result := <-bkr.Call(service+".records", map[string]interface{}{})
+		if result.IsError() {
+			return nil, result.Error()
+		}
+		list := []Record{}
+		bytes, err := json.Marshal(result.Value())
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(bytes, &list); err != nil {
+			return nil, err
+		}
+		records = append(records, list...)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Hop < records[j].Hop
+	})
+	return records, nil
+}
+
+// services returns the services of the nodes of chain, once each.
+func services(chain Chain) []string {
+	list := []string{}
+	for _, hop := range chain {
+		if !contains(list, hop.Node.Service) {
+			list = append(list, hop.Node.Service)
+		}
+	}
+	return list
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// StartNode starts the Go broker of node with its lineage service and MaxCallLevel, connected with
+// transporter.
+func StartNode(node Node, transporter func() transit.Transport) *broker.ServiceBroker {
+	bkr := broker.New(&moleculer.Config{
+		LogLevel:     "ERROR",
+		MaxCallLevel: MaxCallLevel,
+		DiscoverNodeID: func() string {
+			return node.ID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	})
+	bkr.Publish(LineageService(node))
+	bkr.Start()
+	return bkr
+}
+
+// Cluster is the Go lineage node and lineage.js with the JS lineage node, connected over NATS.
+type Cluster struct {
+	Go *broker.ServiceBroker
+	JS *harness.Peer
+}
+
+// Start starts lineage.js and the Go lineage node, connected to the NATS server at url, and waits
+// until they see each other.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "lineage.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Go: StartNode(GoLineage, harness.NATSTransporter(url))}
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Go, deadline, JSLineage.Service); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(cluster.Go, deadline, JSLineage.Service+".services", GoLineage.Service); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// Stop stops the Go lineage node and kills lineage.js.
+func (cluster *Cluster) Stop() {
+	cluster.Go.Stop()
+	cluster.JS.Kill()
+}
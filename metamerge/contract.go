@@ -0,0 +1,79 @@
+// Package metamerge checks what a caller sees of the meta of a call once the callee responded:
+// moleculer JS merges the meta of the RESPONSE, the meta of the callee with its changes, into the
+// meta of the caller. A Go caller and a JS caller each call a Go callee and a JS callee, which add,
+// change and delete keys of the meta, nested objects and arrays included.
+package metamerge
+
+import (
+	"encoding/json"
+)
+
+// Sent is the meta of the callers.
+func Sent() map[string]interface{} {
+	return map[string]interface{}{
+		"kept":    "as sent",
+		"changed": "before",
+		"deleted": "before",
+		"nulled":  "before",
+		"nested": map[string]interface{}{
+			"kept":    1,
+			"changed": 2,
+			"deleted": 3,
+			"deeper":  map[string]interface{}{"list": []interface{}{"a"}},
+		},
+		"list": []interface{}{1, map[string]interface{}{"id": 2}},
+	}
+}
+
+// Change makes the changes of the callees to meta, a copy of the meta they got: they add, change,
+// delete and null top level keys, change, add and delete keys of a nested object and of the object
+// nested in it, append to the arrays and add an object with an array.
+func Change(meta map[string]interface{}) map[string]interface{} {
+	changed := Plain(meta)
+	changed["added"] = "by the callee"
+	changed["changed"] = "after"
+	delete(changed, "deleted")
+	changed["nulled"] = nil
+	if nested, isMap := changed["nested"].(map[string]interface{}); isMap {
+		nested["changed"] = 20
+		nested["added"] = 40
+		delete(nested, "deleted")
+		if deeper, isMap := nested["deeper"].(map[string]interface{}); isMap {
+			deeper["list"] = append(deeper["list"].([]interface{}), "b")
+		}
+	}
+	if list, isList := changed["list"].([]interface{}); isList {
+		changed["list"] = append(list, map[string]interface{}{"id": 3})
+	}
+	changed["addedNested"] = map[string]interface{}{"list": []interface{}{map[string]interface{}{"ok": true}}}
+	return changed
+}
+
+// Merged returns the meta of a moleculer JS caller after the call: the meta of the RESPONSE is
+// merged into sent with Object.assign, so that its top level keys replace those of sent, nested
+// objects and arrays as a whole, while the keys the callee deleted are kept as sent.
+func Merged(sent, response map[string]interface{}) map[string]interface{} {
+	merged := Plain(sent)
+	for key, value := range Plain(response) {
+		merged[key] = value
+	}
+	return merged
+}
+
+// GoIssue is why a Go caller still has the meta it sent after the call.
+const GoIssue = "moleculer-go v0.3.10 never merges the meta of the callee, local or from a RESPONSE: the meta of the caller stays as sent"
+
+// Plain returns a copy of meta as it is on the wire, numbers as float64.
+func Plain(meta map[string]interface{}) map[string]interface{} {
+	plain := map[string]interface{}{}
+	decode(meta, &plain)
+	return plain
+}
+
+func decode(value interface{}, target interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
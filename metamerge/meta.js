@@ -0,0 +1,54 @@
+"use strict";
+
+// The JS meta node of the metamerge suite (see services.go), with the NATS url as first argument:
+//   jsmeta.call     calls params.action and returns { meta, received }: ctx.meta after the call and
+//                   the response, the meta the callee got;
+//   jsmeta.change   makes the changes of Change (contract.go) to ctx.meta and returns the meta it got;
+//   jsmeta.services returns $node.services of js-meta.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS meta with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-meta",
+	logLevel: "warn"
+});
+
+broker.createService({
+	name: "jsmeta",
+	actions: {
+		async call(ctx) {
+			const received = await ctx.call(ctx.params.action, {});
+			return { meta: ctx.meta, received };
+		},
+		change(ctx) {
+			const received = JSON.parse(JSON.stringify(ctx.meta));
+			const meta = ctx.meta;
+			meta.added = "by the callee";
+			meta.changed = "after";
+			delete meta.deleted;
+			meta.nulled = null;
+			if (meta.nested) {
+				meta.nested.changed = 20;
+				meta.nested.added = 40;
+				delete meta.nested.deleted;
+				if (meta.nested.deeper) {
+					meta.nested.deeper.list.push("b");
+				}
+			}
+			if (meta.list) {
+				meta.list.push({ id: 3 });
+			}
+			meta.addedNested = { list: [{ ok: true }] };
+			return received;
+		},
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		}
+	}
+});
+
+broker.start();
@@ -0,0 +1,20 @@
+package metamerge
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMetamerge(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Metamerge Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
@@ -0,0 +1,109 @@
+package metamerge
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the nodes once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// side returns Go or JS.
+func side(node Node) string {
+	if node.Go {
+		return "Go"
+	}
+	return "JS"
+}
+
+// call makes caller call callee, asked by bkr, and checks that the callee got the meta as sent.
+func call(bkr *broker.ServiceBroker, caller, callee Node) Outcome {
+	outcome, err := Call(bkr, caller, callee)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(outcome.Received).Should(Equal(Plain(Sent())), "the meta %s got", callee.ID)
+	return outcome
+}
+
+// merge makes caller call callee, asked by bkr, and checks that the caller merged the meta changed by
+// the callee like moleculer JS. A Go caller is checked against GoIssue: it keeps the meta it sent.
+func merge(bkr *broker.ServiceBroker, caller, callee Node) {
+	outcome := call(bkr, caller, callee)
+	if caller.Go {
+		Expect(outcome.Meta).Should(Equal(Plain(Sent())), GoIssue)
+		return
+	}
+	Expect(outcome.Meta).Should(Equal(Merged(Sent(), Change(Sent()))))
+}
+
+var _ = Describe("Go meta merge", func() {
+	var packets *harness.PacketRecorder
+	var caller, callee *broker.ServiceBroker
+
+	BeforeEach(func() {
+		var err error
+		packets, err = harness.NewPacketRecorder("Memory", harness.PacketFile(CurrentGinkgoTestDescription().FullTestText))
+		Expect(err).ShouldNot(HaveOccurred())
+		mem := &memory.SharedMemory{}
+		caller = StartNode(GoMeta, func() transit.Transport {
+			return packets.Wrap(harness.MemoryTransporter(mem)())
+		})
+		callee = StartNode(GoRelay, harness.MemoryTransporter(mem))
+		Expect(harness.WaitForServices(caller, time.Now().Add(harness.StartTimeout), GoRelay.Service)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		caller.Stop()
+		callee.Stop()
+		packets.Close(CurrentGinkgoTestDescription().Failed)
+	})
+
+	It("a Go callee should send the meta it changed in its RESPONSE", func() {
+		call(caller, GoMeta, GoRelay)
+
+		var meta map[string]interface{}
+		for _, packet := range packets.Packets() {
+			if packet.Direction == "in" && packet.Type == "RESPONSE" && packet.Sender == GoRelay.ID {
+				response := map[string]interface{}{}
+				Expect(decode(packet.Payload, &response)).Should(Succeed())
+				meta, _ = response["meta"].(map[string]interface{})
+			}
+		}
+		Expect(meta).Should(Equal(Plain(Change(Sent()))))
+	})
+
+	It("a Go caller should merge the meta changed by a remote Go callee", func() {
+		merge(caller, GoMeta, GoRelay)
+	})
+
+	It("a Go caller should merge the meta changed by a local Go callee", func() {
+		merge(caller, GoMeta, GoMeta)
+	})
+})
+
+var _ = Describe("Meta merge with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	for _, items := range [][2]Node{{GoMeta, JSMeta}, {JSMeta, GoMeta}, {JSMeta, JSMeta}} {
+		caller, callee := items[0], items[1]
+
+		It("a "+side(caller)+" caller should merge the meta changed by a "+side(callee)+" callee", func() {
+			merge(cluster.Go, caller, callee)
+		})
+	}
+})
@@ -0,0 +1,119 @@
+package metamerge
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/payload"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+// Node is a node of the suite with its meta service.
+type Node struct {
+	ID      string
+	Service string
+	Go      bool
+}
+
+// The nodes of the suite. GoRelay stands in for JSMeta in the specs without Node.js.
+var (
+	GoMeta  = Node{ID: "go-meta", Service: "gometa", Go: true}
+	GoRelay = Node{ID: "go-relay", Service: "relaymeta", Go: true}
+	JSMeta  = Node{ID: "js-meta", Service: "jsmeta", Go: false}
+)
+
+// MetaService returns the meta service of node:
+//   - <service>.call calls params.action and returns { meta, received }: its meta after the call and
+//     the response, the meta the callee got;
+//   - <service>.change makes the changes of Change to its meta and returns the meta it got.
+func MetaService(node Node) moleculer.ServiceSchema {
+	return moleculer.ServiceSchema{
+		Name: node.Service,
+		Actions: []moleculer.Action{
+			{
+				Name: "call",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					result := <-ctx.Call(params.Get("action").String(), map[string]interface{}{})
+					if result.IsError() {
+						return result.Error()
+					}
+					return map[string]interface{}{"meta": ctx.Meta().Value(), "received": result.Value()}
+				},
+			},
+			{
+				Name: "change",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					received := Plain(ctx.Meta().RawMap())
+					ctx.(moleculer.BrokerContext).UpdateMeta(payload.New(Change(received)))
+					return received
+				},
+			},
+		},
+	}
+}
+
+// Outcome is what a caller saw of a call: its meta after the call and the meta the callee got.
+type Outcome struct {
+	Meta     map[string]interface{} `json:"meta"`
+	Received map[string]interface{} `json:"received"`
+}
+
+// Call makes caller call the change action of callee with the Sent meta, asked by bkr.
+func Call(bkr *broker.ServiceBroker, caller, callee Node) (Outcome, error) {
+	result := <-bkr.Call(caller.Service+".call", map[string]interface{}{"action": callee.Service + ".change"}, moleculer.Options{Meta: payload.New(Sent())})
+	if result.IsError() {
+		return Outcome{}, result.Error()
+	}
+	outcome := Outcome{}
+	return outcome, decode(result.Value(), &outcome)
+}
+
+// StartNode starts the Go broker of node with its meta service, connected with transporter.
+func StartNode(node Node, transporter func() transit.Transport) *broker.ServiceBroker {
+	bkr := broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return node.ID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	})
+	bkr.Publish(MetaService(node))
+	bkr.Start()
+	return bkr
+}
+
+// Cluster is the Go meta node and meta.js with the JS meta node, connected over NATS.
+type Cluster struct {
+	Go *broker.ServiceBroker
+	JS *harness.Peer
+}
+
+// Start starts meta.js and the Go meta node, connected to the NATS server at url, and waits until
+// they see each other.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "meta.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Go: StartNode(GoMeta, harness.NATSTransporter(url))}
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Go, deadline, JSMeta.Service); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(cluster.Go, deadline, JSMeta.Service+".services", GoMeta.Service); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// Stop stops the Go meta node and kills meta.js.
+func (cluster *Cluster) Stop() {
+	cluster.Go.Stop()
+	cluster.JS.Kill()
+}
@@ -0,0 +1,20 @@
+package moleculerjs
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMoleculerjs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Moleculer JS ↔ Go Scenario Matrix Suite")
+}
+
+var _ = AfterSuite(func() {
+	harness.StopEmbeddedNATS()
+	harness.StopEmbeddedRedis()
+})
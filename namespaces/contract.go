@@ -0,0 +1,167 @@
+// Package namespaces checks that Go and JS nodes in two namespaces share one transporter without
+// meeting: the nodes of a namespace discover, call and hear the events of each other, Go and JS
+// alike, and never those of the other namespace. The namespace is the prefix of the topics of a
+// node, which moleculer-go and moleculer JS must name alike.
+package namespaces
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node is a node of the suite.
+type Node struct {
+	ID        string
+	Namespace string
+	Go        bool
+}
+
+// Nodes are the nodes of the suite: a Go node and a JS node in each namespace.
+var Nodes = []Node{
+	{ID: "go-a", Namespace: "A", Go: true},
+	{ID: "js-a", Namespace: "A", Go: false},
+	{ID: "go-b", Namespace: "B", Go: true},
+	{ID: "js-b", Namespace: "B", Go: false},
+}
+
+// NodeIDs returns the nodeIDs of the nodes.
+func NodeIDs(nodes []Node) []string {
+	ids := []string{}
+	for _, node := range nodes {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+// Neighbours returns the other nodes of the namespace of node.
+func (node Node) Neighbours() []Node {
+	neighbours := []Node{}
+	for _, other := range Nodes {
+		if other.Namespace == node.Namespace && other.ID != node.ID {
+			neighbours = append(neighbours, other)
+		}
+	}
+	return neighbours
+}
+
+// Prefix returns the prefix of the topics of namespace: MOL, or MOL-<namespace>.
+func Prefix(namespace string) string {
+	if namespace == "" {
+		return "MOL"
+	}
+	return "MOL-" + namespace
+}
+
+// Topic returns the topic of moleculer JS for the packets command to nodeID, or broadcast when nodeID
+// is empty: <prefix>.<command>[.<nodeID>]. The NATS and the Redis transporters name them alike.
+func Topic(namespace, command, nodeID string) string {
+	parts := []string{Prefix(namespace), command}
+	if nodeID != "" {
+		parts = append(parts, nodeID)
+	}
+	return strings.Join(parts, ".")
+}
+
+// ParseTopic returns the namespace, the command and the nodeID of a topic named like Topic.
+func ParseTopic(topic string) (namespace, command, nodeID string, err error) {
+	parts := strings.SplitN(topic, ".", 3)
+	if len(parts) < 2 || (parts[0] != "MOL" && !strings.HasPrefix(parts[0], "MOL-")) {
+		return "", "", "", fmt.Errorf("%s is not a moleculer topic", topic)
+	}
+	namespace = strings.TrimPrefix(strings.TrimPrefix(parts[0], "MOL"), "-")
+	if len(parts) == 3 {
+		nodeID = parts[2]
+	}
+	return namespace, parts[1], nodeID, nil
+}
+
+// GoRedisChannel returns the Redis channel of moleculer-go v0.3.10 for the packets command to nodeID:
+// <prefix>:<command>:<nodeID>, with a trailing colon for broadcasts.
+func GoRedisChannel(namespace, command, nodeID string) string {
+	return fmt.Sprintf("%s:%s:%s", Prefix(namespace), command, nodeID)
+}
+
+// GoRedisIssue is why the Redis channels of moleculer-go do not match those of moleculer JS.
+const GoRedisIssue = "moleculer-go v0.3.10 joins the Redis channels with colons, moleculer JS with dots"
+
+// Observation is what a node saw of the others.
+type Observation struct {
+	// Nodes are the available nodes of $node.list, but the node itself.
+	Nodes []string `json:"nodes"`
+	// Reached are the nodes that answered a member.whoami call sent to them.
+	Reached []string `json:"reached"`
+	// Heard are the nodes whose member.probe event the node received.
+	Heard []string `json:"heard"`
+}
+
+// Check checks that node saw all its Neighbours, or those of them that are listed in neighbours when
+// it is not empty, and none of the nodes of the other namespace.
+func (node Node) Check(observation Observation, neighbours ...string) error {
+	if len(neighbours) == 0 {
+		neighbours = NodeIDs(node.Neighbours())
+	}
+	seen := map[string][]string{
+		"$node.list":                observation.Nodes,
+		"member.whoami answers":     observation.Reached,
+		"member.probe events heard": observation.Heard,
+	}
+	names := []string{}
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, nodeID := range seen[name] {
+			if nodeID != node.ID && !contains(NodeIDs(node.Neighbours()), nodeID) {
+				return fmt.Errorf("%s of %s in namespace %s has %s of another namespace: %v", name, node.ID, node.Namespace, nodeID, seen[name])
+			}
+		}
+		for _, nodeID := range neighbours {
+			if !contains(seen[name], nodeID) {
+				return fmt.Errorf("%s of %s in namespace %s misses %s: %v", name, node.ID, node.Namespace, nodeID, seen[name])
+			}
+		}
+	}
+	return nil
+}
+
+// CheckTopics checks that all the topics node published to are of its namespace and that it
+// published its DISCOVER, INFO and HEARTBEAT broadcasts and its REQ or RES to each of neighbours
+// to the topics of moleculer JS.
+func (node Node) CheckTopics(topics []string, neighbours ...string) error {
+	for _, topic := range topics {
+		namespace, _, _, err := ParseTopic(topic)
+		if err != nil {
+			return fmt.Errorf("%s published to %v: %s", node.ID, topics, err)
+		}
+		if namespace != node.Namespace {
+			return fmt.Errorf("%s in namespace %s published to %s", node.ID, node.Namespace, topic)
+		}
+	}
+	expected := []string{
+		Topic(node.Namespace, "DISCOVER", ""),
+		Topic(node.Namespace, "INFO", ""),
+		Topic(node.Namespace, "HEARTBEAT", ""),
+	}
+	for _, topic := range expected {
+		if !contains(topics, topic) {
+			return fmt.Errorf("%s did not publish to %s: %v", node.ID, topic, topics)
+		}
+	}
+	for _, neighbour := range neighbours {
+		if !contains(topics, Topic(node.Namespace, "REQ", neighbour)) && !contains(topics, Topic(node.Namespace, "RES", neighbour)) {
+			return fmt.Errorf("%s sent neither REQ nor RES to %s: %v", node.ID, neighbour, topics)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+"use strict";
+
+// A JS node of the namespaces suite (see services.go), with the NATS url as first argument, its
+// nodeID in the NODE_ID environment variable and its namespace in NAMESPACE:
+//   member.whoami  returns { nodeID, namespace };
+//   member.shout   broadcasts member.probe with { from: nodeID };
+//   member.observe returns { nodes, reached, heard }: the other available nodes of $node.list, the
+//                  nodes of params.nodeIDs that answered member.whoami and the nodes heard from.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS member " + process.env["NODE_ID"] + " in namespace " + process.env["NAMESPACE"] + " with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const nodeID = process.env["NODE_ID"];
+const namespace = process.env["NAMESPACE"];
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID,
+	namespace,
+	logLevel: "warn"
+});
+
+const heard = [];
+
+broker.createService({
+	name: "member",
+	actions: {
+		whoami() {
+			return { nodeID, namespace };
+		},
+		shout(ctx) {
+			ctx.broadcast("member.probe", { from: nodeID });
+			return nodeID;
+		},
+		async observe(ctx) {
+			const list = await ctx.call("$node.list", { onlyAvailable: true });
+			const nodes = list.map(node => node.id).filter(id => id !== nodeID).sort();
+			const reached = [];
+			for (const id of ctx.params.nodeIDs) {
+				if (id === nodeID) {
+					continue;
+				}
+				try {
+					const answer = await ctx.call("member.whoami", {}, { nodeID: id });
+					reached.push(answer.nodeID);
+				} catch (err) {
+					// Not a node of the namespace.
+				}
+			}
+			return { nodes, reached, heard: heard.slice() };
+		}
+	},
+	events: {
+		"member.probe"(payload) {
+			if (payload.from !== nodeID && !heard.includes(payload.from)) {
+				heard.push(payload.from);
+			}
+		}
+	}
+});
+
+broker.start();
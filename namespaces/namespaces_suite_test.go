@@ -0,0 +1,20 @@
+package namespaces
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestNamespaces(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Namespaces Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
@@ -0,0 +1,166 @@
+package namespaces
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/serializer"
+	"github.com/moleculer-go/moleculer/transit"
+	"github.com/moleculer-go/moleculer/transit/redis"
+	log "github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the nodes once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// startReplayMember starts a replayed JS node with the member service over NATS, in the namespace of node.
+func startReplayMember(node Node) *harness.ReplayPeer {
+	peer := harness.NewReplayPeer(node.ID, harness.NATSTransporter(harness.NATSURL())(), harness.ReplayInfo(
+		map[string]interface{}{
+			"name":     "member",
+			"fullName": "member",
+			"settings": map[string]interface{}{},
+			"metadata": map[string]interface{}{},
+			"actions": map[string]interface{}{
+				"member.whoami": map[string]interface{}{"rawName": "whoami", "name": "member.whoami"},
+			},
+			"events": map[string]interface{}{},
+		},
+	))
+	peer.SetNamespace(node.Namespace)
+	peer.Handle("member.whoami", func(request moleculer.Payload) (interface{}, error) {
+		return map[string]interface{}{"nodeID": node.ID, "namespace": node.Namespace}, nil
+	})
+	Expect(peer.Start()).Should(Succeed())
+	return peer
+}
+
+// knownNodes returns the nodes with services known by peer.
+func knownNodes(peer *harness.ReplayPeer) []string {
+	nodes := []string{}
+	for _, service := range peer.Services() {
+		for _, nodeID := range service["nodes"].([]string) {
+			if !contains(nodes, nodeID) {
+				nodes = append(nodes, nodeID)
+			}
+		}
+	}
+	return nodes
+}
+
+// observe checks the Observation of node, asked by bkr, until it has neighbours.
+func observe(bkr *broker.ServiceBroker, node Node, neighbours ...string) {
+	Eventually(func() error {
+		observation, err := Observe(bkr, node.ID)
+		if err != nil {
+			return err
+		}
+		return node.Check(observation, neighbours...)
+	}, harness.StartTimeout, 200*time.Millisecond).Should(Succeed())
+}
+
+var _ = Describe("Go namespaces", func() {
+	It("Go nodes should only meet the replayed JS node of their namespace over NATS, on the topics a JS node uses", func() {
+		tap, err := StartNATSTap(harness.NATSURL())
+		Expect(err).ShouldNot(HaveOccurred())
+		defer tap.Close()
+
+		brokers := map[string]*broker.ServiceBroker{}
+		peers := map[string]*harness.ReplayPeer{}
+		for _, node := range Nodes {
+			if node.Go {
+				bkr := NewBroker(node, harness.NATSTransporter(harness.NATSURL()))
+				bkr.Publish(MemberService(node))
+				bkr.Start()
+				defer bkr.Stop()
+				brokers[node.Namespace] = bkr
+			} else {
+				peer := startReplayMember(node)
+				defer peer.Kill()
+				peers[node.Namespace] = peer
+			}
+		}
+
+		for _, node := range Nodes {
+			if node.Go {
+				continue
+			}
+			peer := peers[node.Namespace]
+			Eventually(func() []string {
+				return knownNodes(peer)
+			}, harness.StartTimeout, 100*time.Millisecond).Should(ConsistOf(node.ID, node.Neighbours()[0].ID))
+			peer.Broadcast("member.probe", map[string]interface{}{"from": node.ID})
+		}
+		for _, node := range Nodes {
+			if node.Go {
+				observe(brokers[node.Namespace], node)
+				Expect(node.CheckTopics(tap.Topics(node.ID), NodeIDs(node.Neighbours())...)).Should(Succeed())
+			}
+		}
+		for _, node := range Nodes {
+			if !node.Go {
+				Expect(knownNodes(peers[node.Namespace])).Should(ConsistOf(node.ID, node.Neighbours()[0].ID), "the nodes known by "+node.ID)
+			}
+		}
+	})
+
+	It("a Go node should name its Redis channels with colons, unlike a JS node", func() {
+		server, err := harness.EmbeddedRedis()
+		Expect(err).ShouldNot(HaveOccurred())
+		tap, err := StartRedisTap(fmt.Sprintf("%s:%d", server.Host(), server.Port()))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer tap.Close()
+
+		node := Nodes[0]
+		bkr := NewBroker(node, func() transit.Transport {
+			transport := redis.NewRedisTransporter(&redis.RedisConfig{Host: server.Host(), Port: server.Port()})
+			transport.SetSerializer(serializer.CreateJSONSerializer(log.WithField("serializer", "json")))
+			return transport
+		})
+		bkr.Start()
+		defer bkr.Stop()
+
+		Eventually(func() []string {
+			return tap.Topics(node.ID)
+		}, harness.StartTimeout, 100*time.Millisecond).Should(ContainElement(GoRedisChannel(node.Namespace, "HEARTBEAT", "")))
+		Expect(tap.Topics(node.ID)).Should(ContainElement(GoRedisChannel(node.Namespace, "INFO", "")))
+		Expect(node.CheckTopics(tap.Topics(node.ID))).ShouldNot(Succeed(), GoRedisIssue)
+	})
+})
+
+var _ = Describe("Namespaces with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	It("the Go and JS nodes of a namespace should only meet each other", func() {
+		for _, node := range Nodes {
+			Expect(Shout(cluster.Brokers[node.Namespace], node.ID)).Should(Succeed())
+		}
+		for _, node := range Nodes {
+			observe(cluster.Brokers[node.Namespace], node)
+		}
+	})
+
+	It("the Go and JS nodes should publish to the same topics for their namespace", func() {
+		for _, node := range Nodes {
+			observe(cluster.Brokers[node.Namespace], node)
+		}
+		for _, node := range Nodes {
+			Expect(node.CheckTopics(cluster.Tap.Topics(node.ID), NodeIDs(node.Neighbours())...)).Should(Succeed())
+		}
+	})
+})
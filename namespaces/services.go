@@ -0,0 +1,257 @@
+package namespaces
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+	natsclient "github.com/nats-io/nats.go"
+)
+
+// MemberService returns the member service of node:
+//   - member.whoami returns the nodeID and the namespace of node;
+//   - member.shout broadcasts member.probe with the nodeID of node;
+//   - member.observe returns the Observation of node, calling member.whoami on each of params.nodeIDs.
+func MemberService(node Node) moleculer.ServiceSchema {
+	var mutex sync.Mutex
+	heard := []string{}
+	return moleculer.ServiceSchema{
+		Name: "member",
+		Actions: []moleculer.Action{
+			{
+				Name: "whoami",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					return map[string]interface{}{"nodeID": node.ID, "namespace": node.Namespace}
+				},
+			},
+			{
+				Name: "shout",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					ctx.Broadcast("member.probe", map[string]interface{}{"from": node.ID})
+					return node.ID
+				},
+			},
+			{
+				Name: "observe",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					observation := map[string]interface{}{}
+					nodes := []string{}
+					list := <-ctx.Call("$node.list", map[string]interface{}{"onlyAvailable": true})
+					for _, item := range list.Array() {
+						if id := item.Get("id").String(); id != node.ID {
+							nodes = append(nodes, id)
+						}
+					}
+					reached := []string{}
+					for _, nodeID := range params.Get("nodeIDs").StringArray() {
+						if nodeID == node.ID {
+							continue
+						}
+						answer := <-ctx.Call("member.whoami", map[string]interface{}{}, moleculer.Options{NodeID: nodeID})
+						if !answer.IsError() {
+							reached = append(reached, answer.Get("nodeID").String())
+						}
+					}
+					mutex.Lock()
+					observation["heard"] = append([]string{}, heard...)
+					mutex.Unlock()
+					sort.Strings(nodes)
+					observation["nodes"] = nodes
+					observation["reached"] = reached
+					return observation
+				},
+			},
+		},
+		Events: []moleculer.Event{
+			{
+				Name: "member.probe",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+					mutex.Lock()
+					defer mutex.Unlock()
+					if from := params.Get("from").String(); from != node.ID && !contains(heard, from) {
+						heard = append(heard, from)
+					}
+				},
+			},
+		},
+	}
+}
+
+// Observe returns the Observation of the node nodeID, asked by bkr.
+func Observe(bkr *broker.ServiceBroker, nodeID string) (Observation, error) {
+	result := <-bkr.Call("member.observe", map[string]interface{}{"nodeIDs": NodeIDs(Nodes)}, moleculer.Options{NodeID: nodeID})
+	if result.IsError() {
+		return Observation{}, result.Error()
+	}
+	observation := Observation{}
+	return observation, decode(result.Value(), &observation)
+}
+
+// Shout makes the node nodeID broadcast member.probe, asked by bkr.
+func Shout(bkr *broker.ServiceBroker, nodeID string) error {
+	return (<-bkr.Call("member.shout", map[string]interface{}{}, moleculer.Options{NodeID: nodeID})).Error()
+}
+
+func decode(value interface{}, target interface{}) error {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bytes, target)
+}
+
+// NewBroker creates the Go broker of node in its namespace, connected with transporter.
+func NewBroker(node Node, transporter func() transit.Transport) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel:  "ERROR",
+		Namespace: node.Namespace,
+		DiscoverNodeID: func() string {
+			return node.ID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	})
+}
+
+// Tap records the topics that each node publishes to, on a NATS server or a Redis server.
+type Tap struct {
+	mutex  sync.Mutex
+	topics map[string][]string
+	close  func()
+}
+
+func newTap() *Tap {
+	return &Tap{topics: map[string][]string{}}
+}
+
+func (tap *Tap) record(topic string, data []byte) {
+	packet := struct {
+		Sender string `json:"sender"`
+	}{}
+	if json.Unmarshal(data, &packet) != nil || packet.Sender == "" {
+		return
+	}
+	tap.mutex.Lock()
+	defer tap.mutex.Unlock()
+	if !contains(tap.topics[packet.Sender], topic) {
+		tap.topics[packet.Sender] = append(tap.topics[packet.Sender], topic)
+	}
+}
+
+// StartNATSTap taps all the subjects of the NATS server at url.
+func StartNATSTap(url string) (*Tap, error) {
+	conn, err := natsclient.Connect(url)
+	if err != nil {
+		return nil, err
+	}
+	tap := newTap()
+	if _, err := conn.Subscribe(">", func(msg *natsclient.Msg) {
+		tap.record(msg.Subject, msg.Data)
+	}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := conn.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tap.close = conn.Close
+	return tap, nil
+}
+
+// StartRedisTap taps all the channels of the Redis server at host:port.
+func StartRedisTap(address string) (*Tap, error) {
+	client := redis.NewClient(&redis.Options{Addr: address})
+	subscription := client.PSubscribe(context.Background(), "*")
+	if _, err := subscription.Receive(context.Background()); err != nil {
+		client.Close()
+		return nil, err
+	}
+	tap := newTap()
+	go func() {
+		for message := range subscription.Channel() {
+			tap.record(message.Channel, []byte(message.Payload))
+		}
+	}()
+	tap.close = func() {
+		subscription.Close()
+		client.Close()
+	}
+	return tap, nil
+}
+
+// Topics returns the topics nodeID published to, sorted.
+func (tap *Tap) Topics(nodeID string) []string {
+	tap.mutex.Lock()
+	defer tap.mutex.Unlock()
+	topics := append([]string{}, tap.topics[nodeID]...)
+	sort.Strings(topics)
+	return topics
+}
+
+// Close stops tapping.
+func (tap *Tap) Close() {
+	tap.close()
+}
+
+// Cluster is a Go node and member.js with a JS node in each namespace, connected over NATS and tapped.
+type Cluster struct {
+	Brokers map[string]*broker.ServiceBroker
+	JS      []*harness.Peer
+	Tap     *Tap
+}
+
+// Start starts the nodes of the suite, connected to the NATS server at url, and waits until the Go
+// node of each namespace sees the JS node.
+func Start(dir, url string) (*Cluster, error) {
+	tap, err := StartNATSTap(url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{Brokers: map[string]*broker.ServiceBroker{}, Tap: tap}
+	for _, node := range Nodes {
+		if node.Go {
+			bkr := NewBroker(node, harness.NATSTransporter(url))
+			bkr.Publish(MemberService(node))
+			bkr.Start()
+			cluster.Brokers[node.Namespace] = bkr
+			continue
+		}
+		js, err := harness.StartNode(dir, "member.js", map[string]string{"NODE_ID": node.ID, "NAMESPACE": node.Namespace}, url)
+		if err != nil {
+			cluster.Stop()
+			return nil, err
+		}
+		cluster.JS = append(cluster.JS, js)
+	}
+	deadline := time.Now().Add(harness.StartTimeout)
+	for _, node := range Nodes {
+		if node.Go {
+			continue
+		}
+		if err := harness.WaitForNodes(cluster.Brokers[node.Namespace], deadline, node.ID); err != nil {
+			cluster.Stop()
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+// Stop stops the Go nodes, kills the JS nodes and stops tapping.
+func (cluster *Cluster) Stop() {
+	for _, bkr := range cluster.Brokers {
+		bkr.Stop()
+	}
+	for _, js := range cluster.JS {
+		js.Kill()
+	}
+	cluster.Tap.Close()
+}
@@ -3,6 +3,8 @@ package nats
 import (
 	"testing"
 
+	"github.com/moleculer-go/compatibility/harness"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -11,3 +13,7 @@ func TestNats(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "NATS Moleculer JS ↔ Go Compatibility Suite")
 }
+
+var _ = AfterSuite(func() {
+	harness.StopEmbeddedNATS()
+})
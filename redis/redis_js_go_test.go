@@ -1,47 +1,38 @@
 package redis
 
 import (
-	"context"
-	"os"
-	"os/exec"
 	"time"
 
+	"github.com/moleculer-go/compatibility/harness"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
-func startJSRedisService() *exec.Cmd {
-	cmdCtx, _ := context.WithTimeout(context.Background(), time.Minute*2)
-	cmd := exec.CommandContext(cmdCtx, "node", "js-redis-service.js")
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(),
-		"REDIS_HOST="+redisTestHost(),
-		"REDIS_PORT="+redisTestPort(),
-	)
-
-	err := cmd.Start()
+func startJSRedisService() *harness.Peer {
+	peer, err := harness.StartNode(".", "js-redis-service.js", map[string]string{
+		"REDIS_HOST": redisTestHost(),
+		"REDIS_PORT": redisTestPort(),
+	})
 	if err != nil {
 		Fail("Failed to start JS Redis service: " + err.Error())
 		return nil
 	}
-	return cmd
+	return peer
 }
 
 var _ = Describe("Redis JS ↔ Go Compatibility", func() {
-	var jsCmd *exec.Cmd
+	var jsPeer *harness.Peer
 
 	BeforeEach(func() {
 		// Start JS service
-		jsCmd = startJSRedisService()
-		Expect(jsCmd).ShouldNot(BeNil())
+		jsPeer = startJSRedisService()
+		Expect(jsPeer).ShouldNot(BeNil())
 	})
 
 	AfterEach(func() {
-		if jsCmd != nil {
-			jsCmd.Process.Kill()
-			jsCmd.Wait()
+		if jsPeer != nil {
+			jsPeer.Kill()
 		}
 	})
 
@@ -51,7 +42,7 @@ var _ = Describe("Redis JS ↔ Go Compatibility", func() {
 			time.Sleep(3 * time.Second)
 
 			// Verify the service is running (no error means it started successfully)
-			Expect(jsCmd.ProcessState).To(BeNil()) // Process should still be running
+			Expect(jsPeer.Running()).To(BeTrue()) // Process should still be running
 		})
 
 		It("should perform math operations", func() {
@@ -69,11 +60,11 @@ var _ = Describe("Redis JS ↔ Go Compatibility", func() {
 			time.Sleep(2 * time.Second)
 
 			// Verify the service is still running
-			Expect(jsCmd.ProcessState).To(BeNil())
+			Expect(jsPeer.Running()).To(BeTrue())
 
 			// Wait a bit more to ensure stability
 			time.Sleep(3 * time.Second)
-			Expect(jsCmd.ProcessState).To(BeNil())
+			Expect(jsPeer.Running()).To(BeTrue())
 		})
 	})
 })
@@ -0,0 +1,7 @@
+package redis
+
+import (
+	"github.com/moleculer-go/compatibility/scenario"
+)
+
+var _ = scenario.Describe(scenario.Redis(redisTestHost(), redisTestPortNumber()))
@@ -3,6 +3,8 @@ package redis
 import (
 	"testing"
 
+	"github.com/moleculer-go/compatibility/harness"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -11,3 +13,7 @@ func TestRedisTransporter(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Redis Transporter Suite")
 }
+
+var _ = AfterSuite(func() {
+	harness.StopEmbeddedRedis()
+})
@@ -3,23 +3,38 @@ package redis
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
+	"github.com/moleculer-go/compatibility/harness"
 	"github.com/moleculer-go/moleculer/transit/redis"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+func embeddedRedis() *harness.RedisServer {
+	server, err := harness.EmbeddedRedis()
+	if err != nil {
+		panic("could not start the embedded Redis server: " + err.Error())
+	}
+	return server
+}
+
+// redisTestHost returns REDIS_HOST when set, otherwise the host of the embedded Redis server.
 func redisTestHost() string {
 	env := os.Getenv("REDIS_HOST")
 	if env == "" {
-		return "localhost"
+		return embeddedRedis().Host()
 	}
 	return env
 }
 
+// redisTestPort returns REDIS_PORT (default 6379) with REDIS_HOST, otherwise the port of the embedded Redis server.
 func redisTestPort() string {
+	if os.Getenv("REDIS_HOST") == "" {
+		return strconv.Itoa(embeddedRedis().Port())
+	}
 	env := os.Getenv("REDIS_PORT")
 	if env == "" {
 		return "6379"
@@ -27,6 +42,14 @@ func redisTestPort() string {
 	return env
 }
 
+func redisTestPortNumber() int {
+	port, err := strconv.Atoi(redisTestPort())
+	if err != nil {
+		return 6379
+	}
+	return port
+}
+
 var _ = Describe("Redis Transporter", func() {
 	var redisTransporter *redis.RedisTransporter
 
@@ -36,7 +59,7 @@ var _ = Describe("Redis Transporter", func() {
 		fmt.Printf("Redis test host: %s\n", host)
 		redisConfig := &redis.RedisConfig{
 			Host:     host,
-			Port:     6379,
+			Port:     redisTestPortNumber(),
 			Password: "",
 			DB:       2, // Use DB 2 for testing
 			Prefix:   "test-moleculer",
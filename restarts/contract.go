@@ -0,0 +1,130 @@
+// Package restarts checks that a node that restarts under the same nodeID with other services leaves
+// no stale services in the registries of the other nodes: a Go observer and a JS observer must
+// replace the services of the first instance by those of the second one, not merge them.
+package restarts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moleculer-go/moleculer"
+)
+
+// Restart is how the first instance of a subject ends before the second one starts.
+type Restart struct {
+	Name string
+	// Crash is true when the first instance crashes: the second one starts before the observers
+	// notice that the heartbeats stopped, and they only learn of the restart from the new instanceID
+	// in its INFO. Otherwise the first instance stops and sends DISCONNECT.
+	Crash bool
+	// GoIssue describes how a Go observer deviates, empty when it does not: it keeps the actions of
+	// the services the second instance dropped.
+	GoIssue string
+}
+
+// Restarts are the restarts of each spec.
+var Restarts = []Restart{
+	{Name: "a graceful stop"},
+	{
+		Name:    "a crash",
+		Crash:   true,
+		GoIssue: "moleculer-go v0.3.10 ignores instanceID and seq: it replaces the services of the node from its new INFO but keeps the actions of the dropped ones",
+	},
+}
+
+// Instances returns the services of the first and the second instance of a subject: the second one
+// keeps beta, drops alpha and adds gamma.
+func Instances(goSubject bool) ([]string, []string) {
+	prefix := "js"
+	if goSubject {
+		prefix = "go"
+	}
+	return []string{prefix + "alpha", prefix + "beta"}, []string{prefix + "beta", prefix + "gamma"}
+}
+
+// View is what an observer knows of the services of a node and of their actions: each service has a
+// ping action.
+type View struct {
+	Services []string
+	Actions  []string
+}
+
+// Expected returns the view of a node with services.
+func Expected(services []string) View {
+	view := View{Services: append([]string{}, services...), Actions: []string{}}
+	for _, service := range services {
+		view.Actions = append(view.Actions, service+".ping")
+	}
+	sort.Strings(view.Services)
+	sort.Strings(view.Actions)
+	return view
+}
+
+// ViewOf returns the view of the node nodeID from the $node.services and the $node.actions of an
+// observer, called with withEndpoints, without the internal services such as $node. The endpoints of a service are its "endpoints" in moleculer-go
+// and its "nodes" in moleculer JS.
+func ViewOf(services, actions moleculer.Payload, nodeID string) View {
+	view := View{Services: []string{}, Actions: []string{}}
+	for _, service := range services.Array() {
+		if hasNode(service, nodeID) && !strings.HasPrefix(service.Get("name").String(), "$") {
+			view.Services = append(view.Services, service.Get("name").String())
+		}
+	}
+	for _, action := range actions.Array() {
+		if hasNode(action, nodeID) && !strings.HasPrefix(action.Get("name").String(), "$") {
+			view.Actions = append(view.Actions, action.Get("name").String())
+		}
+	}
+	sort.Strings(view.Services)
+	sort.Strings(view.Actions)
+	return view
+}
+
+func hasNode(item moleculer.Payload, nodeID string) bool {
+	for _, endpoint := range item.Get("endpoints").Array() {
+		if endpoint.Get("nodeID").String() == nodeID && endpoint.Get("available").Bool() {
+			return true
+		}
+	}
+	for _, node := range item.Get("nodes").Array() {
+		if node.String() == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the services and actions of expected that the view lacks.
+func (view View) Missing(expected View) []string {
+	return append(difference(expected.Services, view.Services), difference(expected.Actions, view.Actions)...)
+}
+
+// Stale returns the services and actions of the view that expected has not.
+func (view View) Stale(expected View) []string {
+	return append(difference(view.Services, expected.Services), difference(view.Actions, expected.Actions)...)
+}
+
+// Check checks that the view is expected, nothing missing and nothing stale.
+func (view View) Check(expected View) error {
+	missing, stale := view.Missing(expected), view.Stale(expected)
+	if len(missing) > 0 || len(stale) > 0 {
+		return fmt.Errorf("missing %v, stale %v", missing, stale)
+	}
+	return nil
+}
+
+// difference returns the names of list that others has not.
+func difference(list, others []string) []string {
+	result := []string{}
+	for _, name := range list {
+		found := false
+		for _, other := range others {
+			found = found || other == name
+		}
+		if !found {
+			result = append(result, name)
+		}
+	}
+	return result
+}
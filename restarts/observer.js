@@ -0,0 +1,30 @@
+"use strict";
+
+// The JS observer of the restarts suite (see services.go), with the NATS url as first argument:
+//   jsobserver.services returns $node.services of js-observer;
+//   jsobserver.actions  returns $node.actions of js-observer.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS observer with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: "js-observer",
+	logLevel: "warn"
+});
+
+broker.createService({
+	name: "jsobserver",
+	actions: {
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		},
+		actions(ctx) {
+			return ctx.call("$node.actions", ctx.params);
+		}
+	}
+});
+
+broker.start();
@@ -0,0 +1,20 @@
+package restarts
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRestarts(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Restarts Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
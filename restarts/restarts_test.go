@@ -0,0 +1,174 @@
+package restarts
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the observers once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// observer is the registry of an observer: its $node.services and $node.actions like actions,
+// asked by bkr.
+type observer struct {
+	bkr      *broker.ServiceBroker
+	services string
+	actions  string
+	goIssue  bool
+}
+
+func goObserver(bkr *broker.ServiceBroker) observer {
+	return observer{bkr, "$node.services", "$node.actions", true}
+}
+
+func jsObserver(bkr *broker.ServiceBroker) observer {
+	return observer{bkr, "jsobserver.services", "jsobserver.actions", false}
+}
+
+func (observer observer) view(nodeID string) func() View {
+	return func() View {
+		view, err := Observe(observer.bkr, observer.services, observer.actions, nodeID)
+		Expect(err).ShouldNot(HaveOccurred())
+		return view
+	}
+}
+
+// restart starts the first instance of subject, ends it with restart, starts the second instance and
+// checks that the observers replaced the services of the first instance by those of the second one. A
+// Go observer keeps the deviation of restart.GoIssue instead, when there is one.
+func restart(subject Subject, goSubject bool, restart Restart, observers ...observer) {
+	first, second := Instances(goSubject)
+	Expect(subject.Start(first)).Should(Succeed())
+	for _, observer := range observers {
+		Eventually(observer.view(subject.NodeID()), harness.StartTimeout, 100*time.Millisecond).Should(Equal(Expected(first)))
+	}
+
+	if restart.Crash {
+		Expect(subject.Crash()).Should(Succeed())
+	} else {
+		Expect(subject.Stop()).Should(Succeed())
+		for _, observer := range observers {
+			Eventually(observer.view(subject.NodeID()), harness.StartTimeout, 100*time.Millisecond).Should(Equal(Expected(nil)))
+		}
+	}
+
+	Expect(subject.Start(second)).Should(Succeed())
+	expected := Expected(second)
+	for _, observer := range observers {
+		view := observer.view(subject.NodeID())
+		Eventually(func() []string {
+			return view().Missing(expected)
+		}, harness.StartTimeout, 100*time.Millisecond).Should(BeEmpty())
+		if observer.goIssue && restart.GoIssue != "" {
+			// The actions of the services the second instance dropped are left behind.
+			Expect(view().Stale(expected)).Should(Equal(Expected(difference(first, second)).Actions), restart.GoIssue)
+		} else {
+			Eventually(func() error {
+				return view().Check(expected)
+			}, harness.StartTimeout, 100*time.Millisecond).Should(Succeed(), observer.services)
+		}
+	}
+}
+
+// replaySubject is a replayed JS subject: each instance is a replay peer with a new instanceID.
+type replaySubject struct {
+	nodeID string
+	mem    *memory.SharedMemory
+	peer   *harness.ReplayPeer
+}
+
+func (subject *replaySubject) NodeID() string {
+	return subject.nodeID
+}
+
+func (subject *replaySubject) Start(services []string) error {
+	schemas := []interface{}{}
+	for _, name := range services {
+		schemas = append(schemas, map[string]interface{}{
+			"name":     name,
+			"fullName": name,
+			"settings": map[string]interface{}{},
+			"metadata": map[string]interface{}{},
+			"actions": map[string]interface{}{
+				name + ".ping": map[string]interface{}{"rawName": "ping", "name": name + ".ping"},
+			},
+			"events": map[string]interface{}{},
+		})
+	}
+	subject.peer = harness.NewReplayPeer(subject.nodeID, harness.MemoryTransporter(subject.mem)(), harness.ReplayInfo(schemas...))
+	return subject.peer.Start()
+}
+
+func (subject *replaySubject) Stop() error {
+	return subject.peer.Stop()
+}
+
+func (subject *replaySubject) Crash() error {
+	return subject.peer.Kill()
+}
+
+var _ = Describe("Go registry restarts", func() {
+	var mem *memory.SharedMemory
+	var bkr *broker.ServiceBroker
+
+	BeforeEach(func() {
+		mem = &memory.SharedMemory{}
+		bkr = NewBroker(GoObserver, harness.MemoryTransporter(mem))
+		bkr.Start()
+	})
+
+	AfterEach(func() {
+		bkr.Stop()
+	})
+
+	for _, item := range Restarts {
+		restartItem := item
+
+		It("a Go observer should replace the services of a Go node that restarts after "+restartItem.Name, func() {
+			subject := NewGoSubject(SubjectID(true, restartItem), harness.MemoryTransporter(mem))
+			defer subject.Stop()
+			restart(subject, true, restartItem, goObserver(bkr))
+		})
+
+		It("a Go observer should replace the services of a replayed JS node that restarts after "+restartItem.Name, func() {
+			subject := &replaySubject{nodeID: SubjectID(false, restartItem), mem: mem}
+			defer subject.Crash()
+			restart(subject, false, restartItem, goObserver(bkr))
+		})
+	}
+})
+
+var _ = Describe("Restarts with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+	})
+
+	for _, item := range Restarts {
+		restartItem := item
+
+		It("the Go and JS observers should replace the services of a Go node that restarts after "+restartItem.Name, func() {
+			subject := NewGoSubject(SubjectID(true, restartItem), harness.NATSTransporter(harness.NATSURL()))
+			defer subject.Stop()
+			restart(subject, true, restartItem, goObserver(cluster.Observer), jsObserver(cluster.Observer))
+		})
+
+		It("the Go and JS observers should replace the services of a JS node that restarts after "+restartItem.Name, func() {
+			subject := NewJSSubject(SubjectID(false, restartItem), ".", harness.NATSURL())
+			defer subject.Crash()
+			restart(subject, false, restartItem, goObserver(cluster.Observer), jsObserver(cluster.Observer))
+		})
+	}
+})
@@ -0,0 +1,206 @@
+package restarts
+
+import (
+	"strings"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit"
+)
+
+const (
+	// GoObserver is the Go node that observes the subjects.
+	GoObserver = "go-observer"
+	// JSObserver is the JS node of observer.js, with the jsobserver service.
+	JSObserver = "js-observer"
+)
+
+// SubjectID returns the nodeID of the subject of a restart, for a Go subject or a JS subject.
+func SubjectID(goSubject bool, restart Restart) string {
+	prefix := "js-subject-"
+	if goSubject {
+		prefix = "go-subject-"
+	}
+	if restart.Crash {
+		return prefix + "crash"
+	}
+	return prefix + "stop"
+}
+
+// Subject is a node that restarts under the same nodeID.
+type Subject interface {
+	NodeID() string
+	// Start starts an instance of the node with services.
+	Start(services []string) error
+	// Stop stops the instance gracefully.
+	Stop() error
+	// Crash crashes the instance.
+	Crash() error
+}
+
+// Observe returns the view of the node nodeID of an observer: the $node.services and $node.actions
+// like actions services and actions, asked by bkr.
+func Observe(bkr *broker.ServiceBroker, services, actions, nodeID string) (View, error) {
+	params := map[string]interface{}{"onlyAvailable": true, "withEndpoints": true}
+	serviceList := <-bkr.Call(services, params)
+	if serviceList.IsError() {
+		return View{}, serviceList.Error()
+	}
+	actionList := <-bkr.Call(actions, params)
+	if actionList.IsError() {
+		return View{}, actionList.Error()
+	}
+	return ViewOf(serviceList, actionList, nodeID), nil
+}
+
+// NewBroker creates a Go broker connected with transporter.
+func NewBroker(nodeID string, transporter func() transit.Transport) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return transporter()
+		},
+	})
+}
+
+// pingService returns a service named name with a ping action.
+func pingService(name string) moleculer.ServiceSchema {
+	return moleculer.ServiceSchema{
+		Name: name,
+		Actions: []moleculer.Action{
+			{
+				Name: "ping",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					return "pong"
+				},
+			},
+		},
+	}
+}
+
+// GoSubject is a Go subject: each instance is a new broker, connected through a plug so that it can
+// crash (see harness.Plug).
+type GoSubject struct {
+	nodeID      string
+	transporter func() transit.Transport
+	bkr         *broker.ServiceBroker
+	plug        *harness.Plug
+}
+
+// NewGoSubject creates a Go subject connected with transporter.
+func NewGoSubject(nodeID string, transporter func() transit.Transport) *GoSubject {
+	return &GoSubject{nodeID: nodeID, transporter: transporter}
+}
+
+// NodeID returns the nodeID of the subject.
+func (subject *GoSubject) NodeID() string {
+	return subject.nodeID
+}
+
+// Start starts a new broker with services.
+func (subject *GoSubject) Start(services []string) error {
+	plug := harness.NewPlug()
+	subject.plug = plug
+	subject.bkr = NewBroker(subject.nodeID, func() transit.Transport {
+		return plug.Wrap(subject.transporter())
+	})
+	for _, service := range services {
+		subject.bkr.Publish(pingService(service))
+	}
+	subject.bkr.Start()
+	return nil
+}
+
+// Stop stops the broker.
+func (subject *GoSubject) Stop() error {
+	if subject.bkr != nil {
+		subject.bkr.Stop()
+		subject.bkr = nil
+	}
+	return nil
+}
+
+// Crash pulls the plug of the broker and stops it.
+func (subject *GoSubject) Crash() error {
+	if subject.plug != nil {
+		subject.plug.Pull()
+	}
+	return subject.Stop()
+}
+
+// JSSubject is a JS subject: each instance is a subject.js process.
+type JSSubject struct {
+	nodeID string
+	dir    string
+	url    string
+	peer   *harness.Peer
+}
+
+// NewJSSubject creates a JS subject connected to the NATS server at url.
+func NewJSSubject(nodeID, dir, url string) *JSSubject {
+	return &JSSubject{nodeID: nodeID, dir: dir, url: url}
+}
+
+// NodeID returns the nodeID of the subject.
+func (subject *JSSubject) NodeID() string {
+	return subject.nodeID
+}
+
+// Start starts subject.js with services.
+func (subject *JSSubject) Start(services []string) error {
+	peer, err := harness.StartNode(subject.dir, "subject.js", map[string]string{
+		"NODE_ID":  subject.nodeID,
+		"SERVICES": strings.Join(services, ","),
+	}, subject.url)
+	subject.peer = peer
+	return err
+}
+
+// Stop terminates subject.js, which moleculer JS handles with broker.stop().
+func (subject *JSSubject) Stop() error {
+	if subject.peer == nil {
+		return nil
+	}
+	return subject.peer.Stop()
+}
+
+// Crash kills subject.js.
+func (subject *JSSubject) Crash() error {
+	if subject.peer == nil {
+		return nil
+	}
+	return subject.peer.Kill()
+}
+
+// Cluster is the Go observer and observer.js with the JS observer, connected over NATS.
+type Cluster struct {
+	Observer *broker.ServiceBroker
+	JS       *harness.Peer
+}
+
+// Start starts observer.js and the Go observer, connected to the NATS server at url, and waits
+// until the Go observer sees the JS observer.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "observer.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Observer: NewBroker(GoObserver, harness.NATSTransporter(url))}
+	cluster.Observer.Start()
+	if err := harness.WaitForServices(cluster.Observer, time.Now().Add(harness.StartTimeout), "jsobserver"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// Stop stops the Go observer and kills observer.js.
+func (cluster *Cluster) Stop() {
+	cluster.Observer.Stop()
+	cluster.JS.Kill()
+}
@@ -0,0 +1,30 @@
+"use strict";
+
+// A JS subject of the restarts suite (see services.go), with the NATS url as first argument, its
+// nodeID in the NODE_ID environment variable and its services in SERVICES, separated by commas.
+// Each service has a ping action. moleculer JS stops the broker, with a DISCONNECT, when the process
+// gets SIGTERM.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS subject " + process.env["NODE_ID"] + " with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: process.env["NODE_ID"],
+	logLevel: "warn"
+});
+
+for (const name of process.env["SERVICES"].split(",")) {
+	broker.createService({
+		name,
+		actions: {
+			ping() {
+				return "pong";
+			}
+		}
+	});
+}
+
+broker.start();
@@ -0,0 +1,133 @@
+package scenario
+
+import (
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+
+	. "github.com/onsi/gomega"
+)
+
+// JSNodeID is the nodeID of the JS peer started for each scenario.
+const JSNodeID = "js-node"
+
+// GoNodeID is the nodeID of the Go broker started for each scenario.
+const GoNodeID = "go-node"
+
+// Node is the JS side of a scenario: a node process running services.js (harness.Peer)
+// or a harness.ReplayPeer scripted like services.js (see ReplayServices).
+type Node interface {
+	// Exited is closed when the node ended.
+	Exited() <-chan struct{}
+	// Kill ends the node without a DISCONNECT.
+	Kill() error
+}
+
+// Env is what a scenario gets to work with: a started Go broker with the UserService
+// and a JS node running services.js, both connected with Transporter and encoding the packets with Serializer.
+type Env struct {
+	Transporter Transporter
+	Serializer  Serializer
+	Broker      *broker.ServiceBroker
+	User        *UserService
+	JS          Node
+	// Packets records the packets of the Go broker. The file is kept when the spec fails.
+	Packets *harness.PacketRecorder
+}
+
+// Scenario is a row of the scenario table. Run uses Gomega assertions and is called inside an It.
+type Scenario struct {
+	Name string
+	Run  func(env *Env)
+}
+
+// Catalog is the list of imperative scenarios run for every transporter.
+// Scenarios that only need services, calls and expectations are scenario files instead (see File).
+var Catalog = []Scenario{
+	{"discovery", discovery},
+	{"profile.create", profileCreate},
+	{"user.create", userCreate},
+	{"unregister", unregister},
+	{"notifier", notifier},
+	{"finish", finish},
+}
+
+func deadline(timeout time.Duration) time.Time {
+	return time.Now().Add(timeout)
+}
+
+// discovery: each side sees the services of the other.
+func discovery(env *Env) {
+	harness.EventuallyConverge(env.Broker).Should(harness.HaveExactlyServices("account", "$node", "user", "profile"))
+	Expect(harness.WaitForServicesIn(env.Broker, deadline(5*time.Second), "profile.listServices", "user")).Should(Succeed())
+
+	snapshot, err := harness.TakeSnapshot(env.Broker)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(snapshot.Service("profile")).Should(harness.HaveEndpointOn(env.Transporter.JSNode()))
+	Expect(snapshot.Service("user")).Should(harness.HaveEndpointOn(env.Transporter.GoNode()))
+}
+
+// profile.create: Go calls JS, JS emits profile.created and the Go handler calls user.update.
+func profileCreate(env *Env) {
+	r := <-env.Broker.Call("profile.create", map[string]interface{}{
+		"id":    1,
+		"name":  "Test",
+		"email": "test@example.com",
+	})
+	Expect(r.Error()).Should(BeNil())
+	Expect(r.Get("user").Get("name").String()).Should(Equal("Test"))
+	Expect(r.Get("type").String()).Should(Equal("web-user"))
+
+	Eventually(env.User.ProfileCreated, 5*time.Second).Should(Receive())
+}
+
+// user.create: JS calls the Go action and relays the result back.
+func userCreate(env *Env) {
+	Expect(harness.WaitForServicesIn(env.Broker, deadline(5*time.Second), "profile.listServices", "user")).Should(Succeed())
+	r := <-env.Broker.Call("profile.relay", map[string]interface{}{
+		"action": "user.create",
+		"params": map[string]interface{}{
+			"id":    10,
+			"name":  "John",
+			"email": "john@snow.com",
+		},
+	})
+	Expect(r.Error()).Should(BeNil())
+	Expect(r.Get("id").Int()).Should(Equal(10))
+	Expect(r.Get("name").String()).Should(Equal("John"))
+	Expect(r.Get("email").String()).Should(Equal("john@snow.com"))
+}
+
+// unregister: a JS service destroyed at runtime disappears from the Go registry.
+func unregister(env *Env) {
+	r := <-env.Broker.Call("account.unregister", nil)
+	Expect(r.Error()).Should(BeNil())
+
+	Expect(harness.WaitForServicesGone(env.Broker, deadline(5*time.Second), "account")).Should(Succeed())
+	harness.EventuallyConverge(env.Broker).Should(harness.HaveExactlyServices("$node", "user", "profile"))
+}
+
+// notifier: a Go service published after start is discovered by JS.
+func notifier(env *Env) {
+	env.Broker.Publish(NewNotifierSvc())
+	Expect(harness.WaitForServicesIn(env.Broker, deadline(5*time.Second), "profile.listServices", "notifier")).Should(Succeed())
+	harness.EventuallyConverge(env.Broker).Should(harness.HaveExactlyServices("account", "$node", "user", "profile", "notifier"))
+}
+
+// finish: JS emits profile.finished to the Go notifier and exits; its services leave the Go registry.
+func finish(env *Env) {
+	notifierSvc := NewNotifierSvc()
+	env.Broker.Publish(notifierSvc)
+	Expect(harness.WaitForServicesIn(env.Broker, deadline(5*time.Second), "profile.listServices", "notifier")).Should(Succeed())
+
+	r := <-env.Broker.Call("profile.finish", true)
+	Expect(r.Error()).Should(BeNil())
+	Expect(r.String()).Should(Equal("JS side will explode in 500 miliseconds!"))
+
+	Eventually(notifierSvc.Received, 5*time.Second).Should(Receive())
+	Eventually(env.JS.Exited(), 10*time.Second).Should(BeClosed())
+
+	Expect(harness.WaitForServicesGone(env.Broker, deadline(20*time.Second), "profile")).Should(Succeed())
+	harness.EventuallyConverge(env.Broker).Should(harness.HaveExactlyServices("$node", "user", "notifier"))
+}
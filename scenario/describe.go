@@ -0,0 +1,182 @@
+package scenario
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// Dir returns the directory of services.js and its package.json.
+func Dir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}
+
+// Describe registers one container per transporter and serializer with one spec per scenario of
+// the Catalog and per scenario file, so that each transporter/serializer/scenario cell passes or
+// fails on its own. The serializers that moleculer-go does not implement get a single skipped
+// "not implemented in Go" spec. When the transporter can carry a replay peer, the Catalog runs a
+// second time against a harness.ReplayPeer instead of services.js: those specs need no Node.js.
+// Otherwise the catalog replay gets a single skipped "no replay peer" spec. When a JS node cannot meet
+// a Go node over the transporter (Transporter.JSIssue), the catalog and the files get a single skipped
+// "no JS node" spec.
+// Use it at the top level of a suite:
+//
+//	var _ = scenario.Describe(scenario.NATS(harness.NATSURL()))
+func Describe(transporters ...Transporter) bool {
+	for _, transporter := range transporters {
+		describe(transporter)
+	}
+	return true
+}
+
+func describe(transporter Transporter) {
+	ginkgo.Describe(transporter.Name+" Moleculer JS ↔ Go scenarios", func() {
+		for _, item := range Serializers() {
+			serializer := item
+			ginkgo.Describe(serializer.Name, func() {
+				if !serializer.Go {
+					describeNotImplemented(transporter, serializer)
+					return
+				}
+				if transporter.JSIssue != "" {
+					describeNoJS(transporter)
+				} else {
+					ginkgo.Describe("catalog", func() {
+						describeCatalog(transporter, serializer, startJS)
+					})
+				}
+				ginkgo.Describe("catalog replay", func() {
+					if !transporter.Replay {
+						describeNoReplay(transporter)
+						return
+					}
+					describeCatalog(transporter, serializer, startReplay)
+				})
+				if transporter.JSIssue == "" {
+					ginkgo.Describe("files", func() {
+						describeFiles(transporter, serializer, Files())
+					})
+				}
+			})
+		}
+	})
+}
+
+// describeNoJS registers a single skipped spec for the catalog and the files of the transporters over
+// which a JS node and a Go node cannot meet.
+func describeNoJS(transporter Transporter) {
+	ginkgo.It("no JS node", func() {
+		ginkgo.Skip(fmt.Sprintf("the catalog and the files are not tested over %s: %s", transporter.Name, transporter.JSIssue))
+	})
+}
+
+// describeNoReplay registers a single skipped spec for the transporters that cannot carry a replay peer.
+func describeNoReplay(transporter Transporter) {
+	ginkgo.It("no replay peer", func() {
+		ginkgo.Skip(fmt.Sprintf("the %s transporter of moleculer-go cannot carry a replay peer, the catalog replay is not tested", transporter.Name))
+	})
+}
+
+// newBroker creates the Go broker of a spec. When packets is not nil its packets are recorded.
+func newBroker(transporter Transporter, packets *harness.PacketRecorder) *broker.ServiceBroker {
+	config := &moleculer.Config{
+		LogLevel: "WARN",
+		DiscoverNodeID: func() string {
+			return transporter.GoNode()
+		},
+	}
+	transporter.Configure(config, packets)
+	return broker.New(config)
+}
+
+// recordPackets starts the packet recording of the current spec, see harness.PacketRecorder.
+func recordPackets(transporter Transporter) *harness.PacketRecorder {
+	packets, err := harness.NewPacketRecorder(transporter.Name, harness.PacketFile(ginkgo.CurrentGinkgoTestDescription().FullTestText))
+	Expect(err).ShouldNot(HaveOccurred())
+	return packets
+}
+
+// keepPacketsOnFailure ends the packet recording of the current spec and keeps the file only if the spec failed.
+func keepPacketsOnFailure(packets *harness.PacketRecorder) {
+	if packets == nil {
+		return
+	}
+	failed := ginkgo.CurrentGinkgoTestDescription().Failed
+	if err := packets.Close(failed); err != nil {
+		fmt.Fprintln(ginkgo.GinkgoWriter, "could not close the packet recording:", err)
+	}
+	if failed {
+		fmt.Fprintln(ginkgo.GinkgoWriter, "packets recorded in", packets.Path())
+	}
+}
+
+// startJS starts services.js as the JS node.
+func startJS(transporter Transporter, serializer Serializer) (Node, error) {
+	peer, err := harness.StartNode(Dir(), "services.js", map[string]string{"NODE_ID": transporter.JSNode()}, transporter.JS, serializer.Name)
+	if err != nil {
+		return nil, err
+	}
+	return peer, nil
+}
+
+// startReplay starts a replay peer scripted like services.js as the JS node, with the INFO of
+// testdata/services.js.jsonl.
+// The peer uses the transports of moleculer-go, so it only speaks JSON.
+func startReplay(transporter Transporter, serializer Serializer) (Node, error) {
+	recording, err := harness.LoadRecording(filepath.Join(Dir(), "testdata", "services.js.jsonl"), JSNodeID)
+	if err != nil {
+		return nil, err
+	}
+	peer := harness.NewReplayPeer(JSNodeID, transporter.Transport(JSNodeID), recording.Info)
+	ReplayServices(peer)
+	return peer, peer.Start()
+}
+
+func describeCatalog(transporter Transporter, serializer Serializer, start func(Transporter, Serializer) (Node, error)) {
+	env := &Env{Transporter: transporter, Serializer: serializer}
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		env.JS, err = start(transporter, serializer)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		env.Packets = recordPackets(transporter)
+		env.Broker = newBroker(transporter, env.Packets)
+		env.User = NewUserService()
+		env.Broker.Publish(env.User)
+		env.Broker.Start()
+
+		Expect(harness.WaitForServices(env.Broker, time.Now().Add(harness.StartTimeout), "profile", "account")).Should(Succeed())
+		// moleculer-go announces the services published after start only once its own start INFO
+		// is out: wait until the JS side has discovered the Go services.
+		Expect(harness.WaitForServicesIn(env.Broker, time.Now().Add(harness.StartTimeout), "profile.listServices", "user")).Should(Succeed())
+	})
+
+	ginkgo.AfterEach(func() {
+		if env.Broker != nil {
+			env.Broker.Stop()
+			env.Broker = nil
+		}
+		if env.JS != nil {
+			env.JS.Kill()
+			env.JS = nil
+		}
+		keepPacketsOnFailure(env.Packets)
+	})
+
+	for _, item := range Catalog {
+		scenario := item
+		ginkgo.It(scenario.Name, func() {
+			scenario.Run(env)
+		})
+	}
+}
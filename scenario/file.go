@@ -0,0 +1,246 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// File is a declarative scenario: the services to create on each side and the steps to perform
+// from the test, with their expectations. Files are YAML or JSON, e.g. files/profile.metarepeat.yaml.
+type File struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Services    Services `json:"services"`
+	Steps       []Step   `json:"steps"`
+	Path        string   `json:"-"`
+}
+
+// Services lists the services created on the JS peer and on the Go broker.
+// The name "scenario" is reserved for the control service of the JS runner.
+type Services struct {
+	JS []ServiceDef `json:"js"`
+	Go []ServiceDef `json:"go"`
+}
+
+// ServiceDef is a service whose actions and event handlers are lists of operations.
+type ServiceDef struct {
+	Name    string          `json:"name"`
+	Actions map[string][]Op `json:"actions"`
+	Events  map[string][]Op `json:"events"`
+}
+
+// Op is one operation of an action or event handler. Exactly one of Call, Emit, Broadcast,
+// Return, Throw and Panic is set. Values may refer to ${params}, ${meta} and to the
+// ${<as>.result} and ${<as>.error} of previous calls.
+type Op struct {
+	// Call calls an action with Params and Meta. When As is set the result or error is
+	// captured under that name, otherwise an error is propagated to the caller.
+	Call   string      `json:"call"`
+	Params interface{} `json:"params"`
+	Meta   interface{} `json:"meta"`
+	As     string      `json:"as"`
+
+	// Emit and Broadcast send an event with Payload.
+	Emit      string      `json:"emit"`
+	Broadcast string      `json:"broadcast"`
+	Payload   interface{} `json:"payload"`
+
+	// Return ends the handler with a result.
+	Return json.RawMessage `json:"return"`
+	// Throw ends the handler with an error message.
+	Throw string `json:"throw"`
+	// Panic panics with a message (Go only, JS throws instead).
+	Panic string `json:"panic"`
+}
+
+// Step is something the test does, from the Go broker (default) or through the JS runner.
+type Step struct {
+	Call      string      `json:"call"`
+	Emit      string      `json:"emit"`
+	Broadcast string      `json:"broadcast"`
+	From      string      `json:"from"`
+	Params    interface{} `json:"params"`
+	Meta      interface{} `json:"meta"`
+	Payload   interface{} `json:"payload"`
+	Expect    Expectation `json:"expect"`
+}
+
+// Expectation is checked after a step. Maps are matched partially: the actual value may have more keys.
+type Expectation struct {
+	// Result is the expected result of a call.
+	Result json.RawMessage `json:"result"`
+	// Error is the expected error message of a call.
+	Error *string `json:"error"`
+	// Received lists the action calls and events that the declared services must have handled during the step.
+	Received []Received `json:"received"`
+}
+
+// Received is an action call or event handled by a declared service.
+type Received struct {
+	Action  string      `json:"action"`
+	Event   string      `json:"event"`
+	On      string      `json:"on"`
+	Params  interface{} `json:"params"`
+	Meta    interface{} `json:"meta"`
+	Payload interface{} `json:"payload"`
+}
+
+// String describes the step in failure messages.
+func (step Step) String() string {
+	from := step.From
+	if from == "" {
+		from = "go"
+	}
+	switch {
+	case step.Call != "":
+		return fmt.Sprint("call ", step.Call, " from ", from)
+	case step.Emit != "":
+		return fmt.Sprint("emit ", step.Emit, " from ", from)
+	}
+	return fmt.Sprint("broadcast ", step.Broadcast, " from ", from)
+}
+
+// normalize converts the map[interface{}]interface{} values of yaml.v2 to map[string]interface{}.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for index, item := range v {
+			result[index] = normalize(item)
+		}
+		return result
+	}
+	return value
+}
+
+// ParseFile parses the content of a YAML or JSON scenario file.
+func ParseFile(content []byte) (*File, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(normalize(raw))
+	if err != nil {
+		return nil, err
+	}
+	file := &File{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return nil, err
+	}
+	return file, file.validate()
+}
+
+// LoadFile reads and parses a scenario file.
+func LoadFile(path string) (*File, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := ParseFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	file.Path = path
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return file, nil
+}
+
+// Files returns the paths of the scenario files shipped in the files directory.
+func Files() []string {
+	paths := []string{}
+	for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+		matches, _ := filepath.Glob(filepath.Join(Dir(), "files", pattern))
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (op Op) kinds() []string {
+	kinds := []string{}
+	for kind, set := range map[string]bool{
+		"call":      op.Call != "",
+		"emit":      op.Emit != "",
+		"broadcast": op.Broadcast != "",
+		"return":    len(op.Return) > 0,
+		"throw":     op.Throw != "",
+		"panic":     op.Panic != "",
+	} {
+		if set {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func validateOps(where string, ops []Op) error {
+	for index, op := range ops {
+		if kinds := op.kinds(); len(kinds) != 1 {
+			return fmt.Errorf("%s operation %d must have exactly one of call, emit, broadcast, return, throw or panic, has %v", where, index, kinds)
+		}
+	}
+	return nil
+}
+
+func validateServices(side string, services []ServiceDef) error {
+	for _, service := range services {
+		if service.Name == "" || service.Name == "scenario" {
+			return fmt.Errorf("invalid %s service name %q", side, service.Name)
+		}
+		for name, ops := range service.Actions {
+			if err := validateOps(side+" action "+service.Name+"."+name, ops); err != nil {
+				return err
+			}
+		}
+		for name, ops := range service.Events {
+			if err := validateOps(side+" event "+name+" of "+service.Name, ops); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (file *File) validate() error {
+	if err := validateServices("js", file.Services.JS); err != nil {
+		return err
+	}
+	if err := validateServices("go", file.Services.Go); err != nil {
+		return err
+	}
+	for index, step := range file.Steps {
+		set := 0
+		for _, name := range []string{step.Call, step.Emit, step.Broadcast} {
+			if name != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("step %d must have exactly one of call, emit or broadcast", index)
+		}
+		if step.From != "" && step.From != "go" && step.From != "js" {
+			return fmt.Errorf("step %d: from must be go or js, got %q", index, step.From)
+		}
+		for _, received := range step.Expect.Received {
+			if (received.Action == "") == (received.Event == "") {
+				return fmt.Errorf("step %d: a received expectation needs exactly one of action or event", index)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package scenario
+
+import (
+	"path/filepath"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Scenario files", func() {
+	ginkgo.It("should load the shipped scenario files", func() {
+		paths := Files()
+		Expect(paths).ShouldNot(BeEmpty())
+		for _, path := range paths {
+			file, err := LoadFile(path)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(file.Steps).ShouldNot(BeEmpty(), filepath.Base(path))
+		}
+	})
+
+	ginkgo.It("should parse services, operations and expectations", func() {
+		file, err := LoadFile(filepath.Join(Dir(), "files", "profile.mistake.yaml"))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(file.Name).Should(Equal("profile.mistake"))
+		Expect(serviceNames(file.Services.Go)).Should(Equal([]string{"user"}))
+		Expect(serviceNames(file.Services.JS)).Should(Equal([]string{"profile"}))
+
+		mistake := file.Services.JS[0].Actions["mistake"]
+		Expect(mistake).Should(HaveLen(3))
+		Expect(mistake[0].Call).Should(Equal("user.panix"))
+		Expect(mistake[0].Meta).Should(Equal(map[string]interface{}{"name": "John", "sword": "Valyrian Steel"}))
+		Expect(mistake[0].As).Should(Equal("panix"))
+
+		step := file.Steps[0]
+		Expect(step.Params).Should(Equal(true))
+		Expect(*step.Expect.Error).Should(HavePrefix("Error from JS side!"))
+		Expect(step.Expect.Received).Should(HaveLen(2))
+	})
+
+	ginkgo.It("should accept JSON files", func() {
+		file, err := ParseFile([]byte(`{"steps": [{"emit": "user.created", "payload": {"id": 1}}]}`))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(file.Steps[0].Emit).Should(Equal("user.created"))
+		Expect(file.Steps[0].Payload).Should(Equal(map[string]interface{}{"id": float64(1)}))
+	})
+
+	ginkgo.It("should reject ambiguous operations and steps", func() {
+		_, err := ParseFile([]byte(`
+services:
+  go:
+    - name: user
+      actions:
+        get:
+          - { throw: boom, panic: boom }
+`))
+		Expect(err).Should(MatchError(ContainSubstring("go action user.get operation 0")))
+
+		_, err = ParseFile([]byte(`steps: [{ call: user.get, emit: user.got }]`))
+		Expect(err).Should(MatchError(ContainSubstring("step 0")))
+
+		_, err = ParseFile([]byte(`steps: [{ call: user.get, from: java }]`))
+		Expect(err).Should(MatchError(ContainSubstring("from must be go or js")))
+
+		_, err = ParseFile([]byte(`services: { js: [{ name: scenario }] }`))
+		Expect(err).Should(MatchError(ContainSubstring("invalid js service name")))
+	})
+})
@@ -1,14 +1,18 @@
-package nats
+package scenario
 
 import (
-	"errors"
-
 	"github.com/moleculer-go/moleculer"
 )
 
+// UserService is the Go side of the scenarios. It calls user.update when JS emits profile.created.
 type UserService struct {
-	profileCreated chan bool
-	OnPanix        func(moleculer.Context)
+	// ProfileCreated receives a value every time a profile.created event has been handled.
+	ProfileCreated chan bool
+}
+
+// NewUserService creates a UserService ready to be published.
+func NewUserService() *UserService {
+	return &UserService{ProfileCreated: make(chan bool, 1)}
 }
 
 func (s *UserService) Name() string {
@@ -36,18 +40,6 @@ func (s *UserService) Update(ctx moleculer.Context, user moleculer.Payload) mole
 	return user
 }
 
-func (s *UserService) Panix(ctx moleculer.Context, params moleculer.Payload) moleculer.Payload {
-	ctx.Logger().Info("user.panix called! ")
-	s.OnPanix(ctx)
-
-	panic("this action will panic!")
-}
-
-func (s *UserService) Fail(ctx moleculer.Context) interface{} {
-	ctx.Logger().Info("user.fail called! ")
-	return errors.New("this actions returns an error!")
-}
-
 func (s *UserService) Events() []moleculer.Event {
 	return []moleculer.Event{
 		{
@@ -68,7 +60,35 @@ func (s *UserService) Events() []moleculer.Event {
 				ctx.Logger().Info("user updated with profile Id :) ")
 
 				go func() {
-					s.profileCreated <- true
+					s.ProfileCreated <- true
+				}()
+			},
+		},
+	}
+}
+
+// NotifierSvc is published by Go during a scenario and listens to profile.finished from JS.
+type NotifierSvc struct {
+	// Received receives a value for every profile.finished event.
+	Received chan bool
+}
+
+// NewNotifierSvc creates a NotifierSvc ready to be published.
+func NewNotifierSvc() *NotifierSvc {
+	return &NotifierSvc{Received: make(chan bool, 1)}
+}
+
+func (s *NotifierSvc) Name() string {
+	return "notifier"
+}
+
+func (s *NotifierSvc) Events() []moleculer.Event {
+	return []moleculer.Event{
+		{
+			Name: "profile.finished",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+				go func() {
+					s.Received <- true
 				}()
 			},
 		},
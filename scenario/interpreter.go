@@ -0,0 +1,263 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/payload"
+)
+
+var reference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// lookup follows a dotted path such as panix.error in vars.
+func lookup(vars map[string]interface{}, path string) interface{} {
+	var value interface{} = vars
+	for _, key := range strings.Split(path, ".") {
+		object, isMap := value.(map[string]interface{})
+		if !isMap {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// resolve replaces the ${path} references of value. A string that is a single reference is
+// replaced by the referenced value, other references are interpolated as text (JSON for non strings).
+// services.js implements the same rules.
+func resolve(value interface{}, vars map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := reference.FindStringSubmatch(v); match != nil && match[0] == v {
+			return lookup(vars, match[1])
+		}
+		return reference.ReplaceAllStringFunc(v, func(ref string) string {
+			return text(lookup(vars, reference.FindStringSubmatch(ref)[1]))
+		})
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = resolve(item, vars)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for index, item := range v {
+			result[index] = resolve(item, vars)
+		}
+		return result
+	}
+	return value
+}
+
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+// plain converts a value to its JSON form (float64 numbers, map[string]interface{} objects)
+// so values from Go and from the wire compare equal.
+func plain(value interface{}) interface{} {
+	if p, isPayload := value.(moleculer.Payload); isPayload {
+		value = p.Value()
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var result interface{}
+	if json.Unmarshal(content, &result) != nil {
+		return value
+	}
+	return result
+}
+
+// match compares an expected value with an actual one. Expected maps match when each of their
+// keys matches, other values must be equal. It returns a description of the first difference.
+func match(path string, expected, actual interface{}) error {
+	expected, actual = plain(expected), plain(actual)
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, isMap := actual.(map[string]interface{})
+		if !isMap {
+			return fmt.Errorf("%s: expected an object, got %s", path, text(actual))
+		}
+		keys := []string{}
+		for key := range e {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, exists := a[key]; !exists {
+				return fmt.Errorf("%s.%s: missing, expected %s", path, key, text(e[key]))
+			}
+			if err := match(path+"."+key, e[key], a[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		a, isArray := actual.([]interface{})
+		if !isArray || len(a) != len(e) {
+			return fmt.Errorf("%s: expected %s, got %s", path, text(expected), text(actual))
+		}
+		for index := range e {
+			if err := match(fmt.Sprint(path, "[", index, "]"), e[index], a[index]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		return fmt.Errorf("%s: expected %s, got %s", path, text(expected), text(actual))
+	}
+	return nil
+}
+
+// Record is an action call or event handled by a declared service, on the go or js side.
+type Record struct {
+	Kind    string      `json:"kind"`
+	Name    string      `json:"name"`
+	On      string      `json:"on"`
+	Params  interface{} `json:"params"`
+	Meta    interface{} `json:"meta"`
+	Payload interface{} `json:"payload"`
+}
+
+// recorder keeps the records of the Go services of a scenario file.
+type recorder struct {
+	mutex   sync.Mutex
+	records []Record
+}
+
+func (r *recorder) add(record Record) {
+	r.mutex.Lock()
+	r.records = append(r.records, record)
+	r.mutex.Unlock()
+}
+
+func (r *recorder) list() []Record {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Record{}, r.records...)
+}
+
+func (r *recorder) reset() {
+	r.mutex.Lock()
+	r.records = nil
+	r.mutex.Unlock()
+}
+
+// matches checks a record against a received expectation.
+func (received Received) matches(record Record) error {
+	kind, name := "action", received.Action
+	if received.Event != "" {
+		kind, name = "event", received.Event
+	}
+	if record.Kind != kind || record.Name != name || (received.On != "" && received.On != record.On) {
+		return fmt.Errorf("not %s %s", kind, name)
+	}
+	for _, field := range []struct {
+		name             string
+		expected, actual interface{}
+	}{
+		{"params", received.Params, record.Params},
+		{"meta", received.Meta, record.Meta},
+		{"payload", received.Payload, record.Payload},
+	} {
+		if field.expected == nil {
+			continue
+		}
+		if err := match(field.name, field.expected, field.actual); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// capture is the value of a call captured with as.
+func capture(result moleculer.Payload) map[string]interface{} {
+	if result.IsError() {
+		return map[string]interface{}{"result": nil, "error": result.Error().Error()}
+	}
+	return map[string]interface{}{"result": plain(result), "error": ""}
+}
+
+func metaOptions(meta interface{}) []moleculer.Options {
+	if meta == nil {
+		return nil
+	}
+	return []moleculer.Options{{Meta: payload.New(meta)}}
+}
+
+// runOps executes the operations of a Go handler and returns its result, an error or panics.
+func runOps(ctx moleculer.Context, ops []Op, vars map[string]interface{}) interface{} {
+	for _, op := range ops {
+		switch {
+		case op.Call != "":
+			result := <-ctx.Call(op.Call, resolve(op.Params, vars), metaOptions(resolve(op.Meta, vars))...)
+			if op.As == "" && result.IsError() {
+				return result.Error()
+			}
+			if op.As != "" {
+				vars[op.As] = capture(result)
+			}
+		case op.Emit != "":
+			ctx.Emit(op.Emit, resolve(op.Payload, vars))
+		case op.Broadcast != "":
+			ctx.Broadcast(op.Broadcast, resolve(op.Payload, vars))
+		case len(op.Return) > 0:
+			var value interface{}
+			if err := json.Unmarshal(op.Return, &value); err != nil {
+				return err
+			}
+			return resolve(value, vars)
+		case op.Throw != "":
+			return errors.New(text(resolve(op.Throw, vars)))
+		case op.Panic != "":
+			panic(text(resolve(op.Panic, vars)))
+		}
+	}
+	return nil
+}
+
+// goService creates the Go service of a definition. Every call and event is recorded before its operations run.
+func goService(def ServiceDef, records *recorder) moleculer.ServiceSchema {
+	schema := moleculer.ServiceSchema{Name: def.Name}
+	for name, ops := range def.Actions {
+		actionName, actionOps := def.Name+"."+name, ops
+		schema.Actions = append(schema.Actions, moleculer.Action{
+			Name: name,
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				vars := map[string]interface{}{"params": plain(params), "meta": plain(ctx.Meta())}
+				records.add(Record{Kind: "action", Name: actionName, On: "go", Params: vars["params"], Meta: vars["meta"]})
+				return runOps(ctx, actionOps, vars)
+			},
+		})
+	}
+	for name, ops := range def.Events {
+		eventName, eventOps := name, ops
+		schema.Events = append(schema.Events, moleculer.Event{
+			Name: name,
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+				vars := map[string]interface{}{"params": plain(params), "meta": plain(ctx.Meta())}
+				records.add(Record{Kind: "event", Name: eventName, On: "go", Payload: vars["params"], Meta: vars["meta"]})
+				runOps(ctx, eventOps, vars)
+			},
+		})
+	}
+	return schema
+}
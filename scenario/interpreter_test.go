@@ -0,0 +1,128 @@
+package scenario
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/payload"
+	"github.com/moleculer-go/moleculer/transit/memory"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func memoryBroker(nodeID string, mem *memory.SharedMemory) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := memory.Create(log.WithField("transport", "memory"), mem)
+			return &transport
+		},
+	})
+}
+
+var _ = ginkgo.Describe("Scenario interpreter", func() {
+	vars := map[string]interface{}{
+		"params": map[string]interface{}{"id": 10, "tags": []interface{}{"a"}},
+		"panix":  map[string]interface{}{"error": "boom"},
+	}
+
+	ginkgo.It("should resolve references", func() {
+		Expect(resolve("${params}", vars)).Should(Equal(vars["params"]))
+		Expect(resolve("${params.id}", vars)).Should(Equal(10))
+		Expect(resolve("error: [${panix.error}] id: ${params.id} tags: ${params.tags}", vars)).Should(Equal(`error: [boom] id: 10 tags: ["a"]`))
+		Expect(resolve("[${missing.value}]", vars)).Should(Equal("[]"))
+		Expect(resolve(map[string]interface{}{"list": []interface{}{"${params.id}"}}, vars)).Should(Equal(
+			map[string]interface{}{"list": []interface{}{10}}))
+	})
+
+	ginkgo.It("should match maps partially and other values exactly", func() {
+		actual := map[string]interface{}{"meta": map[string]interface{}{"country": "NZ", "extra": 1}, "list": []interface{}{1, 2}}
+		Expect(match("result", map[string]interface{}{"meta": map[string]interface{}{"country": "NZ"}}, actual)).Should(Succeed())
+		Expect(match("result", map[string]interface{}{"list": []interface{}{1.0, 2.0}}, actual)).Should(Succeed())
+		Expect(match("result", map[string]interface{}{"list": []interface{}{1}}, actual)).Should(MatchError(ContainSubstring("result.list")))
+		Expect(match("result", map[string]interface{}{"meta": map[string]interface{}{"cached": "maybe"}}, actual)).Should(
+			MatchError("result.meta.cached: missing, expected maybe"))
+		Expect(match("result", "1", 1)).Should(HaveOccurred())
+	})
+
+	ginkgo.Describe("Go services", func() {
+		var local, remote *broker.ServiceBroker
+		var records *recorder
+
+		ginkgo.BeforeEach(func() {
+			file, err := ParseFile([]byte(`
+services:
+  go:
+    - name: user
+      actions:
+        panix:
+          - panic: this action will panic!
+        fail:
+          - throw: this actions returns an error!
+        create:
+          - emit: user.created
+            payload: ${params}
+          - return: { created: "${params.id}" }
+    - name: profile
+      actions:
+        mistake:
+          - call: user.panix
+            meta: { name: John }
+            as: panix
+          - call: user.fail
+            as: fail
+          - throw: "panixError: [${panix.error}] failError: [${fail.error}]"
+      events:
+        user.created: []
+`))
+			Expect(err).ShouldNot(HaveOccurred())
+			records = &recorder{}
+			mem := &memory.SharedMemory{}
+			local = memoryBroker("local-node", mem)
+			remote = memoryBroker("remote-node", mem)
+			remote.Publish(goService(file.Services.Go[0], records))
+			local.Publish(goService(file.Services.Go[1], records))
+			local.Start()
+			remote.Start()
+			Expect(harness.WaitForServices(local, time.Now().Add(5*time.Second), "user")).Should(Succeed())
+		})
+
+		ginkgo.AfterEach(func() {
+			local.Stop()
+			remote.Stop()
+		})
+
+		ginkgo.It("should capture the errors of calls and throw interpolated messages", func() {
+			r := <-local.Call("profile.mistake", true)
+			Expect(r.IsError()).Should(BeTrue())
+			Expect(r.Error().Error()).Should(Equal("panixError: [this action will panic!] failError: [this actions returns an error!]"))
+
+			err := Received{Action: "user.panix", On: "go", Meta: map[string]interface{}{"name": "John"}}.matches(records.list()[1])
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		ginkgo.It("should return results and record events", func() {
+			r := <-local.Call("user.create", map[string]interface{}{"id": 7}, moleculer.Options{Meta: payload.Empty().Add("source", "test")})
+			Expect(r.Error()).Should(BeNil())
+			Expect(r.Get("created").Int()).Should(Equal(7))
+
+			received := Received{Event: "user.created", On: "go", Payload: map[string]interface{}{"id": 7}, Meta: map[string]interface{}{"source": "test"}}
+			Eventually(func() error {
+				for _, record := range records.list() {
+					if received.matches(record) == nil {
+						return nil
+					}
+				}
+				return fmt.Errorf("user.created not received: %v", records.list())
+			}).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package scenario
+
+import (
+	"errors"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+)
+
+// FinishDelay is how long profile.finish waits before the node exits, as in services.js.
+var FinishDelay = 500 * time.Millisecond
+
+// ReplayServices scripts the actions of services.js on a replay peer. The INFO of the peer is the
+// one in testdata/services.js.jsonl, written like the INFO of services.js, not captured from it.
+func ReplayServices(peer *harness.ReplayPeer) {
+	peer.Handle("profile.listServices", func(request moleculer.Payload) (interface{}, error) {
+		return peer.Services(), nil
+	})
+	peer.Handle("profile.relay", func(request moleculer.Payload) (interface{}, error) {
+		params := request.Get("params")
+		if !params.Get("action").Exists() {
+			return nil, errors.New("profile.relay needs an action")
+		}
+		return peer.Call(params.Get("action").String(), params.Get("params").Value(), request.Get("meta").Value())
+	})
+	peer.Handle("profile.create", func(request moleculer.Payload) (interface{}, error) {
+		user := request.Get("params")
+		profile := map[string]interface{}{
+			"user": map[string]interface{}{
+				"id":    user.Get("id").Value(),
+				"name":  user.Get("name").Value(),
+				"email": user.Get("email").Value(),
+			},
+			"type": "web-user",
+		}
+		peer.Emit("profile.created", profile)
+		return profile, nil
+	})
+	peer.Handle("profile.finish", func(request moleculer.Payload) (interface{}, error) {
+		message := "JS side will explode in 500 miliseconds!"
+		peer.Emit("profile.finished", map[string]interface{}{"message": message})
+		// process.exit in services.js runs the exit handler of the JS broker, which sends DISCONNECT.
+		time.AfterFunc(FinishDelay, func() {
+			peer.Stop()
+		})
+		return message, nil
+	})
+	unregister := func(request moleculer.Payload) (interface{}, error) {
+		peer.RemoveService("account")
+		return "account service unregistered", nil
+	}
+	peer.Handle("profile.unregister", unregister)
+	peer.Handle("account.unregister", unregister)
+}
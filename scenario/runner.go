@@ -0,0 +1,195 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer/broker"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// ReceivedTimeout is how long a step waits for the received expectations.
+var ReceivedTimeout = 5 * time.Second
+
+// fileEnv is the state of a declarative scenario: the Go broker with the Go services of the file
+// and the JS peer running runner.js with the JS services of the file.
+type fileEnv struct {
+	file    *File
+	broker  *broker.ServiceBroker
+	js      *harness.Peer
+	records *recorder
+	packets *harness.PacketRecorder
+}
+
+func serviceNames(services []ServiceDef) []string {
+	names := []string{}
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+	return names
+}
+
+// describeFiles registers one container per scenario file, each with one spec running its steps in order.
+func describeFiles(transporter Transporter, serializer Serializer, paths []string) {
+	for _, path := range paths {
+		file, err := LoadFile(path)
+		if err != nil {
+			ginkgo.It(path, func() {
+				ginkgo.Fail(err.Error())
+			})
+			continue
+		}
+		describeFile(transporter, serializer, file)
+	}
+}
+
+func describeFile(transporter Transporter, serializer Serializer, file *File) {
+	ginkgo.Describe(file.Name, func() {
+		env := &fileEnv{file: file}
+
+		ginkgo.BeforeEach(func() {
+			services, err := json.Marshal(file.Services.JS)
+			Expect(err).ShouldNot(HaveOccurred())
+			env.js, err = harness.StartNode(Dir(), "runner.js", map[string]string{
+				"NODE_ID":           transporter.JSNode(),
+				"SCENARIO_SERVICES": string(services),
+			}, transporter.JS, serializer.Name)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			env.packets = recordPackets(transporter)
+			env.broker = newBroker(transporter, env.packets)
+			env.records = &recorder{}
+			for _, def := range file.Services.Go {
+				env.broker.Publish(goService(def, env.records))
+			}
+			env.broker.Start()
+
+			deadline := time.Now().Add(harness.StartTimeout)
+			Expect(harness.WaitForServices(env.broker, deadline, append(serviceNames(file.Services.JS), "scenario")...)).Should(Succeed())
+			if len(file.Services.Go) > 0 {
+				Expect(harness.WaitForServicesIn(env.broker, deadline, "scenario.services", serviceNames(file.Services.Go)...)).Should(Succeed())
+			}
+		})
+
+		ginkgo.AfterEach(func() {
+			if env.broker != nil {
+				env.broker.Stop()
+				env.broker = nil
+			}
+			if env.js != nil {
+				env.js.Kill()
+				env.js = nil
+			}
+			keepPacketsOnFailure(env.packets)
+		})
+
+		ginkgo.It(file.Description, func() {
+			for index, step := range file.Steps {
+				ginkgo.By(fmt.Sprint("step ", index, ": ", step))
+				env.run(step)
+			}
+		})
+	})
+}
+
+// run performs a step and checks its expectations.
+func (env *fileEnv) run(step Step) {
+	env.records.reset()
+	Expect((<-env.broker.Call("scenario.reset", nil)).Error()).Should(BeNil())
+
+	var result interface{}
+	var failure *string
+	if step.From == "js" {
+		response := <-env.broker.Call("scenario.perform", map[string]interface{}{
+			"call":      step.Call,
+			"emit":      step.Emit,
+			"broadcast": step.Broadcast,
+			"params":    step.Params,
+			"meta":      step.Meta,
+			"payload":   step.Payload,
+		})
+		Expect(response.Error()).Should(BeNil(), "scenario.perform failed")
+		result = plain(response.Get("result"))
+		if response.Get("error").Exists() {
+			message := response.Get("error").String()
+			failure = &message
+		}
+	} else if step.Call != "" {
+		response := <-env.broker.Call(step.Call, step.Params, metaOptions(step.Meta)...)
+		if response.IsError() {
+			message := response.Error().Error()
+			failure = &message
+		} else {
+			result = plain(response)
+		}
+	} else if step.Emit != "" {
+		env.broker.Emit(step.Emit, step.Payload)
+	} else {
+		env.broker.Broadcast(step.Broadcast, step.Payload)
+	}
+
+	expect := step.Expect
+	if expect.Error != nil {
+		Expect(failure).ShouldNot(BeNil(), "%s: expected error %q, got result %v", step, *expect.Error, text(result))
+		Expect(*failure).Should(Equal(*expect.Error), "%s: error message", step)
+	} else if step.Call != "" {
+		Expect(failure).Should(BeNil(), "%s: unexpected error", step)
+	}
+	if len(expect.Result) > 0 {
+		var expected interface{}
+		Expect(json.Unmarshal(expect.Result, &expected)).Should(Succeed())
+		Expect(match("result", expected, result)).Should(Succeed(), "%s: result", step)
+	}
+	for _, received := range expect.Received {
+		expected := received
+		Eventually(func() error {
+			return env.findReceived(expected)
+		}, ReceivedTimeout, harness.PollInterval).Should(Succeed(), "%s: received", step)
+	}
+}
+
+// allRecords returns the records of both sides since the start of the step.
+func (env *fileEnv) allRecords() ([]Record, error) {
+	records := env.records.list()
+	response := <-env.broker.Call("scenario.received", nil)
+	if response.IsError() {
+		return nil, response.Error()
+	}
+	content, err := json.Marshal(plain(response))
+	if err != nil {
+		return nil, err
+	}
+	remote := []Record{}
+	if err := json.Unmarshal(content, &remote); err != nil {
+		return nil, err
+	}
+	return append(records, remote...), nil
+}
+
+// findReceived succeeds when a record matches the expectation, or describes the closest records.
+func (env *fileEnv) findReceived(expected Received) error {
+	records, err := env.allRecords()
+	if err != nil {
+		return err
+	}
+	differences := []string{}
+	for _, record := range records {
+		err := expected.matches(record)
+		if err == nil {
+			return nil
+		}
+		if record.Name == expected.Action || record.Name == expected.Event {
+			differences = append(differences, fmt.Sprint(record.Name, " on ", record.On, ": ", err))
+		}
+	}
+	name := expected.Action + expected.Event
+	if len(differences) == 0 {
+		return fmt.Errorf("%s was not received", name)
+	}
+	return fmt.Errorf("%s was received but did not match:\n%s", name, strings.Join(differences, "\n"))
+}
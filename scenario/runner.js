@@ -0,0 +1,141 @@
+"use strict";
+
+// Runs the JS services of a declarative scenario file (see file.go).
+// The services are given as JSON in SCENARIO_SERVICES, the transporter as first argument (a name,
+// a URL or a JSON transporter config) and the serializer as second argument (JSON when missing).
+
+const transporter = process.argv[2].startsWith("{") ? JSON.parse(process.argv[2]) : process.argv[2];
+const serializer = process.argv[3] || "JSON";
+console.log("Start Moleculer JS scenario runner with transporter: " + process.argv[2] + " and serializer: " + serializer);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({ transporter, serializer, nodeID: process.env["NODE_ID"], logLevel: "info" });
+const definitions = JSON.parse(process.env["SCENARIO_SERVICES"] || "[]") || [];
+
+let received = [];
+
+function lookup(vars, path) {
+  return path.split(".").reduce((value, key) => (value !== null && typeof value === "object" ? value[key] : undefined), vars);
+}
+
+function text(value) {
+  if (value === undefined || value === null) {
+    return "";
+  }
+  return typeof value === "string" ? value : JSON.stringify(value);
+}
+
+// Same rules as resolve in interpreter.go.
+function resolve(value, vars) {
+  if (typeof value === "string") {
+    const single = value.match(/^\$\{([^}]+)\}$/);
+    if (single) {
+      return lookup(vars, single[1]);
+    }
+    return value.replace(/\$\{([^}]+)\}/g, (_, path) => text(lookup(vars, path)));
+  }
+  if (Array.isArray(value)) {
+    return value.map(item => resolve(item, vars));
+  }
+  if (value !== null && typeof value === "object") {
+    const result = {};
+    Object.keys(value).forEach(key => { result[key] = resolve(value[key], vars); });
+    return result;
+  }
+  return value;
+}
+
+async function run(ctx, ops, vars) {
+  for (const op of ops || []) {
+    if (op.call) {
+      const opts = op.meta ? { meta: resolve(op.meta, vars) } : {};
+      try {
+        const result = await ctx.call(op.call, resolve(op.params, vars), opts);
+        if (op.as) {
+          vars[op.as] = { result, error: "" };
+        }
+      } catch (e) {
+        if (!op.as) {
+          throw e;
+        }
+        vars[op.as] = { result: null, error: e.message };
+      }
+    } else if (op.emit) {
+      ctx.emit(op.emit, resolve(op.payload, vars));
+    } else if (op.broadcast) {
+      ctx.broadcast(op.broadcast, resolve(op.payload, vars));
+    } else if (op.return !== undefined && op.return !== null) {
+      return resolve(op.return, vars);
+    } else if (op.throw || op.panic) {
+      throw new Error(text(resolve(op.throw || op.panic, vars)));
+    }
+  }
+  return null;
+}
+
+definitions.forEach(definition => {
+  const actions = {};
+  Object.keys(definition.actions || {}).forEach(name => {
+    const fullName = definition.name + "." + name;
+    actions[name] = ctx => {
+      received.push({ kind: "action", name: fullName, on: "js", params: ctx.params, meta: ctx.meta });
+      return run(ctx, definition.actions[name], { params: ctx.params, meta: ctx.meta });
+    };
+  });
+  const events = {};
+  Object.keys(definition.events || {}).forEach(name => {
+    events[name] = ctx => {
+      received.push({ kind: "event", name, on: "js", payload: ctx.params, meta: ctx.meta });
+      return run(ctx, definition.events[name], { params: ctx.params, meta: ctx.meta });
+    };
+  });
+  broker.createService({ name: definition.name, actions, events });
+});
+
+// Control service used by the Go runner.
+broker.createService({
+  name: "scenario",
+  actions: {
+    services(ctx) {
+      return ctx.call("$node.services");
+    },
+
+    received() {
+      return received;
+    },
+
+    reset() {
+      received = [];
+      return true;
+    },
+
+    async perform(ctx) {
+      const { call, emit, broadcast, params, meta, payload } = ctx.params;
+      if (emit) {
+        ctx.emit(emit, payload);
+        return {};
+      }
+      if (broadcast) {
+        ctx.broadcast(broadcast, payload);
+        return {};
+      }
+      try {
+        const result = await ctx.call(call, params, meta ? { meta } : {});
+        return { result: result === undefined ? null : result };
+      } catch (e) {
+        return { error: e.message };
+      }
+    }
+  }
+});
+
+broker.start().then(() => {
+  console.log("Moleculer JS scenario runner started with services: " + definitions.map(d => d.name).join(", "));
+
+  // The harness stops this process after each scenario.
+  // Keep a fallback timeout in case the test process dies first.
+  setTimeout(() => {
+    broker.stop().then(() => process.exit(0)).catch(() => process.exit(1));
+  }, 60000);
+});
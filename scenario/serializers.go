@@ -0,0 +1,39 @@
+package scenario
+
+import (
+	"fmt"
+
+	"github.com/onsi/ginkgo"
+)
+
+// Serializer is a row of the serializer matrix: how both sides encode the packets.
+type Serializer struct {
+	// Name is used in the spec descriptions and is the serializer option of the JS ServiceBroker, e.g. "MsgPack".
+	Name string
+	// Go is true when moleculer-go implements the serializer. moleculer.Config has no serializer
+	// option in moleculer-go v0.3.10: its brokers and transports always use JSON.
+	Go bool
+}
+
+// JSON is the default serializer of moleculer JS and the only one of moleculer-go.
+var JSON = Serializer{Name: "JSON", Go: true}
+
+// Serializers returns the serializers of moleculer JS, the ones moleculer-go does not implement included.
+func Serializers() []Serializer {
+	return []Serializer{
+		JSON,
+		{Name: "MsgPack"},
+		{Name: "Notepack"},
+		{Name: "CBOR"},
+		{Name: "ProtoBuf"},
+		{Name: "Avro"},
+	}
+}
+
+// describeNotImplemented registers the cell of a serializer that moleculer-go does not implement,
+// so that it shows up as skipped instead of missing.
+func describeNotImplemented(transporter Transporter, serializer Serializer) {
+	ginkgo.It("not implemented in Go", func() {
+		ginkgo.Skip(fmt.Sprintf("the %s serializer is not implemented in moleculer-go, %s ↔ %s is not tested", serializer.Name, transporter.Name, serializer.Name))
+	})
+}
@@ -0,0 +1,87 @@
+"use strict";
+
+// The transporter is a name, a URL or a JSON transporter config, e.g. the TCP options with a free UDP port.
+const transporter = process.argv[2].startsWith("{") ? JSON.parse(process.argv[2]) : process.argv[2];
+const serializer = process.argv[3] || "JSON";
+console.log("Start Moleculer JS with transporter: " + process.argv[2] + " and serializer: " + serializer);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({ transporter, serializer, nodeID: process.env["NODE_ID"], logLevel: "trace"});
+
+let looper = false;
+
+broker.createService({
+  name: "profile",
+  actions: {
+
+    listServices(ctx) {
+      return ctx.call("$node.services");
+    },
+
+    // relay calls another action from the JS side, e.g. a Go action.
+    relay(ctx) {
+      const { action, params } = ctx.params;
+      console.log("[moleculer-JS] profile.relay calling: ", action);
+      return ctx.call(action, params, { meta: ctx.meta });
+    },
+
+    create(ctx) {
+      const user = ctx.params;
+      console.log("[moleculer-JS] profile.create action user: ", user);
+      const { id, name, email } = user;
+      const profile = { user: { id, name, email }, type: "web-user" };
+      ctx.emit("profile.created", profile);
+
+      setInterval(_ => {
+        if (looper) {
+          ctx.broadcast("profile.loopevent", { name: "loop" });
+        }
+      }, 2000);
+
+      return profile;
+    },
+
+    async finish(ctx) {
+      console.log("[moleculer-JS] profile.finish called with: ", ctx.params);
+      ctx.emit("profile.finished", { message: "JS side will explode in 500 miliseconds!" });
+      setTimeout(_ => {
+        process.exit(0);
+      }, 500);
+      return "JS side will explode in 500 miliseconds!";
+    },
+
+    async unregister(ctx) {
+      console.log("[moleculer-JS] profile.unregister called");
+      broker.destroyService("account");
+      return "account service unregistered";
+    }
+  }
+});
+
+broker.createService({
+  name: "account",
+  actions: {
+    unregister(ctx) {
+      console.log("[moleculer-JS] account.unregister called");
+      broker.destroyService("account");
+      return "account service unregistered";
+    }
+  }
+});
+
+broker.start().then(() => {
+  console.log("Moleculer JS broker started with transporter: " + process.argv[2]);
+
+  // The harness stops this process after each scenario.
+  // Keep a fallback timeout in case the test process dies first.
+  setTimeout(() => {
+    console.log("JS Broker stopped by fallback timeout");
+    broker.stop().then(() => {
+      process.exit(0);
+    }).catch(err => {
+      console.error("Error stopping broker:", err);
+      process.exit(1);
+    });
+  }, 60000);
+});
@@ -0,0 +1,182 @@
+package scenario
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/serializer"
+	"github.com/moleculer-go/moleculer/transit"
+	"github.com/moleculer-go/moleculer/transit/redis"
+	"github.com/moleculer-go/moleculer/transit/tcp"
+	log "github.com/sirupsen/logrus"
+)
+
+// Transporter is a column of the scenario table: how the JS peer and the Go broker connect to each other.
+type Transporter struct {
+	// Name is used in the spec descriptions, e.g. "NATS".
+	Name string
+	// JS is the transporter argument given to services.js.
+	JS string
+	// Transport creates the transport of a Go node: the Go broker, or the replay peer that stands in for JS.
+	Transport func(nodeID string) transit.Transport
+	// Replay is true when a harness.ReplayPeer can stand in for the JS peer, see startReplay.
+	Replay bool
+	// JSIssue is why a JS node and a Go node cannot meet over the transporter, empty when they can.
+	// The catalog and the files against services.js are then a single skipped spec.
+	JSIssue string
+	// NodePrefix is put in front of GoNodeID and JSNodeID, so that the suites sharing a
+	// discovery do not mistake each other's nodes for theirs.
+	NodePrefix string
+}
+
+// GoNode returns the nodeID of the Go broker on this transporter.
+func (transporter Transporter) GoNode() string {
+	return transporter.NodePrefix + GoNodeID
+}
+
+// JSNode returns the nodeID of the JS peer on this transporter.
+func (transporter Transporter) JSNode() string {
+	return transporter.NodePrefix + JSNodeID
+}
+
+// Configure sets the transporter of the Go broker. When packets is not nil, the packets
+// sent and received by the Go broker are recorded.
+func (transporter Transporter) Configure(config *moleculer.Config, packets *harness.PacketRecorder) {
+	nodeID := transporter.GoNode()
+	if config.DiscoverNodeID != nil {
+		nodeID = config.DiscoverNodeID()
+	}
+	config.TransporterFactory = func() interface{} {
+		transport := transporter.Transport(nodeID)
+		if packets != nil {
+			return packets.Wrap(transport)
+		}
+		return transport
+	}
+}
+
+func transportLogger(name string) *log.Entry {
+	return log.WithField("transport", name)
+}
+
+// jsonSerializer is the serializer of the Go transports. The transports created by a
+// TransporterFactory keep the serializer given at creation, and Go only has JSON.
+func jsonSerializer() serializer.Serializer {
+	return serializer.CreateJSONSerializer(log.WithField("serializer", "json"))
+}
+
+// NATS connects both sides to the NATS server at url.
+func NATS(url string) Transporter {
+	return Transporter{
+		Name:   "NATS",
+		JS:     url,
+		Replay: true,
+		Transport: func(nodeID string) transit.Transport {
+			return harness.NATSTransporter(url)()
+		},
+	}
+}
+
+// TCP uses the TCP transporter with UDP discovery on both sides.
+// The options are the defaults of Transporter: "TCP" in moleculer-go, except the UDP port: each
+// call takes a free one, and the nodeIDs start with prefix, so that the suites that run in
+// parallel do not discover each other. The TCP transporter builds its gossip packets from the
+// registry of a broker, so it cannot carry a replay peer.
+func TCP(prefix string) Transporter {
+	port := freeUDPPort()
+	return Transporter{
+		Name:       "TCP",
+		JS:         fmt.Sprintf(`{"type":"TCP","options":{"udpPort":%d}}`, port),
+		NodePrefix: prefix + "-",
+		Transport: func(nodeID string) transit.Transport {
+			return tcp.CreateTCPTransporter(tcp.TCPOptions{
+				UdpDiscovery:          true,
+				UdpReuseAddr:          true,
+				UdpPort:               port,
+				UdpPeriod:             30 * time.Second,
+				WorkerPoolSize:        20,
+				ConnectionTimeout:     30 * time.Second,
+				IdleConnectionTimeout: 60 * time.Second,
+				UdpMulticast:          "239.0.0.0",
+				UdpMulticastTTL:       1,
+				UdpBroadcast:          []string{},
+				Urls:                  []string{},
+				UseHostname:           true,
+				GossipPeriod:          2,
+				MaxConnections:        32,
+				MaxPacketSize:         1024 * 1024,
+				NodeId:                nodeID,
+				Logger:                transportLogger("tcp"),
+				Serializer:            jsonSerializer(),
+			})
+		},
+	}
+}
+
+// GoRedisIssue is why Go and JS nodes never meet over Redis.
+const GoRedisIssue = "moleculer-go v0.3.10 names its Redis channels MOL:INFO:<nodeID>, moleculer JS MOL.INFO.<nodeID>: the Go and JS nodes never discover each other"
+
+// Redis connects both sides to the Redis server at host:port. Only the replay peer meets the Go
+// broker, see GoRedisIssue.
+func Redis(host string, port int) Transporter {
+	return Transporter{
+		Name:    "Redis",
+		JS:      fmt.Sprintf("redis://%s:%d", host, port),
+		Replay:  true,
+		JSIssue: GoRedisIssue,
+		Transport: func(nodeID string) transit.Transport {
+			transport := redis.NewRedisTransporter(&redis.RedisConfig{Host: host, Port: port})
+			transport.SetSerializer(jsonSerializer())
+			return transport
+		},
+	}
+}
+
+// freeUDPPort returns a UDP port that no other process uses.
+func freeUDPPort() int {
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		panic("could not find a free UDP port: " + err.Error())
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func env(name, fallback string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// redisTransporter returns the Redis server at REDIS_HOST and REDIS_PORT, or the embedded Redis server when REDIS_HOST is not set.
+func redisTransporter() Transporter {
+	if host := os.Getenv("REDIS_HOST"); host != "" {
+		port, err := strconv.Atoi(env("REDIS_PORT", "6379"))
+		if err != nil {
+			port = 6379
+		}
+		return Redis(host, port)
+	}
+	server, err := harness.EmbeddedRedis()
+	if err != nil {
+		panic("could not start the embedded Redis server: " + err.Error())
+	}
+	return Redis(server.Host(), server.Port())
+}
+
+// Transporters returns all the transporters: NATS (NATS_HOST, or an embedded server), TCP
+// with the nodeID prefix and Redis (REDIS_HOST and REDIS_PORT, or an embedded server).
+func Transporters(prefix string) []Transporter {
+	return []Transporter{
+		NATS(harness.NATSURL()),
+		TCP(prefix),
+		redisTransporter(),
+	}
+}
@@ -0,0 +1,86 @@
+package scenario
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Transporters", func() {
+	var dir string
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "packets")
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	ginkgo.AfterEach(func() {
+		harness.StopEmbeddedNATS()
+		harness.StopEmbeddedRedis()
+		os.RemoveAll(dir)
+	})
+
+	goBroker := func(nodeID string, transporter Transporter, packets *harness.PacketRecorder) *broker.ServiceBroker {
+		config := &moleculer.Config{
+			LogLevel: "ERROR",
+			DiscoverNodeID: func() string {
+				return nodeID
+			},
+		}
+		transporter.Configure(config, packets)
+		return broker.New(config)
+	}
+
+	connectGoBrokers := func(transporter Transporter) {
+		packets, err := harness.NewPacketRecorder(transporter.Name, filepath.Join(dir, transporter.Name+".jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		defer packets.Close(false)
+
+		local := goBroker("local-node", transporter, packets)
+		remote := goBroker("remote-node", transporter, nil)
+		remote.Publish(NewNotifierSvc(), moleculer.ServiceSchema{
+			Name: "echo",
+			Actions: []moleculer.Action{{
+				Name: "say",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					return params.Value()
+				},
+			}},
+		})
+		local.Start()
+		remote.Start()
+		defer local.Stop()
+		defer remote.Stop()
+
+		Expect(harness.WaitForServices(local, time.Now().Add(10*time.Second), "echo")).Should(Succeed())
+		Expect((<-local.Call("echo.say", "hello")).String()).Should(Equal("hello"))
+
+		types := []string{}
+		for _, packet := range packets.Packets() {
+			types = append(types, packet.Direction+" "+packet.Type)
+		}
+		Expect(types).Should(ContainElement("out REQUEST"))
+		Expect(types).Should(ContainElement("in RESPONSE"))
+	}
+
+	ginkgo.It("should connect Go brokers over NATS", func() {
+		connectGoBrokers(Transporters("scenario")[0])
+	})
+
+	ginkgo.It("should connect Go brokers over Redis", func() {
+		connectGoBrokers(Transporters("scenario")[2])
+	})
+
+	ginkgo.It("should connect Go brokers over TCP", func() {
+		connectGoBrokers(TCP("scenario"))
+	})
+})
@@ -0,0 +1,119 @@
+// Package timeouts checks how call timeouts cross the boundary between moleculer-go and moleculer JS:
+// which timeout a caller applies, the error it gets, the timeout the remote handler sees and what
+// happens to the RESPONSE that arrives after the timeout.
+//
+// moleculer-go has a single timeout, the RequestTimeout of the broker config: moleculer.Options has
+// no per-call timeout. It applies to remote calls only and fails them with errors.New("request timeout").
+// The REQUESTs it sends always have the timeout 0.
+package timeouts
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+const (
+	// GoNodeID is the node of GoService.
+	GoNodeID = "go-node"
+	// JSNodeID is the node of slow.js.
+	JSNodeID = "js-node"
+
+	// GoRequestTimeout is the RequestTimeout of the Go broker of Start.
+	GoRequestTimeout = 2 * time.Second
+	// JSRequestTimeout is the requestTimeout of the broker of slow.js.
+	JSRequestTimeout = time.Second
+
+	// GoTimeoutError is the error of a Go call that times out.
+	GoTimeoutError = "request timeout"
+)
+
+// Timeout returns the timeout in milliseconds of the REQUEST that started ctx, 0 when it has none
+// or ctx was not started by a REQUEST. moleculer-go keeps it in the context but has no accessor for it.
+func Timeout(ctx moleculer.Context) int {
+	brokerContext, isBrokerContext := ctx.(moleculer.BrokerContext)
+	if !isBrokerContext {
+		return 0
+	}
+	timeout, _ := brokerContext.AsMap()["timeout"].(int)
+	return timeout
+}
+
+// GoService has the slow Go actions:
+//   - goslow.sleep waits for the "ms" param in milliseconds and returns it with the Timeout of its context;
+//   - goslow.deadline returns the Timeout of its context.
+var GoService = moleculer.ServiceSchema{
+	Name: "goslow",
+	Actions: []moleculer.Action{
+		{
+			Name: "sleep",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				time.Sleep(time.Duration(params.Get("ms").Int()) * time.Millisecond)
+				return map[string]interface{}{"slept": params.Get("ms").Int(), "timeout": Timeout(ctx)}
+			},
+		},
+		{
+			Name: "deadline",
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+				return Timeout(ctx)
+			},
+		},
+	},
+}
+
+// Slow is a Go broker with GoService and slow.js, connected over NATS.
+type Slow struct {
+	Broker *broker.ServiceBroker
+	JS     *harness.Peer
+}
+
+// Start starts slow.js with JSRequestTimeout and a Go broker with GoService and GoRequestTimeout, both
+// connected to the NATS server at url, and waits until each side sees the service of the other.
+// When packets is not nil, the packets of the Go broker are recorded.
+func Start(dir, url string, packets *harness.PacketRecorder) (*Slow, error) {
+	env := map[string]string{
+		"NODE_ID":         JSNodeID,
+		"REQUEST_TIMEOUT": strconv.Itoa(int(JSRequestTimeout / time.Millisecond)),
+	}
+	js, err := harness.StartNode(dir, "slow.js", env, url)
+	if err != nil {
+		return nil, err
+	}
+	bkr := broker.New(&moleculer.Config{
+		LogLevel:       "WARN",
+		RequestTimeout: GoRequestTimeout,
+		DiscoverNodeID: func() string {
+			return GoNodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := harness.NATSTransporter(url)()
+			if packets != nil {
+				return packets.Wrap(transport)
+			}
+			return transport
+		},
+	})
+	bkr.Publish(GoService)
+	bkr.Start()
+	slow := &Slow{Broker: bkr, JS: js}
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(bkr, deadline, "jsslow"); err != nil {
+		slow.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(bkr, deadline, "jsslow.services", "goslow"); err != nil {
+		slow.Stop()
+		return nil, err
+	}
+	return slow, nil
+}
+
+// Stop stops the Go broker and kills slow.js.
+func (slow *Slow) Stop() {
+	slow.Broker.Stop()
+	slow.JS.Kill()
+}
@@ -0,0 +1,76 @@
+"use strict";
+
+// The JS service of the timeouts suite (see services.go), with the NATS url as first argument and
+// the requestTimeout of the broker in the REQUEST_TIMEOUT environment variable.
+//   jsslow.sleep    waits for the "ms" param in milliseconds and returns it with ctx.options.timeout.
+//   jsslow.deadline returns ctx.options.timeout.
+//   jsslow.callgo   calls the goslow action named by the "action" param with the "params" param and,
+//                   when there is one, the "timeout" param as call option. It returns {data} or
+//                   {error} with the fields of the error.
+//   jsslow.relay    calls jsslow.forward with the "timeout" param as call option, which waits for
+//                   the "delay" param in milliseconds and calls goslow.deadline with what remains.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS slow with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const broker = new ServiceBroker({
+	transporter,
+	nodeID: process.env["NODE_ID"],
+	requestTimeout: Number(process.env["REQUEST_TIMEOUT"] || 0),
+	logLevel: "info"
+});
+
+function sleep(ms) {
+	return new Promise(resolve => setTimeout(resolve, ms));
+}
+
+// fields returns the fields of an error that are defined, without the stack.
+function fields(err) {
+	const result = {};
+	for (const name of ["name", "message", "code", "type", "data", "retryable", "nodeID"]) {
+		if (err[name] !== undefined) {
+			result[name] = err[name];
+		}
+	}
+	return result;
+}
+
+broker.createService({
+	name: "jsslow",
+	actions: {
+		services(ctx) {
+			return ctx.call("$node.services");
+		},
+
+		async sleep(ctx) {
+			await sleep(ctx.params.ms);
+			return { slept: ctx.params.ms, timeout: ctx.options.timeout };
+		},
+
+		deadline(ctx) {
+			return ctx.options.timeout;
+		},
+
+		async callgo(ctx) {
+			const opts = ctx.params.timeout === undefined ? {} : { timeout: ctx.params.timeout };
+			try {
+				return { data: await ctx.call("goslow." + ctx.params.action, ctx.params.params, opts) };
+			} catch (err) {
+				return { error: fields(err) };
+			}
+		},
+
+		relay(ctx) {
+			return ctx.call("jsslow.forward", { delay: ctx.params.delay }, { timeout: ctx.params.timeout });
+		},
+
+		async forward(ctx) {
+			await sleep(ctx.params.delay);
+			return ctx.call("goslow.deadline");
+		}
+	}
+});
+
+broker.start();
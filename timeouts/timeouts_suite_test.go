@@ -0,0 +1,23 @@
+package timeouts
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTimeouts(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Timeouts Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazySlow.Stop()
+	if recorder != nil {
+		recorder.Close(false)
+	}
+	harness.StopEmbeddedNATS()
+})
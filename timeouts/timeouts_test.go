@@ -0,0 +1,299 @@
+package timeouts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// slow is started by lazySlow, recorder records its packets.
+var slow *Slow
+var recorder *harness.PacketRecorder
+
+// lazySlow starts the Go broker and slow.js once, for all the specs that need moleculer JS.
+var lazySlow = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	recorder, err = harness.NewPacketRecorder("NATS", harness.PacketFile("timeouts"))
+	if err != nil {
+		return nil, err
+	}
+	slow, err = Start(".", harness.NATSURL(), recorder)
+	return slow, err
+})
+
+// memoryBroker creates a broker with the given RequestTimeout on the memory transporter shared by
+// the brokers created from mem, recording its packets when packets is not nil.
+func memoryBroker(nodeID string, requestTimeout time.Duration, mem *memory.SharedMemory, packets *harness.PacketRecorder) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel:       "ERROR",
+		RequestTimeout: requestTimeout,
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			transport := harness.MemoryTransporter(mem)()
+			if packets != nil {
+				return packets.Wrap(transport)
+			}
+			return transport
+		},
+	})
+}
+
+// exchange returns the fields of the last REQUEST for action in the recorded packets and of its
+// RESPONSE, nil when it was not recorded (yet).
+func exchange(packets []harness.Packet, action string) (request, response map[string]interface{}) {
+	for _, packet := range packets {
+		fields := plainMap(packet.Payload)
+		if packet.Type == "REQUEST" && fields["action"] == action {
+			request, response = fields, nil
+		} else if request != nil && packet.Type == "RESPONSE" && fields["id"] == request["id"] {
+			response = fields
+		}
+	}
+	return request, response
+}
+
+// plainMap converts a recorded payload to the map it is on the wire, numbers as float64.
+func plainMap(value interface{}) map[string]interface{} {
+	bytes, err := json.Marshal(value)
+	Expect(err).ShouldNot(HaveOccurred())
+	fields := map[string]interface{}{}
+	Expect(json.Unmarshal(bytes, &fields)).Should(Succeed())
+	return fields
+}
+
+var _ = Describe("Go call timeouts", func() {
+	var dir string
+	var packets, served *harness.PacketRecorder
+	var mem *memory.SharedMemory
+	var caller, callee *broker.ServiceBroker
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "timeouts")
+		Expect(err).ShouldNot(HaveOccurred())
+		packets, err = harness.NewPacketRecorder("Memory", filepath.Join(dir, "packets.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+		served, err = harness.NewPacketRecorder("Memory", filepath.Join(dir, "served.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		mem = &memory.SharedMemory{}
+		caller = memoryBroker("caller-node", 200*time.Millisecond, mem, packets)
+		callee = memoryBroker(GoNodeID, time.Second, mem, served)
+		callee.Publish(GoService)
+		callee.Start()
+		caller.Start()
+		Expect(harness.WaitForServices(caller, time.Now().Add(5*time.Second), "goslow")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		caller.Stop()
+		callee.Stop()
+		packets.Close(false)
+		served.Close(false)
+		os.RemoveAll(dir)
+	})
+
+	It("should fail a remote call after the RequestTimeout and discard the late RESPONSE", func() {
+		start := time.Now()
+		result := <-caller.Call("goslow.sleep", map[string]interface{}{"ms": 600})
+		Expect(result.Error()).Should(MatchError(GoTimeoutError))
+		Expect(time.Since(start)).Should(BeNumerically("<", 600*time.Millisecond))
+
+		request, _ := exchange(packets.Packets(), "goslow.sleep")
+		Expect(request).Should(HaveKeyWithValue("timeout", 0.0))
+		Eventually(func() map[string]interface{} {
+			_, response := exchange(packets.Packets(), "goslow.sleep")
+			return response
+		}, 2*time.Second).Should(HaveKeyWithValue("success", true))
+
+		result = <-caller.Call("goslow.sleep", map[string]interface{}{"ms": 10})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("slept").Int()).Should(Equal(10))
+		Expect(result.Get("timeout").Int()).Should(Equal(0))
+	})
+
+	It("should not apply the RequestTimeout to local calls", func() {
+		result := <-callee.Call("goslow.sleep", map[string]interface{}{"ms": 1200})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("slept").Int()).Should(Equal(1200))
+	})
+
+	Context("with a replayed JS caller", func() {
+		var peer *harness.ReplayPeer
+
+		BeforeEach(func() {
+			peer = harness.NewReplayPeer(JSNodeID, harness.MemoryTransporter(mem)(), harness.ReplayInfo(
+				map[string]interface{}{
+					"name":     "jsslow",
+					"fullName": "jsslow",
+					"settings": map[string]interface{}{},
+					"metadata": map[string]interface{}{},
+					"actions": map[string]interface{}{
+						"jsslow.sleep": map[string]interface{}{"rawName": "sleep", "name": "jsslow.sleep"},
+					},
+					"events": map[string]interface{}{},
+				},
+			))
+			peer.Handle("jsslow.sleep", func(request moleculer.Payload) (interface{}, error) {
+				ms := request.Get("params").Get("ms").Int()
+				time.Sleep(time.Duration(ms) * time.Millisecond)
+				return map[string]interface{}{"slept": ms, "timeout": request.Get("timeout").Int()}, nil
+			})
+			Expect(peer.Start()).Should(Succeed())
+			Expect(harness.WaitForServices(caller, time.Now().Add(5*time.Second), "jsslow")).Should(Succeed())
+			Eventually(peer.Services, 5*time.Second).Should(ContainElement(HaveKeyWithValue("name", "goslow")))
+		})
+
+		AfterEach(func() {
+			peer.Kill()
+		})
+
+		It("should let the Go handler read the timeout of the REQUEST", func() {
+			result, err := peer.CallTimeout("goslow.deadline", map[string]interface{}{}, nil, 1500*time.Millisecond)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Int()).Should(Equal(1500))
+
+			result, err = peer.Call("goslow.deadline", map[string]interface{}{}, nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Int()).Should(Equal(0))
+		})
+
+		It("should keep running the Go handler after the JS caller timed out", func() {
+			_, err := peer.CallTimeout("goslow.sleep", map[string]interface{}{"ms": 600}, nil, 200*time.Millisecond)
+			Expect(err).Should(MatchError("Request is timed out when call 'goslow.sleep' action on 'go-node' node."))
+
+			Eventually(func() map[string]interface{} {
+				_, response := exchange(served.Packets(), "goslow.sleep")
+				return response
+			}, 2*time.Second).Should(HaveKeyWithValue("success", true))
+			result, err := peer.CallTimeout("goslow.sleep", map[string]interface{}{"ms": 10}, nil, time.Second)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result.Get("slept").Int()).Should(Equal(10))
+			Expect(result.Get("timeout").Int()).Should(Equal(1000))
+		})
+
+		It("should send the timeout 0 to a slow JS action and discard its late RESPONSE", func() {
+			result := <-caller.Call("jsslow.sleep", map[string]interface{}{"ms": 600})
+			Expect(result.Error()).Should(MatchError(GoTimeoutError))
+
+			Eventually(func() map[string]interface{} {
+				_, response := exchange(packets.Packets(), "jsslow.sleep")
+				return response
+			}, 2*time.Second).Should(HaveKeyWithValue("success", true))
+
+			result = <-caller.Call("jsslow.sleep", map[string]interface{}{"ms": 10})
+			Expect(result.Error()).ShouldNot(HaveOccurred())
+			Expect(result.Get("slept").Int()).Should(Equal(10))
+			Expect(result.Get("timeout").Int()).Should(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("Timeouts with moleculer JS", func() {
+	BeforeEach(lazySlow.Start)
+
+	It("Go → JS should fail after the Go RequestTimeout, JS sees no timeout", func() {
+		start := time.Now()
+		result := <-slow.Broker.Call("jsslow.sleep", map[string]interface{}{"ms": 3000})
+		Expect(result.Error()).Should(MatchError(GoTimeoutError))
+		Expect(time.Since(start)).Should(BeNumerically("~", GoRequestTimeout, 500*time.Millisecond))
+
+		request, _ := exchange(recorder.Packets(), "jsslow.sleep")
+		Expect(request).Should(HaveKeyWithValue("timeout", 0.0))
+
+		result = <-slow.Broker.Call("jsslow.deadline", map[string]interface{}{})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Int()).Should(Equal(0))
+	})
+
+	It("Go → JS should discard the late RESPONSE", func() {
+		result := <-slow.Broker.Call("jsslow.sleep", map[string]interface{}{"ms": 2500})
+		Expect(result.Error()).Should(MatchError(GoTimeoutError))
+		Eventually(func() map[string]interface{} {
+			_, response := exchange(recorder.Packets(), "jsslow.sleep")
+			return response
+		}, 3*time.Second).Should(HaveKeyWithValue("success", true))
+
+		result = <-slow.Broker.Call("jsslow.sleep", map[string]interface{}{"ms": 10})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("slept").Int()).Should(Equal(10))
+	})
+
+	It("JS → Go should fail with a RequestTimeoutError after the per-call timeout", func() {
+		result := <-slow.Broker.Call("jsslow.callgo", map[string]interface{}{
+			"action":  "sleep",
+			"params":  map[string]interface{}{"ms": 1500},
+			"timeout": 300,
+		})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("error").Value()).Should(Equal(map[string]interface{}{
+			"name":      "RequestTimeoutError",
+			"message":   "Request is timed out when call 'goslow.sleep' action on 'go-node' node.",
+			"code":      504.0,
+			"type":      "REQUEST_TIMEOUT",
+			"retryable": true,
+			"data":      map[string]interface{}{"action": "goslow.sleep", "nodeID": "go-node"},
+			"nodeID":    "js-node",
+		}))
+	})
+
+	It("JS → Go should fail with a RequestTimeoutError after the requestTimeout of the JS broker", func() {
+		result := <-slow.Broker.Call("jsslow.callgo", map[string]interface{}{
+			"action": "sleep",
+			"params": map[string]interface{}{"ms": 1500},
+		})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("error").Get("name").String()).Should(Equal("RequestTimeoutError"))
+	})
+
+	It("JS → Go should let the Go handler read the per-call timeout or the requestTimeout", func() {
+		result := <-slow.Broker.Call("jsslow.callgo", map[string]interface{}{"action": "deadline", "timeout": 300})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("data").Int()).Should(Equal(300))
+
+		result = <-slow.Broker.Call("jsslow.callgo", map[string]interface{}{"action": "deadline"})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("data").Int()).Should(Equal(int(JSRequestTimeout / time.Millisecond)))
+	})
+
+	It("JS → JS → Go should let the Go handler read what remains of the timeout", func() {
+		result := <-slow.Broker.Call("jsslow.relay", map[string]interface{}{"timeout": 1000, "delay": 300})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Int()).Should(And(BeNumerically(">", 0), BeNumerically("<=", 700)))
+	})
+
+	It("JS → Go should discard the late RESPONSE", func() {
+		result := <-slow.Broker.Call("jsslow.callgo", map[string]interface{}{
+			"action":  "sleep",
+			"params":  map[string]interface{}{"ms": 600},
+			"timeout": 200,
+		})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("error").Get("name").String()).Should(Equal("RequestTimeoutError"))
+
+		Eventually(func() map[string]interface{} {
+			_, response := exchange(recorder.Packets(), "goslow.sleep")
+			return response
+		}, 2*time.Second).Should(HaveKeyWithValue("success", true))
+		Expect(slow.JS.Running()).Should(BeTrue())
+		result = <-slow.Broker.Call("jsslow.callgo", map[string]interface{}{
+			"action":  "sleep",
+			"params":  map[string]interface{}{"ms": 10},
+			"timeout": 1000,
+		})
+		Expect(result.Error()).ShouldNot(HaveOccurred())
+		Expect(result.Get("data").Get("slept").Int()).Should(Equal(10))
+	})
+})
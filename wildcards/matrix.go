@@ -0,0 +1,82 @@
+// Package wildcards checks the event patterns of listeners across moleculer-go and moleculer JS:
+// a listener of each pattern of Patterns on a Go node and on a JS node, and each event of Events
+// emitted by a Go node and by a JS node.
+//
+// moleculer JS matches the patterns with wildcards (see harness.MatchEvent) twice: to choose the
+// nodes it sends an event to, and to choose the local listeners of an event it receives.
+// moleculer-go has no wildcards: it sends an event only to listeners of the same name and calls only
+// the local listeners of the same name, so a Go listener of "user.*" gets no event at all.
+package wildcards
+
+import (
+	"sort"
+
+	"github.com/moleculer-go/compatibility/harness"
+)
+
+// Patterns are the patterns of the listeners: exact, one segment, any segments, a wildcard in the middle
+// of a segment, a leading segment, any name without a dot, any name and an internal name.
+var Patterns = []string{"user.created", "user.*", "user.**", "user.*ed", "*.created", "*", "**", "$user.*"}
+
+// Events are the events emitted.
+var Events = []string{"user.created", "user.updated", "user.profile.updated", "ping", "account.created", "$user.created"}
+
+// Delivery is the entry of an event delivered to the listener of a pattern.
+func Delivery(pattern, event string) string {
+	return pattern + " <- " + event
+}
+
+// exact is how moleculer-go matches a pattern: the same name only.
+func exact(name, pattern string) bool {
+	return name == pattern
+}
+
+// Expected returns the sorted deliveries of Events to the listeners of Patterns, when the emitter and
+// the listeners are Go nodes or JS nodes.
+func Expected(goEmitter, goListener bool) []string {
+	sends, calls := harness.MatchEvent, harness.MatchEvent
+	if goEmitter {
+		sends = exact
+	}
+	if goListener {
+		calls = exact
+	}
+	deliveries := []string{}
+	for _, event := range Events {
+		if !matchesAny(event, sends) {
+			continue
+		}
+		for _, pattern := range Patterns {
+			if calls(event, pattern) {
+				deliveries = append(deliveries, Delivery(pattern, event))
+			}
+		}
+	}
+	sort.Strings(deliveries)
+	return deliveries
+}
+
+// Sent returns the sorted events an emitter sends to a node with the listeners of Patterns.
+func Sent(goEmitter bool) []string {
+	sends := harness.MatchEvent
+	if goEmitter {
+		sends = exact
+	}
+	sent := []string{}
+	for _, event := range Events {
+		if matchesAny(event, sends) {
+			sent = append(sent, event)
+		}
+	}
+	sort.Strings(sent)
+	return sent
+}
+
+func matchesAny(event string, match func(name, pattern string) bool) bool {
+	for _, pattern := range Patterns {
+		if match(event, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package wildcards
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+)
+
+const (
+	// GoEmitter is the Go node that emits Events.
+	GoEmitter = "go-emitter"
+	// GoListener is the Go node with GoListenerService.
+	GoListener = "go-listener"
+	// JSEmitter is the JS node that emits Events.
+	JSEmitter = "js-emitter"
+	// JSListener is the JS node with the listeners of Patterns.
+	JSListener = "js-listener"
+)
+
+// ListenerService returns the service of the listeners of Patterns, named name:
+//   - <name>.received returns the sorted deliveries of Events, see Delivery;
+//   - <name>.reset forgets them.
+func ListenerService(name string) moleculer.ServiceSchema {
+	var mutex sync.Mutex
+	received := []string{}
+	service := moleculer.ServiceSchema{
+		Name: name,
+		Actions: []moleculer.Action{
+			{
+				Name: "received",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					sorted := append([]string{}, received...)
+					sort.Strings(sorted)
+					return sorted
+				},
+			},
+			{
+				Name: "reset",
+				Handler: func(ctx moleculer.Context, params moleculer.Payload) interface{} {
+					mutex.Lock()
+					defer mutex.Unlock()
+					received = []string{}
+					return len(received)
+				},
+			},
+		},
+	}
+	for _, item := range Patterns {
+		pattern := item
+		service.Events = append(service.Events, moleculer.Event{
+			Name: pattern,
+			Handler: func(ctx moleculer.Context, params moleculer.Payload) {
+				event := ctx.(moleculer.BrokerContext).EventName()
+				mutex.Lock()
+				defer mutex.Unlock()
+				received = append(received, Delivery(pattern, event))
+			},
+		})
+	}
+	return service
+}
+
+// Emit emits each of Events from a Go broker.
+func Emit(emitter *broker.ServiceBroker) {
+	for _, event := range Events {
+		emitter.Emit(event, map[string]interface{}{"event": event})
+	}
+}
+
+// Received returns the sorted deliveries of the listener service name, asked by bkr.
+func Received(bkr *broker.ServiceBroker, name string) ([]string, error) {
+	result := <-bkr.Call(name+".received", map[string]interface{}{})
+	if result.IsError() {
+		return nil, result.Error()
+	}
+	received := []string{}
+	for _, delivery := range result.Array() {
+		received = append(received, delivery.String())
+	}
+	return received, nil
+}
+
+// Reset forgets the deliveries of the listener service name, asked by bkr.
+func Reset(bkr *broker.ServiceBroker, name string) error {
+	return (<-bkr.Call(name+".reset", map[string]interface{}{})).Error()
+}
+
+// Cluster is the Go emitter, the Go listener and wildcards.js with the JS emitter and the JS
+// listener, connected over NATS.
+type Cluster struct {
+	Emitter  *broker.ServiceBroker
+	Listener *broker.ServiceBroker
+	JS       *harness.Peer
+}
+
+// natsBroker creates a broker connected to the NATS server at url.
+func natsBroker(nodeID, url string) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel: "WARN",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return harness.NATSTransporter(url)()
+		},
+	})
+}
+
+// Start starts wildcards.js, the Go emitter and the Go listener with the gowildcards service, all
+// connected to the NATS server at url, and waits until each emitter sees both listeners.
+func Start(dir, url string) (*Cluster, error) {
+	js, err := harness.StartNode(dir, "wildcards.js", map[string]string{}, url)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &Cluster{JS: js, Emitter: natsBroker(GoEmitter, url), Listener: natsBroker(GoListener, url)}
+	cluster.Listener.Publish(ListenerService("gowildcards"))
+	cluster.Listener.Start()
+	cluster.Emitter.Start()
+
+	deadline := time.Now().Add(harness.StartTimeout)
+	if err := harness.WaitForServices(cluster.Emitter, deadline, "gowildcards", "jswildcards", "jsemitter"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	if err := harness.WaitForServicesIn(cluster.Emitter, deadline, "jsemitter.services", "gowildcards", "jswildcards"); err != nil {
+		cluster.Stop()
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// EmitJS asks the JS emitter to emit each of Events.
+func (cluster *Cluster) EmitJS() error {
+	return (<-cluster.Emitter.Call("jsemitter.emit", map[string]interface{}{"events": Events})).Error()
+}
+
+// Stop stops the Go nodes and kills wildcards.js.
+func (cluster *Cluster) Stop() {
+	cluster.Emitter.Stop()
+	cluster.Listener.Stop()
+	cluster.JS.Kill()
+}
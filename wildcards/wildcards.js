@@ -0,0 +1,60 @@
+"use strict";
+
+// The JS nodes of the wildcards suite (see matrix.go), with the NATS url as first argument:
+//   js-listener has the jswildcards service, with a listener per pattern of the "patterns" below.
+//     jswildcards.received returns the sorted deliveries of the "events" below, as "<pattern> <- <event>";
+//     jswildcards.reset    forgets them.
+//   js-emitter has the jsemitter service:
+//     jsemitter.services returns $node.services of js-emitter;
+//     jsemitter.emit     emits each of the "events" param.
+
+const transporter = process.argv[2];
+console.log("Start Moleculer JS wildcards with transporter: " + transporter);
+
+const { ServiceBroker } = require("moleculer");
+
+const patterns = ["user.created", "user.*", "user.**", "user.*ed", "*.created", "*", "**", "$user.*"];
+const events = ["user.created", "user.updated", "user.profile.updated", "ping", "account.created", "$user.created"];
+
+const listener = new ServiceBroker({ transporter, nodeID: "js-listener", logLevel: "warn" });
+let received = [];
+const listeners = {};
+for (const pattern of patterns) {
+	listeners[pattern] = function(ctx) {
+		// "**" and "*.created" also get the internal events of moleculer JS.
+		if (events.includes(ctx.eventName)) {
+			received.push(pattern + " <- " + ctx.eventName);
+		}
+	};
+}
+listener.createService({
+	name: "jswildcards",
+	actions: {
+		received() {
+			return received.slice().sort();
+		},
+		reset() {
+			received = [];
+			return received.length;
+		}
+	},
+	events: listeners
+});
+
+const emitter = new ServiceBroker({ transporter, nodeID: "js-emitter", logLevel: "warn" });
+emitter.createService({
+	name: "jsemitter",
+	actions: {
+		services(ctx) {
+			return ctx.call("$node.services", ctx.params);
+		},
+		emit(ctx) {
+			for (const event of ctx.params.events) {
+				emitter.emit(event, { event });
+			}
+			return ctx.params.events.length;
+		}
+	}
+});
+
+Promise.all([listener.start(), emitter.start()]);
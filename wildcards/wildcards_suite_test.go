@@ -0,0 +1,20 @@
+package wildcards
+
+import (
+	"testing"
+
+	"github.com/moleculer-go/compatibility/harness"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWildcards(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Wildcards Suite")
+}
+
+var _ = AfterSuite(func() {
+	lazyCluster.Stop()
+	harness.StopEmbeddedNATS()
+})
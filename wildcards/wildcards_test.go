@@ -0,0 +1,220 @@
+package wildcards
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/moleculer-go/compatibility/harness"
+	"github.com/moleculer-go/moleculer"
+	"github.com/moleculer-go/moleculer/broker"
+	"github.com/moleculer-go/moleculer/transit/memory"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cluster is started by lazyCluster.
+var cluster *Cluster
+
+// lazyCluster starts the Go and JS nodes once, for all the specs that need moleculer JS.
+var lazyCluster = harness.NewLazyFixture(func() (harness.Fixture, error) {
+	var err error
+	cluster, err = Start(".", harness.NATSURL())
+	return cluster, err
+})
+
+// memoryBroker creates a broker on the memory transporter shared by the brokers created from mem,
+// with its packets recorded by packets.
+func memoryBroker(nodeID string, mem *memory.SharedMemory, packets *harness.PacketRecorder) *broker.ServiceBroker {
+	return broker.New(&moleculer.Config{
+		LogLevel: "ERROR",
+		DiscoverNodeID: func() string {
+			return nodeID
+		},
+		TransporterFactory: func() interface{} {
+			return packets.Wrap(harness.MemoryTransporter(mem)())
+		},
+	})
+}
+
+// settled waits until the listener service name got the expected deliveries and no more.
+func settled(bkr *broker.ServiceBroker, name string, expected []string) {
+	received := func() []string {
+		deliveries, err := Received(bkr, name)
+		Expect(err).ShouldNot(HaveOccurred())
+		return deliveries
+	}
+	Eventually(received, 5*time.Second, 50*time.Millisecond).Should(Equal(expected))
+	Consistently(received, 300*time.Millisecond, 50*time.Millisecond).Should(Equal(expected))
+}
+
+// eventsTo returns the sorted names of the EVENT packets recorded in direction to nodeID.
+func eventsTo(packets *harness.PacketRecorder, direction, nodeID string) []string {
+	names := []string{}
+	for _, packet := range packets.Packets() {
+		if packet.Type != "EVENT" || packet.Direction != direction || packet.Target != nodeID {
+			continue
+		}
+		fields, _ := packet.Payload.(map[string]interface{})
+		name, _ := fields["event"].(string)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var _ = Describe("Wildcard matrix", func() {
+	It("JS nodes should deliver each event to the listeners of the patterns it matches", func() {
+		Expect(Expected(false, false)).Should(Equal([]string{
+			Delivery("$user.*", "$user.created"),
+			Delivery("*", "ping"),
+			Delivery("**", "$user.created"),
+			Delivery("**", "account.created"),
+			Delivery("**", "ping"),
+			Delivery("**", "user.created"),
+			Delivery("**", "user.profile.updated"),
+			Delivery("**", "user.updated"),
+			Delivery("*.created", "$user.created"),
+			Delivery("*.created", "account.created"),
+			Delivery("*.created", "user.created"),
+			Delivery("user.*", "user.created"),
+			Delivery("user.*", "user.updated"),
+			Delivery("user.**", "user.created"),
+			Delivery("user.**", "user.profile.updated"),
+			Delivery("user.**", "user.updated"),
+			Delivery("user.*ed", "user.created"),
+			Delivery("user.*ed", "user.updated"),
+			Delivery("user.created", "user.created"),
+		}))
+		Expect(Sent(false)).Should(ConsistOf(Events))
+	})
+
+	It("Go nodes should deliver an event only to the listeners of its name", func() {
+		onlyExact := []string{Delivery("user.created", "user.created")}
+		Expect(Expected(true, true)).Should(Equal(onlyExact))
+		Expect(Expected(false, true)).Should(Equal(onlyExact))
+		Expect(Sent(true)).Should(Equal([]string{"user.created"}))
+	})
+
+	It("a JS listener of a Go emitter should only miss the events without a listener of their name", func() {
+		Expect(Expected(true, false)).Should(Equal([]string{
+			Delivery("**", "user.created"),
+			Delivery("*.created", "user.created"),
+			Delivery("user.*", "user.created"),
+			Delivery("user.**", "user.created"),
+			Delivery("user.*ed", "user.created"),
+			Delivery("user.created", "user.created"),
+		}))
+	})
+})
+
+var _ = Describe("Go wildcard listeners", func() {
+	var dir string
+	var packets *harness.PacketRecorder
+	var mem *memory.SharedMemory
+	var emitter, listener *broker.ServiceBroker
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "wildcards")
+		Expect(err).ShouldNot(HaveOccurred())
+		packets, err = harness.NewPacketRecorder("Memory", filepath.Join(dir, "packets.jsonl"))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		mem = &memory.SharedMemory{}
+		listener = memoryBroker(GoListener, mem, packets)
+		listener.Publish(ListenerService("gowildcards"))
+		listener.Start()
+		emitter = memoryBroker(GoEmitter, mem, packets)
+		emitter.Start()
+		Expect(harness.WaitForServices(emitter, time.Now().Add(5*time.Second), "gowildcards")).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		emitter.Stop()
+		listener.Stop()
+		packets.Close(false)
+		os.RemoveAll(dir)
+	})
+
+	It("a Go emitter should only reach the Go listeners of the same name", func() {
+		Emit(emitter)
+		settled(emitter, "gowildcards", Expected(true, true))
+		Expect(eventsTo(packets, "out", GoListener)).Should(Equal(Sent(true)))
+	})
+
+	Context("with a replayed JS listener", func() {
+		var peer *harness.ReplayPeer
+
+		BeforeEach(func() {
+			events := map[string]interface{}{}
+			for _, pattern := range Patterns {
+				events[pattern] = map[string]interface{}{"name": pattern}
+			}
+			peer = harness.NewReplayPeer(JSListener, harness.MemoryTransporter(mem)(), harness.ReplayInfo(map[string]interface{}{
+				"name":     "jswildcards",
+				"settings": map[string]interface{}{},
+				"metadata": map[string]interface{}{},
+				"actions":  map[string]interface{}{},
+				"events":   events,
+			}))
+			Expect(peer.Start()).Should(Succeed())
+			Expect(harness.WaitForServices(emitter, time.Now().Add(5*time.Second), "jswildcards")).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			peer.Kill()
+		})
+
+		It("a Go emitter should only send the events with a JS listener of the same name", func() {
+			Emit(emitter)
+			settled(emitter, "gowildcards", Expected(true, true))
+			Expect(eventsTo(packets, "out", JSListener)).Should(Equal(Sent(true)))
+		})
+	})
+
+	Context("with a replayed JS emitter", func() {
+		var peer *harness.ReplayPeer
+
+		BeforeEach(func() {
+			peer = harness.NewReplayPeer(JSEmitter, harness.MemoryTransporter(mem)(), harness.ReplayInfo())
+			Expect(peer.Start()).Should(Succeed())
+			Eventually(peer.Services, 5*time.Second).Should(ContainElement(HaveKeyWithValue("name", "gowildcards")))
+		})
+
+		AfterEach(func() {
+			peer.Kill()
+		})
+
+		It("the Go listeners should get the wildcard matches of a JS emitter but only call the listeners of the same name", func() {
+			for _, event := range Events {
+				peer.Emit(event, map[string]interface{}{"event": event})
+			}
+			settled(emitter, "gowildcards", Expected(false, true))
+			Expect(eventsTo(packets, "in", GoListener)).Should(Equal(Sent(false)))
+		})
+	})
+})
+
+var _ = Describe("Wildcard events with moleculer JS", func() {
+	BeforeEach(func() {
+		lazyCluster.Start()
+		Expect(Reset(cluster.Emitter, "gowildcards")).Should(Succeed())
+		Expect(Reset(cluster.Emitter, "jswildcards")).Should(Succeed())
+	})
+
+	It("a JS emitter should reach the JS listeners by wildcard and the Go listeners by name", func() {
+		Expect(cluster.EmitJS()).Should(Succeed())
+		settled(cluster.Emitter, "jswildcards", Expected(false, false))
+		settled(cluster.Emitter, "gowildcards", Expected(false, true))
+	})
+
+	It("a Go emitter should reach the JS and Go listeners of the events with a listener of the same name", func() {
+		Emit(cluster.Emitter)
+		settled(cluster.Emitter, "jswildcards", Expected(true, false))
+		settled(cluster.Emitter, "gowildcards", Expected(true, true))
+	})
+})